@@ -0,0 +1,470 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/cli/config"
+	"github.com/MFCaballero/simple-quiz/internal/domain/backup"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/spf13/cobra"
+)
+
+func AdminCommand(config config.Config) *cobra.Command {
+	var adminCmd = &cobra.Command{
+		Use:   "admin",
+		Short: "Manage the quiz",
+	}
+
+	adminCmd.AddCommand(QuestionStatsCommand(config))
+	adminCmd.AddCommand(ExportResultsCommand(config))
+	adminCmd.AddCommand(ImportQuestionsCommand(config))
+	adminCmd.AddCommand(LintQuestionsCommand())
+	adminCmd.AddCommand(UserTimelineCommand(config))
+	adminCmd.AddCommand(BackupCommand(config))
+	adminCmd.AddCommand(RestoreCommand(config))
+
+	return adminCmd
+}
+
+func QuestionStatsCommand(config config.Config) *cobra.Command {
+	var statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show per question item analysis",
+		Run: func(cmd *cobra.Command, args []string) {
+			analysis, err := getQuestionStats(apiURL(config))
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("**** Item Analysis (%d quizzers finished) ****\n", analysis.Participants)
+			for _, question := range analysis.Questions {
+				fmt.Printf("%s) %s\n", question.QuestionID, question.Question)
+				fmt.Printf("   Responses: %d  Difficulty: %.2f  Discrimination: %.2f\n", question.Responses, question.Difficulty, question.Discrimination)
+				var correctRate float64
+				for _, option := range question.Options {
+					marker := " "
+					if option.IsCorrect {
+						marker = "*"
+						correctRate = option.SelectionRate
+					}
+					fmt.Printf("   %s %s %-30s %5.1f%% (%d)\n", marker, option.ID, option.Label, option.SelectionRate*100, option.Selections)
+				}
+				if question.Responses == 0 {
+					continue
+				}
+				if question.Difficulty == 0 {
+					fmt.Println("   WARNING: nobody answered this question correctly")
+				}
+				for _, option := range question.Options {
+					if !option.IsCorrect && option.SelectionRate > correctRate {
+						fmt.Printf("   WARNING: wrong option %s is chosen more often than the correct one\n", option.ID)
+					}
+				}
+			}
+		},
+	}
+
+	return statsCmd
+}
+
+func ExportResultsCommand(config config.Config) *cobra.Command {
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export quiz results to a file",
+		Run: func(cmd *cobra.Command, args []string) {
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				log.Fatal(err)
+			}
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if output == "" {
+				output = "results." + format
+			}
+			if err := exportResults(apiURL(config), format, output); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Results exported to %s\n", output)
+		},
+	}
+	exportCmd.Flags().StringP("format", "f", "csv", "Export format, csv or jsonl")
+	exportCmd.Flags().StringP("output", "o", "", "Output file (default results.<format>)")
+
+	return exportCmd
+}
+
+func ImportQuestionsCommand(config config.Config) *cobra.Command {
+	var importCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import questions from a CSV, YAML or GIFT file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(args[0]), ".")
+			}
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				log.Fatal(err)
+			}
+			replace, err := cmd.Flags().GetBool("replace")
+			if err != nil {
+				log.Fatal(err)
+			}
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			report, err := importQuestions(apiURL(config), format, dryRun, replace, content)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(report.Errors) > 0 {
+				fmt.Printf("%s has %d problems, nothing was imported:\n", args[0], len(report.Errors))
+				for _, importErr := range report.Errors {
+					fmt.Printf("  line %d: %s\n", importErr.Line, importErr.Message)
+				}
+				os.Exit(1)
+			}
+			if len(report.Issues) > 0 {
+				fmt.Printf("Importing %s would leave the quiz invalid, nothing was imported:\n", args[0])
+				for _, issue := range report.Issues {
+					fmt.Println(" ", issue)
+				}
+				os.Exit(1)
+			}
+			verb := "Imported"
+			if report.DryRun {
+				verb = "Would import"
+			}
+			fmt.Printf("%s %d questions (%s), the quiz has %d questions\n", verb, report.Imported, strings.Join(report.QuestionIDs, ", "), report.Total)
+		},
+	}
+	importCmd.Flags().StringP("format", "f", "", "File format, csv, yaml or gift (default from the file extension)")
+	importCmd.Flags().Bool("dry-run", false, "Validate the file and report what would be imported")
+	importCmd.Flags().Bool("replace", false, "Replace the current questions instead of appending")
+
+	return importCmd
+}
+
+func LintQuestionsCommand() *cobra.Command {
+	var lintCmd = &cobra.Command{
+		Use:   "lint <file>",
+		Short: "Check a questions file for mistakes",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			strict, err := cmd.Flags().GetBool("strict")
+			if err != nil {
+				log.Fatal(err)
+			}
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			questions := model.QuestionMap{}
+			if err := json.Unmarshal(content, &questions); err != nil {
+				log.Fatalf("%s is not a valid questions file: %v", args[0], err)
+			}
+
+			issues := model.ValidateQuestions(questions)
+			for _, issue := range issues {
+				fmt.Println(issue)
+			}
+			errorCount, warningCount := len(issues.Errors()), len(issues.Warnings())
+			fmt.Printf("%d questions checked: %d errors, %d warnings\n", len(questions), errorCount, warningCount)
+			if errorCount > 0 || (strict && warningCount > 0) {
+				os.Exit(1)
+			}
+		},
+	}
+	lintCmd.Flags().Bool("strict", false, "Fail on warnings too")
+
+	return lintCmd
+}
+
+func UserTimelineCommand(config config.Config) *cobra.Command {
+	var timelineCmd = &cobra.Command{
+		Use:   "timeline <user>",
+		Short: "Replay everything a quizzer did",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			timeline, err := getUserTimeline(apiURL(config), args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("**** Timeline of user %s ****\n", timeline.UserID)
+			for _, step := range timeline.Steps {
+				event := step.Event
+				at := event.At.Local().Format("2006-01-02 15:04:05")
+				switch event.Type {
+				case model.EventStarted:
+					fmt.Printf("%s  started as %s\n", at, event.Name)
+				case model.EventAnswered:
+					fmt.Printf("%s  answered question %s with %s\n", at, event.QuestionID, event.Option.ID)
+				case model.EventAnswerChanged:
+					fmt.Printf("%s  changed question %s to %s\n", at, event.QuestionID, event.Option.ID)
+				case model.EventFinished:
+					fmt.Printf("%s  finished with a score of %.0f%%\n", at, event.Score*100)
+				}
+			}
+			fmt.Printf("%d answers, changed %d times\n", len(timeline.User.Answers), timeline.AnswerChanges)
+		},
+	}
+
+	return timelineCmd
+}
+
+func BackupCommand(config config.Config) *cobra.Command {
+	var backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Download a backup of users, questions and events",
+		Run: func(cmd *cobra.Command, args []string) {
+			output, err := cmd.Flags().GetString("output")
+			if err != nil {
+				log.Fatal(err)
+			}
+			output, err = downloadBackup(apiURL(config), output)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Backup saved to %s\n", output)
+		},
+	}
+	backupCmd.Flags().StringP("output", "o", "", "Output file (default the name given by the server)")
+
+	return backupCmd
+}
+
+func RestoreCommand(config config.Config) *cobra.Command {
+	var restoreCmd = &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Replace users, questions and events with a backup",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dryRun, err := cmd.Flags().GetBool("dry-run")
+			if err != nil {
+				log.Fatal(err)
+			}
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			report, err := restoreBackup(apiURL(config), dryRun, content)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Backup of %s (schema version %d)\n", report.CreatedAt.Local().Format("2006-01-02 15:04:05"), report.SchemaVersion)
+			fmt.Printf("  users: %s\n", describeChanges(report.Users.Added, report.Users.Removed, report.Users.Changed))
+			fmt.Printf("  questions: %s\n", describeChanges(report.Questions.Added, report.Questions.Removed, report.Questions.Changed))
+			fmt.Printf("  events: %d now, %d in the backup\n", report.Events.Current, report.Events.Restored)
+			for _, issue := range report.Issues {
+				fmt.Println(" ", issue)
+			}
+			switch {
+			case report.Issues.HasErrors():
+				fmt.Println("The backup is invalid, nothing was restored")
+				os.Exit(1)
+			case report.DryRun:
+				fmt.Println("Dry run, nothing was restored")
+			default:
+				fmt.Println("Backup restored")
+			}
+		},
+	}
+	restoreCmd.Flags().Bool("dry-run", false, "Report what would change without restoring")
+
+	return restoreCmd
+}
+
+func describeChanges(added, removed, changed []string) string {
+	if len(added)+len(removed)+len(changed) == 0 {
+		return "no changes"
+	}
+	return fmt.Sprintf("%d added, %d removed, %d changed", len(added), len(removed), len(changed))
+}
+
+type itemAnalysis struct {
+	Participants int `json:"participants"`
+	Questions    []struct {
+		QuestionID     string  `json:"question_id"`
+		Question       string  `json:"question"`
+		Responses      int     `json:"responses"`
+		Difficulty     float64 `json:"difficulty"`
+		Discrimination float64 `json:"discrimination"`
+		Options        []struct {
+			ID            string  `json:"id"`
+			Label         string  `json:"label"`
+			IsCorrect     bool    `json:"is_correct"`
+			Selections    int     `json:"selections"`
+			SelectionRate float64 `json:"selection_rate"`
+		} `json:"options"`
+	} `json:"questions"`
+}
+
+func getQuestionStats(url string) (*itemAnalysis, error) {
+	resp, err := http.Get(url + "/admin/stats")
+	if err != nil {
+		return nil, fmt.Errorf("error getting question statistics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, processErrorResponse(resp)
+	}
+
+	analysis := &itemAnalysis{}
+	if err := json.NewDecoder(resp.Body).Decode(analysis); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return analysis, nil
+}
+
+func exportResults(url, format, output string) error {
+	resp, err := http.Get(url + "/admin/results?format=" + format)
+	if err != nil {
+		return fmt.Errorf("error exporting results: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return processErrorResponse(resp)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", output, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return fmt.Errorf("error writing %s: %v", output, err)
+	}
+
+	return nil
+}
+
+type importReport struct {
+	DryRun      bool     `json:"dry_run"`
+	Imported    int      `json:"imported"`
+	Total       int      `json:"total"`
+	QuestionIDs []string `json:"question_ids"`
+	Errors      []struct {
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Issues model.Issues `json:"issues"`
+}
+
+func importQuestions(url, format string, dryRun, replace bool, content []byte) (*importReport, error) {
+	query := fmt.Sprintf("format=%s&dry_run=%t&replace=%t", format, dryRun, replace)
+	resp, err := http.Post(url+"/admin/questions/import?"+query, "application/octet-stream", bytes.NewBuffer(content))
+	if err != nil {
+		return nil, fmt.Errorf("error importing questions: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusUnprocessableEntity {
+		return nil, processErrorResponse(resp)
+	}
+
+	report := &importReport{}
+	if err := json.NewDecoder(resp.Body).Decode(report); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return report, nil
+}
+
+type userTimeline struct {
+	UserID        string `json:"user_id"`
+	AnswerChanges int    `json:"answer_changes"`
+	Steps         []struct {
+		Event    model.Event `json:"event"`
+		Answered int         `json:"answered"`
+	} `json:"steps"`
+	User model.User `json:"user"`
+}
+
+func getUserTimeline(url, userID string) (*userTimeline, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/admin/users/%s/timeline", url, userID))
+	if err != nil {
+		return nil, fmt.Errorf("error getting user timeline: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, processErrorResponse(resp)
+	}
+
+	timeline := &userTimeline{}
+	if err := json.NewDecoder(resp.Body).Decode(timeline); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return timeline, nil
+}
+
+func downloadBackup(url, output string) (string, error) {
+	resp, err := http.Get(url + "/admin/backup")
+	if err != nil {
+		return "", fmt.Errorf("error backing up: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", processErrorResponse(resp)
+	}
+
+	if output == "" {
+		output = "quiz-backup.tar.gz"
+		if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			output = filepath.Base(params["filename"])
+		}
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return "", fmt.Errorf("error creating %s: %v", output, err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", output, err)
+	}
+
+	return output, nil
+}
+
+func restoreBackup(url string, dryRun bool, content []byte) (*backup.Report, error) {
+	resp, err := http.Post(fmt.Sprintf("%s/admin/restore?dry_run=%t", url, dryRun), "application/gzip", bytes.NewBuffer(content))
+	if err != nil {
+		return nil, fmt.Errorf("error restoring backup: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnprocessableEntity {
+		return nil, processErrorResponse(resp)
+	}
+
+	report := &backup.Report{}
+	if err := json.NewDecoder(resp.Body).Decode(report); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return report, nil
+}
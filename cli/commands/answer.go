@@ -63,7 +63,7 @@ func AnswerQuestionCommand(config config.Config) *cobra.Command {
 				QuestionID: question,
 				OptionID:   option,
 			}
-			if err := answerQuestion(req, config.BackendURL, userID); err != nil {
+			if err := answerQuestion(req, apiURL(config), userID); err != nil {
 				log.Fatal(err)
 			}
 			fmt.Println("Question answered")
@@ -84,7 +84,7 @@ func GetAnsweredCommand(config config.Config) *cobra.Command {
 		Short: "Get answered questions",
 		Run: func(cmd *cobra.Command, args []string) {
 			userID := cmd.Context().Value(userID).(string)
-			answered, err := getUserAnswers(config.BackendURL, userID)
+			answered, err := getUserAnswers(apiURL(config), userID)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -104,7 +104,7 @@ func FinishQuizCommand(config config.Config) *cobra.Command {
 		Short: "Finish the quiz",
 		Run: func(cmd *cobra.Command, args []string) {
 			userID := cmd.Context().Value(userID).(string)
-			if err := finishQuiz(config.BackendURL, userID); err != nil {
+			if err := finishQuiz(apiURL(config), userID); err != nil {
 				log.Fatal(err)
 			}
 			fmt.Println("Quiz finished")
@@ -120,7 +120,7 @@ func GetScoreCommand(config config.Config) *cobra.Command {
 		Short: "Get user score",
 		Run: func(cmd *cobra.Command, args []string) {
 			userID := cmd.Context().Value(userID).(string)
-			scoreData, err := getUserScoreData(config.BackendURL, userID)
+			scoreData, err := getUserScoreData(apiURL(config), userID)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -200,6 +200,10 @@ func answerQuestion(body answerRequest, url, userID string) error {
 }
 
 func getUserAnswers(url, userID string) ([]userAnswer, error) {
+	if pagedAPI(url) {
+		return getAllPages[userAnswer](fmt.Sprintf("%s/users/%s/answered", url, userID), "answers")
+	}
+
 	resp, err := http.Get(fmt.Sprintf("%s/users/%s/answered", url, userID))
 	if err != nil {
 		return nil, fmt.Errorf("error getting user's answered questions: %v", err)
@@ -256,6 +260,15 @@ func processErrorResponse(resp *http.Response) error {
 	if readErr != nil {
 		return fmt.Errorf("unexpected status code: %d, unable to read response body: %v", resp.StatusCode, readErr)
 	}
+	// From v2 on, errors come in an envelope.
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(errorMessage, &envelope) == nil && envelope.Error.Message != "" {
+		errorMessage = []byte(envelope.Error.Message)
+	}
 
 	switch resp.StatusCode {
 	case http.StatusBadRequest:
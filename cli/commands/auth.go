@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"net/http"
+	"strings"
+)
+
+// adminTransport sends the admin token with the requests to the /admin
+// routes, and only with them.
+type adminTransport struct {
+	next  http.RoundTripper
+	token string
+}
+
+// NewAdminTransport makes next authenticate the requests to the /admin
+// routes with token. Without a token, requests are sent as they are.
+func NewAdminTransport(next http.RoundTripper, token string) http.RoundTripper {
+	if token == "" {
+		return next
+	}
+	return &adminTransport{next: next, token: token}
+}
+
+func (at *adminTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.Contains(req.URL.Path, "/admin/") {
+		return at.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+at.token)
+	return at.next.RoundTrip(req)
+}
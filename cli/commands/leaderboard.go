@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/cli/config"
+	"github.com/MFCaballero/simple-quiz/cli/session"
+	"github.com/spf13/cobra"
+)
+
+func LeaderboardCommand(sessionManager *session.SessionManager, config config.Config) *cobra.Command {
+	var leaderboardCmd = &cobra.Command{
+		Use:   "leaderboard",
+		Short: "Show the quiz ranking",
+		Run: func(cmd *cobra.Command, args []string) {
+			top, err := cmd.Flags().GetInt("top")
+			if err != nil {
+				log.Fatal(err)
+			}
+			offset, err := cmd.Flags().GetInt("offset")
+			if err != nil {
+				log.Fatal(err)
+			}
+			since, err := cmd.Flags().GetDuration("since")
+			if err != nil {
+				log.Fatal(err)
+			}
+			var userID string
+			if session, err := sessionManager.GetSession(); err == nil && session != nil {
+				userID = session.ID
+			}
+
+			query := url.Values{}
+			query.Set("limit", strconv.Itoa(top))
+			query.Set("offset", strconv.Itoa(offset))
+			if userID != "" {
+				query.Set("user", userID)
+			}
+			if since > 0 {
+				query.Set("from", time.Now().Add(-since).UTC().Format(time.RFC3339))
+			}
+			page, err := getLeaderboard(apiURL(config), query)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Println("**** Leaderboard ****")
+			if len(page.Entries) == 0 {
+				fmt.Println("Nobody has finished the quiz yet")
+			}
+			var listed bool
+			for _, entry := range page.Entries {
+				printLeaderboardEntry(entry, entry.UserID == userID)
+				listed = listed || entry.UserID == userID
+			}
+			if page.User != nil && !listed {
+				fmt.Println("   ...")
+				printLeaderboardEntry(*page.User, true)
+			}
+			if page.NextOffset > 0 {
+				fmt.Printf("Showing %d of %d quizzers, use --offset %d to see more\n", page.NextOffset, page.Total, page.NextOffset)
+			}
+		},
+	}
+	leaderboardCmd.Flags().IntP("top", "n", 10, "Number of positions to show")
+	leaderboardCmd.Flags().Int("offset", 0, "Number of positions to skip")
+	leaderboardCmd.Flags().Duration("since", 0, "Only rank quizzers that finished within this period, e.g. 24h")
+
+	return leaderboardCmd
+}
+
+func printLeaderboardEntry(entry leaderboardEntry, isCaller bool) {
+	marker, suffix := " ", ""
+	if isCaller {
+		marker, suffix = "*", " (you)"
+	}
+	fmt.Printf("%s %3d. %-20s %.0f%%%s\n", marker, entry.Rank, entry.Name, entry.Score*100, suffix)
+}
+
+type leaderboardEntry struct {
+	Rank   int     `json:"rank"`
+	UserID string  `json:"user_id"`
+	Name   string  `json:"name"`
+	Score  float32 `json:"score"`
+}
+
+type leaderboardPage struct {
+	Entries    []leaderboardEntry `json:"entries"`
+	Total      int                `json:"total"`
+	NextOffset int                `json:"next_offset"`
+	User       *leaderboardEntry  `json:"user"`
+}
+
+func getLeaderboard(url string, query url.Values) (*leaderboardPage, error) {
+	resp, err := http.Get(url + "/leaderboard?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error getting leaderboard: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, processErrorResponse(resp)
+	}
+
+	page := &leaderboardPage{}
+	if err := json.NewDecoder(resp.Body).Decode(page); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+
+	return page, nil
+}
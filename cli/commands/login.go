@@ -29,7 +29,7 @@ func LoginCommand(sessionManager *session.SessionManager, config config.Config)
 			if session != nil {
 				log.Fatalf("Already logged user %s", session.Name)
 			}
-			userID, err := loginUser(name, config.BackendURL)
+			userID, err := loginUser(name, apiURL(config))
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"sort"
 
 	"github.com/MFCaballero/simple-quiz/cli/config"
 	"github.com/MFCaballero/simple-quiz/cli/session"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
 	"github.com/spf13/cobra"
 )
 
@@ -29,13 +30,13 @@ func ListQuestionsCommand(config config.Config) *cobra.Command {
 		Use:   "list",
 		Short: "List all quiz questions",
 		Run: func(cmd *cobra.Command, args []string) {
-			questions, err := listQuestions(config.BackendURL)
+			questions, err := listQuestions(apiURL(config))
 			if err != nil {
 				log.Fatal(err)
 			}
 			fmt.Println("List of Quiz Questions:")
-			for i := 1; i <= len(questions); i++ {
-				fmt.Printf("%d) %v\n", i, questions[strconv.Itoa(i)].Label)
+			for _, question := range questions {
+				fmt.Printf("%s) %v\n", question.ID, question.Label)
 			}
 		},
 	}
@@ -52,7 +53,7 @@ func GetQuestionCommand(config config.Config) *cobra.Command {
 			if err != nil {
 				log.Fatal(err)
 			}
-			question, err := getQuestion(config.BackendURL, questionNumber)
+			question, err := getQuestion(apiURL(config), questionNumber)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -70,14 +71,22 @@ func GetQuestionCommand(config config.Config) *cobra.Command {
 }
 
 type question struct {
-	Label   string `json:"label"`
-	Options []struct {
+	// ID is only set when listing the questions.
+	ID       string `json:"id,omitempty"`
+	Label    string `json:"label"`
+	Position int    `json:"position"`
+	Options  []struct {
 		ID    string `json:"id"`
 		Label string `json:"label"`
 	} `json:"options"`
 }
 
-func listQuestions(url string) (map[string]question, error) {
+// listQuestions returns the questions in the order they are asked.
+func listQuestions(url string) ([]question, error) {
+	if pagedAPI(url) {
+		return getAllPages[question](url+"/questions", "questions")
+	}
+
 	resp, err := http.Get(url + "/questions")
 	if err != nil {
 		return nil, fmt.Errorf("error getting questions: %v", err)
@@ -88,11 +97,22 @@ func listQuestions(url string) (map[string]question, error) {
 		return nil, processErrorResponse(resp)
 	}
 
-	questions := map[string]question{}
-	if err := json.NewDecoder(resp.Body).Decode(&questions); err != nil {
+	byID := map[string]question{}
+	if err := json.NewDecoder(resp.Body).Decode(&byID); err != nil {
 		return nil, fmt.Errorf("error decoding response: %v", err)
 	}
 
+	questions := make([]question, 0, len(byID))
+	for id, question := range byID {
+		question.ID = id
+		questions = append(questions, question)
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		if c := model.CompareKeys(model.PositionKey(questions[i].Position), model.PositionKey(questions[j].Position)); c != 0 {
+			return c < 0
+		}
+		return model.CompareKeys(questions[i].ID, questions[j].ID) < 0
+	})
 	return questions, nil
 }
 
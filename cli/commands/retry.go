@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRetries is how many times a rate limited request is sent again.
+	maxRetries = 3
+	// maxRetryWait is the longest wait honoured before sending a rate
+	// limited request again. Longer waits, such as a login lockout, are
+	// reported instead.
+	maxRetryWait = 30 * time.Second
+)
+
+// retryTransport sends the requests the server rate limited again, once
+// the wait asked for by the Retry-After header is over.
+type retryTransport struct {
+	next http.RoundTripper
+}
+
+// NewRetryTransport makes next honour the rate limits of the server.
+func NewRetryTransport(next http.RoundTripper) http.RoundTripper {
+	return &retryTransport{next: next}
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := rt.next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == maxRetries {
+			return resp, err
+		}
+		wait, ok := retryAfter(resp.Header.Get("Retry-After"))
+		if !ok || wait > maxRetryWait || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		fmt.Fprintf(os.Stderr, "Too many requests, trying again in %s\n", wait)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryAfter reads a Retry-After header, given in seconds or as a date.
+func retryAfter(header string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
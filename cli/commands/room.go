@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/cli/config"
+	"github.com/MFCaballero/simple-quiz/cli/session"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+func HostCommand(config config.Config) *cobra.Command {
+	var hostCmd = &cobra.Command{
+		Use:   "host",
+		Short: "Host a live quiz room, moving on with Enter",
+		Run: func(cmd *cobra.Command, args []string) {
+			seconds, err := cmd.Flags().GetInt("seconds")
+			if err != nil {
+				log.Fatal(err)
+			}
+			conn, err := dialRoom(apiURL(config), "/rooms/host?seconds="+strconv.Itoa(seconds))
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer conn.Close()
+			if err := hostRoom(conn); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	hostCmd.Flags().IntP("seconds", "s", 20, "Seconds to answer each question, from 5 to 120")
+
+	return hostCmd
+}
+
+func JoinCommand(sessionManager *session.SessionManager, config config.Config) *cobra.Command {
+	var joinCmd = &cobra.Command{
+		Use:   "join <code>",
+		Short: "Join a live quiz room",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, err := cmd.Flags().GetString("name")
+			if err != nil {
+				log.Fatal(err)
+			}
+			name = strings.TrimSpace(name)
+			if name == "" {
+				if session, err := sessionManager.GetSession(); err == nil && session != nil {
+					name = session.Name
+				}
+			}
+			if name == "" {
+				log.Fatal("a name is required, log in or use --name")
+			}
+			conn, err := dialRoom(apiURL(config), "/rooms/"+url.PathEscape(args[0])+"/join?name="+url.QueryEscape(name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer conn.Close()
+			if err := playRoom(conn, name); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	joinCmd.Flags().StringP("name", "u", "", "Your name in the room, the one you logged in with by default")
+
+	return joinCmd
+}
+
+type roomMessage struct {
+	Type     string   `json:"type"`
+	Code     string   `json:"code"`
+	Players  []string `json:"players"`
+	Question *struct {
+		Number  int    `json:"number"`
+		Total   int    `json:"total"`
+		Label   string `json:"label"`
+		Options []struct {
+			ID    string `json:"id"`
+			Label string `json:"label"`
+		} `json:"options"`
+		Seconds int `json:"seconds"`
+	} `json:"question"`
+	Answered int `json:"answered"`
+	Result   *struct {
+		Number         int      `json:"number"`
+		CorrectOptions []string `json:"correct_options"`
+		Answered       bool     `json:"answered"`
+		Correct        bool     `json:"correct"`
+		Points         int      `json:"points"`
+	} `json:"result"`
+	Standings []roomStanding `json:"standings"`
+	Error     string         `json:"error"`
+}
+
+type roomStanding struct {
+	Rank  int    `json:"rank"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+}
+
+type roomCommand struct {
+	Type     string `json:"type"`
+	OptionID string `json:"option_id,omitempty"`
+}
+
+func dialRoom(apiURL, path string) (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(apiURL, "http") + path
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, processErrorResponse(resp)
+		}
+		return nil, fmt.Errorf("error connecting to the room: %v", err)
+	}
+	return conn, nil
+}
+
+// hostRoom shows what happens in the room, asking the next question every
+// time Enter is pressed.
+func hostRoom(conn *websocket.Conn) error {
+	messages := readRoomMessages(conn)
+	lines := readLines()
+	total := 0
+	for {
+		select {
+		case message, open := <-messages:
+			if !open {
+				return nil
+			}
+			switch message.Type {
+			case "room":
+				fmt.Printf("Room open, players join with: quiz join %s\n", message.Code)
+				fmt.Println("Press Enter to ask the first question")
+			case "players":
+				fmt.Printf("Players (%d): %s\n", len(message.Players), strings.Join(message.Players, ", "))
+			case "question":
+				total = message.Question.Total
+				printRoomQuestion(message)
+				fmt.Println("Press Enter to reveal the results now")
+			case "progress":
+				fmt.Printf("%d answered\n", message.Answered)
+			case "results":
+				fmt.Printf("The answer was %s\n", strings.Join(message.Result.CorrectOptions, ", "))
+				printStandings(message.Standings, "")
+				if message.Result.Number == total {
+					fmt.Println("Press Enter to finish the quiz")
+				} else {
+					fmt.Println("Press Enter for the next question")
+				}
+			case "finished":
+				fmt.Println("**** Final standings ****")
+				printStandings(message.Standings, "")
+				return nil
+			case "error":
+				fmt.Println("Error:", message.Error)
+			}
+		case _, open := <-lines:
+			if !open {
+				lines = nil
+				continue
+			}
+			if err := conn.WriteJSON(roomCommand{Type: "next"}); err != nil {
+				return fmt.Errorf("error sending command: %v", err)
+			}
+		}
+	}
+}
+
+// playRoom shows the questions of the room and sends the option ids typed
+// as answers.
+func playRoom(conn *websocket.Conn, name string) error {
+	messages := readRoomMessages(conn)
+	lines := readLines()
+	for {
+		select {
+		case message, open := <-messages:
+			if !open {
+				return nil
+			}
+			switch message.Type {
+			case "joined":
+				fmt.Printf("Joined room %s as %s, waiting for the host to start\n", message.Code, name)
+			case "players":
+				fmt.Printf("Players (%d): %s\n", len(message.Players), strings.Join(message.Players, ", "))
+			case "question":
+				printRoomQuestion(message)
+				fmt.Printf("You have %d seconds, type an option id and press Enter\n", message.Question.Seconds)
+			case "answer_received":
+				fmt.Println("Answer sent, waiting for the others")
+			case "results":
+				result := message.Result
+				answer := strings.Join(result.CorrectOptions, ", ")
+				switch {
+				case result.Correct:
+					fmt.Printf("Right! +%d points\n", result.Points)
+				case result.Answered:
+					fmt.Printf("Wrong, the answer was %s\n", answer)
+				default:
+					fmt.Printf("You didn't answer, the answer was %s\n", answer)
+				}
+				printStandings(message.Standings, name)
+			case "finished":
+				fmt.Println("**** Final standings ****")
+				printStandings(message.Standings, name)
+				return nil
+			case "error":
+				fmt.Println("Error:", message.Error)
+			}
+		case line, open := <-lines:
+			if !open {
+				lines = nil
+				continue
+			}
+			optionID := strings.ToUpper(strings.TrimSpace(line))
+			if optionID == "" {
+				continue
+			}
+			if err := conn.WriteJSON(roomCommand{Type: "answer", OptionID: optionID}); err != nil {
+				return fmt.Errorf("error sending answer: %v", err)
+			}
+		}
+	}
+}
+
+func printRoomQuestion(message roomMessage) {
+	question := message.Question
+	fmt.Printf("\nQuestion %d/%d: %s\n", question.Number, question.Total, question.Label)
+	for _, option := range question.Options {
+		fmt.Printf("%s %s\n", option.ID, option.Label)
+	}
+}
+
+// printStandings shows the top five, and the position of name when it is
+// further down.
+func printStandings(standings []roomStanding, name string) {
+	for i, standing := range standings {
+		isCaller := standing.Name == name
+		if i >= 5 && !isCaller {
+			continue
+		}
+		if i >= 5 {
+			fmt.Println("   ...")
+		}
+		marker := " "
+		if isCaller {
+			marker = ">"
+		}
+		fmt.Printf("%s %2d. %-20s %6d\n", marker, standing.Rank, standing.Name, standing.Score)
+	}
+}
+
+// readRoomMessages reads the messages of the room until the connection
+// closes.
+func readRoomMessages(conn *websocket.Conn) <-chan roomMessage {
+	messages := make(chan roomMessage)
+	go func() {
+		defer close(messages)
+		for {
+			var message roomMessage
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			messages <- message
+		}
+	}()
+	return messages
+}
+
+func readLines() <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
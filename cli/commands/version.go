@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/cli/config"
+)
+
+// supportedAPIVersions are the versions of the API this CLI can talk to,
+// from the preferred one.
+var supportedAPIVersions = []string{"v2", "v1"}
+
+var negotiated struct {
+	once sync.Once
+	url  string
+}
+
+// apiURL is the URL of the newest version of the API both the CLI and the
+// backend support. Backends that don't list their versions serve the API
+// without one.
+func apiURL(config config.Config) string {
+	negotiated.once.Do(func() {
+		negotiated.url = config.BackendURL
+		if version := negotiateAPIVersion(config.BackendURL); version != "" {
+			negotiated.url = config.BackendURL + "/" + version
+		}
+	})
+	return negotiated.url
+}
+
+func negotiateAPIVersion(url string) string {
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url + "/version")
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	var version struct {
+		APIVersions []string `json:"api_versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&version); err != nil {
+		return ""
+	}
+	for _, supported := range supportedAPIVersions {
+		for _, served := range version.APIVersions {
+			if served == supported {
+				return supported
+			}
+		}
+	}
+	return ""
+}
+
+// pagesLimit is how many items are asked for per page, the most the backend
+// serves.
+const pagesLimit = 1000
+
+// pagedAPI tells whether the lists of the API at apiURL come in pages, as
+// those of /v2 do.
+func pagedAPI(apiURL string) bool {
+	return strings.HasSuffix(apiURL, "/v2")
+}
+
+// getAllPages gets every page of a listing, following the cursors, and
+// gives the items of the list field of all of them.
+func getAllPages[T any](listURL, list string) ([]T, error) {
+	items := []T{}
+	cursor := ""
+	for {
+		query := url.Values{"limit": {fmt.Sprint(pagesLimit)}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		page, err := getPage(listURL + "?" + query.Encode())
+		if err != nil {
+			return nil, err
+		}
+		var pageItems []T
+		if err := json.Unmarshal(page[list], &pageItems); err != nil {
+			return nil, fmt.Errorf("error decoding response: %v", err)
+		}
+		items = append(items, pageItems...)
+
+		cursor = ""
+		if next, ok := page["next_cursor"]; ok {
+			if err := json.Unmarshal(next, &cursor); err != nil {
+				return nil, fmt.Errorf("error decoding response: %v", err)
+			}
+		}
+		if cursor == "" {
+			return items, nil
+		}
+	}
+}
+
+func getPage(pageURL string) (map[string]json.RawMessage, error) {
+	resp, err := http.Get(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s: %v", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, processErrorResponse(resp)
+	}
+	var page map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("error decoding response: %v", err)
+	}
+	return page, nil
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/cli/config"
+	"github.com/spf13/cobra"
+)
+
+// watchRetry is how long to wait before reconnecting to the stream.
+const watchRetry = 3 * time.Second
+
+func WatchCommand(config config.Config) *cobra.Command {
+	var watchCmd = &cobra.Command{
+		Use:   "watch",
+		Short: "Follow the quizzers live: answers, finishes and leaderboard moves",
+		Run: func(cmd *cobra.Command, args []string) {
+			url := apiURL(config)
+			if err := printLeaderboardTop(url); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("Watching, press Ctrl-C to stop")
+
+			var lastID string
+			for {
+				resp, err := openEventStream(url, lastID)
+				if err != nil {
+					fmt.Printf("Can't reach the server, retrying in %s: %v\n", watchRetry, err)
+					time.Sleep(watchRetry)
+					continue
+				}
+				if resp.StatusCode != http.StatusOK {
+					err := processErrorResponse(resp)
+					resp.Body.Close()
+					log.Fatal(err)
+				}
+				lastID = readEvents(resp.Body, lastID, func(event liveEvent) {
+					printLiveEvent(url, event)
+				})
+				resp.Body.Close()
+				fmt.Println("Connection lost, reconnecting")
+				time.Sleep(watchRetry)
+			}
+		},
+	}
+
+	return watchCmd
+}
+
+type liveEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// openEventStream follows the live events, from the one after lastID when
+// it is set.
+func openEventStream(url, lastID string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url+"/admin/live", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// readEvents hands the events of the stream to handle until it ends, and
+// returns the id of the last one.
+func readEvents(stream io.Reader, lastID string, handle func(liveEvent)) string {
+	scanner := bufio.NewScanner(stream)
+	var event liveEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if event.Type != "" {
+				handle(event)
+			}
+			if event.ID != "" {
+				lastID = event.ID
+			}
+			event = liveEvent{}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Type = value
+		case "data":
+			event.Data += value
+		}
+	}
+	return lastID
+}
+
+func printLiveEvent(url string, event liveEvent) {
+	at := time.Now().Format("15:04:05")
+	switch event.Type {
+	case "answered":
+		var answered struct {
+			Name       string `json:"name"`
+			QuestionID string `json:"question_id"`
+			Changed    bool   `json:"changed"`
+			Answered   int    `json:"answered"`
+			Total      int    `json:"total"`
+		}
+		if json.Unmarshal([]byte(event.Data), &answered) != nil {
+			return
+		}
+		verb := "answered"
+		if answered.Changed {
+			verb = "changed the answer to"
+		}
+		fmt.Printf("[%s] %s %s question %s (%d/%d)\n", at, answered.Name, verb, answered.QuestionID, answered.Answered, answered.Total)
+	case "finished":
+		var finished struct {
+			Name  string  `json:"name"`
+			Score float32 `json:"score"`
+			Rank  int     `json:"rank"`
+		}
+		if json.Unmarshal([]byte(event.Data), &finished) != nil {
+			return
+		}
+		rank := ""
+		if finished.Rank > 0 {
+			rank = fmt.Sprintf(", ranking #%d", finished.Rank)
+		}
+		fmt.Printf("[%s] %s finished with %.0f%%%s\n", at, finished.Name, finished.Score*100, rank)
+	case "leaderboard":
+		var leaderboard struct {
+			Changes []struct {
+				Name string `json:"name"`
+				From int    `json:"from"`
+				To   int    `json:"to"`
+			} `json:"changes"`
+		}
+		if json.Unmarshal([]byte(event.Data), &leaderboard) != nil {
+			return
+		}
+		for _, change := range leaderboard.Changes {
+			switch {
+			case change.From == 0:
+				fmt.Printf("[%s] %s enters the leaderboard at #%d\n", at, change.Name, change.To)
+			case change.To < change.From:
+				fmt.Printf("[%s] %s moves up from #%d to #%d\n", at, change.Name, change.From, change.To)
+			default:
+				fmt.Printf("[%s] %s moves down from #%d to #%d\n", at, change.Name, change.From, change.To)
+			}
+		}
+	case "reset":
+		fmt.Printf("[%s] Some events were missed while reconnecting\n", at)
+		if err := printLeaderboardTop(url); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+func printLeaderboardTop(apiURL string) error {
+	page, err := getLeaderboard(apiURL, url.Values{"limit": {"10"}})
+	if err != nil {
+		return err
+	}
+	fmt.Println("**** Leaderboard ****")
+	if len(page.Entries) == 0 {
+		fmt.Println("Nobody has finished the quiz yet")
+	}
+	for _, entry := range page.Entries {
+		printLeaderboardEntry(entry, false)
+	}
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 
 type Config struct {
 	BackendURL string `required:"true"`
+	// AdminToken authenticates the admin commands.
+	AdminToken string
 }
 
 func LoadConfig() Config {
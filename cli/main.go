@@ -1,6 +1,8 @@
 package main
 
 import (
+	"net/http"
+
 	"github.com/MFCaballero/simple-quiz/cli/commands"
 	"github.com/MFCaballero/simple-quiz/cli/config"
 	"github.com/MFCaballero/simple-quiz/cli/session"
@@ -10,10 +12,16 @@ import (
 func main() {
 	sessionManager := session.NewSessionManager()
 	config := config.LoadConfig()
+	http.DefaultClient.Transport = commands.NewRetryTransport(commands.NewAdminTransport(http.DefaultTransport, config.AdminToken))
 	rootCmd := &cobra.Command{Use: "quiz"}
 	rootCmd.AddCommand(commands.LoginCommand(sessionManager, config)...)
 	rootCmd.AddCommand(commands.QuestionCommand(sessionManager, config))
 	rootCmd.AddCommand(commands.AnswerCommand(sessionManager, config))
+	rootCmd.AddCommand(commands.LeaderboardCommand(sessionManager, config))
+	rootCmd.AddCommand(commands.AdminCommand(config))
+	rootCmd.AddCommand(commands.HostCommand(config))
+	rootCmd.AddCommand(commands.JoinCommand(sessionManager, config))
+	rootCmd.AddCommand(commands.WatchCommand(config))
 
 	if err := rootCmd.Execute(); err != nil {
 		panic(err)
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Config holds the server settings, read from QUIZ_* environment variables.
+type Config struct {
+	Port            int           `default:"8080"`
+	DataDir         string        `default:"./db" split_words:"true"`
+	Storage         string        `default:"json"`
+	CompactInterval time.Duration `default:"1m" split_words:"true"`
+	// QuestionsPollInterval is how often questions.json is checked for
+	// edits, zero disables watching.
+	QuestionsPollInterval time.Duration `default:"2s" split_words:"true"`
+	// LogFormat is text or json, LogLevel one of debug, info, warn or error.
+	LogFormat string `default:"text" split_words:"true"`
+	LogLevel  string `default:"info" split_words:"true"`
+	// AdminToken is the bearer token the /admin routes require. They reject
+	// every request when it is empty.
+	AdminToken string `split_words:"true"`
+	// WebhookAllowedHosts are the hosts webhooks can notify even though
+	// they aren't public, separated by commas.
+	WebhookAllowedHosts []string `split_words:"true"`
+	// GRPCPort serves the gRPC API, zero disables it.
+	GRPCPort int `default:"9090" split_words:"true"`
+	// IPRateLimit is how many logins and answers per second a client
+	// address can send, after a burst of IPRateBurst. Zero disables it.
+	IPRateLimit float64 `default:"10" split_words:"true"`
+	IPRateBurst int     `default:"50" split_words:"true"`
+	// UserRateLimit and UserRateBurst limit the answers of each quizzer the
+	// same way.
+	UserRateLimit float64 `default:"2" split_words:"true"`
+	UserRateBurst int     `default:"10" split_words:"true"`
+	// LoginMaxFailures rejected logins in a row lock a client address out
+	// for LoginLockout. Zero disables the lockout.
+	LoginMaxFailures int           `default:"5" split_words:"true"`
+	LoginLockout     time.Duration `default:"15m" split_words:"true"`
+	// LockAnswers rejects changing an answer once it is submitted.
+	LockAnswers bool `default:"false" split_words:"true"`
+	// MaxAnswerChanges is how many times each answer can be changed, zero
+	// for no limit.
+	MaxAnswerChanges int `default:"0" split_words:"true"`
+}
+
+func LoadConfig() Config {
+	var config Config
+
+	if err := envconfig.Process("quiz", &config); err != nil {
+		panic(err.Error())
+	}
+	return config
+}
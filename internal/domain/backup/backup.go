@@ -0,0 +1,167 @@
+// Package backup reads and writes backups of the quiz data as a single
+// gzipped tar archive, and reports what restoring one would change.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// Manifest describes the content of an archive. It is the first file of the
+// archive so it can be checked before anything else is read.
+type Manifest struct {
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Users         int       `json:"users"`
+	Questions     int       `json:"questions"`
+	Events        int       `json:"events"`
+}
+
+const (
+	manifestFile  = "manifest.json"
+	usersFile     = "users.json"
+	questionsFile = "questions.json"
+	revisionsFile = "question_revisions.json"
+	eventsFile    = "events.jsonl"
+)
+
+// Write writes the backup as a gzipped tar archive.
+func Write(w io.Writer, backup model.Backup) error {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	manifest := Manifest{
+		SchemaVersion: backup.SchemaVersion,
+		CreatedAt:     backup.CreatedAt,
+		Users:         len(backup.Users),
+		Questions:     len(backup.Questions),
+		Events:        len(backup.Events),
+	}
+	events := &bytes.Buffer{}
+	encoder := json.NewEncoder(events)
+	for _, event := range backup.Events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("encoding events: %v", err)
+		}
+	}
+
+	for _, file := range []struct {
+		name    string
+		content interface{}
+	}{
+		{manifestFile, manifest},
+		{usersFile, backup.Users},
+		{questionsFile, backup.Questions},
+		{revisionsFile, backup.QuestionRevisions},
+		{eventsFile, events.Bytes()},
+	} {
+		content, ok := file.content.([]byte)
+		if !ok {
+			var err error
+			if content, err = json.MarshalIndent(file.content, "", "  "); err != nil {
+				return fmt.Errorf("encoding %s: %v", file.name, err)
+			}
+		}
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: backup.CreatedAt,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("writing %s: %v", file.name, err)
+		}
+		if _, err := archive.Write(content); err != nil {
+			return fmt.Errorf("writing %s: %v", file.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("writing archive: %v", err)
+	}
+	return gz.Close()
+}
+
+// Read reads an archive written by Write. Archives from a newer schema than
+// the one this version of the quiz knows about are refused.
+func Read(r io.Reader) (*model.Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %v", err)
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	backup := &model.Backup{
+		Users:             model.UserMap{},
+		Questions:         model.QuestionMap{},
+		QuestionRevisions: model.QuestionRevisions{},
+	}
+	seen := map[string]bool{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %v", err)
+		}
+		if !seen[manifestFile] && header.Name != manifestFile {
+			return nil, fmt.Errorf("archive does not start with %s", manifestFile)
+		}
+		seen[header.Name] = true
+
+		switch header.Name {
+		case manifestFile:
+			var manifest Manifest
+			if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+				return nil, fmt.Errorf("decoding %s: %v", header.Name, err)
+			}
+			if manifest.SchemaVersion < 1 || manifest.SchemaVersion > model.SchemaVersion {
+				return nil, fmt.Errorf("backup has schema version %d, this server supports up to %d", manifest.SchemaVersion, model.SchemaVersion)
+			}
+			backup.SchemaVersion = manifest.SchemaVersion
+			backup.CreatedAt = manifest.CreatedAt
+		case usersFile:
+			err = json.NewDecoder(archive).Decode(&backup.Users)
+		case questionsFile:
+			err = json.NewDecoder(archive).Decode(&backup.Questions)
+		case revisionsFile:
+			err = json.NewDecoder(archive).Decode(&backup.QuestionRevisions)
+		case eventsFile:
+			backup.Events, err = readEvents(archive)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %v", header.Name, err)
+		}
+	}
+
+	for _, name := range []string{manifestFile, usersFile, questionsFile} {
+		if !seen[name] {
+			return nil, fmt.Errorf("archive has no %s", name)
+		}
+	}
+	return backup, nil
+}
+
+func readEvents(r io.Reader) ([]model.Event, error) {
+	var events []model.Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 10<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var event model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,155 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var options = []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+
+func testBackup() model.Backup {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return model.Backup{
+		SchemaVersion: model.SchemaVersion,
+		CreatedAt:     createdAt,
+		Users: model.UserMap{
+			"1": {ID: "1", Name: "Ana", Answers: []model.Answer{{QuestionID: "1", QuestionRevision: 2, Option: options[0]}}},
+			"2": {ID: "2", Name: "Bob"},
+		},
+		Questions: model.QuestionMap{
+			"1": {Label: "First?", Options: options, Revision: 2},
+			"2": {Label: "Second?", Options: options, Revision: 1},
+		},
+		QuestionRevisions: model.QuestionRevisions{
+			"1": {{Label: "First", Options: options, Revision: 1}, {Label: "First?", Options: options, Revision: 2}},
+			"2": {{Label: "Second?", Options: options, Revision: 1}},
+		},
+		Events: []model.Event{
+			{Sequence: 1, UserID: "1", Type: model.EventStarted, At: createdAt, Name: "Ana"},
+			{Sequence: 2, UserID: "2", Type: model.EventStarted, At: createdAt, Name: "Bob"},
+		},
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	original := testBackup()
+	archive := &bytes.Buffer{}
+	require.NoError(t, Write(archive, original))
+
+	restored, err := Read(archive)
+	require.NoError(t, err)
+	assert.Equal(t, original, *restored)
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		order []string
+		err   string
+	}{
+		{
+			name:  "Newer Schema",
+			files: map[string]string{manifestFile: `{"schema_version": 999}`, usersFile: `{}`, questionsFile: `{}`},
+			order: []string{manifestFile, usersFile, questionsFile},
+			err:   "schema version 999",
+		},
+		{
+			name:  "Manifest Not First",
+			files: map[string]string{manifestFile: `{"schema_version": 1}`, usersFile: `{}`, questionsFile: `{}`},
+			order: []string{usersFile, manifestFile, questionsFile},
+			err:   "does not start with manifest.json",
+		},
+		{
+			name:  "Missing Questions",
+			files: map[string]string{manifestFile: `{"schema_version": 1}`, usersFile: `{}`},
+			order: []string{manifestFile, usersFile},
+			err:   "has no questions.json",
+		},
+		{
+			name:  "Malformed Users",
+			files: map[string]string{manifestFile: `{"schema_version": 1}`, usersFile: `[`, questionsFile: `{}`},
+			order: []string{manifestFile, usersFile, questionsFile},
+			err:   "decoding users.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := &bytes.Buffer{}
+			gz := gzip.NewWriter(archive)
+			tw := tar.NewWriter(gz)
+			for _, name := range tt.order {
+				content := tt.files[name]
+				require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+				_, err := tw.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			require.NoError(t, tw.Close())
+			require.NoError(t, gz.Close())
+
+			_, err := Read(archive)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("Not An Archive", func(t *testing.T) {
+		_, err := Read(bytes.NewBufferString(`{"users": {}}`))
+		assert.ErrorContains(t, err, "not a backup archive")
+	})
+}
+
+func TestCompare(t *testing.T) {
+	current := testBackup()
+	restored := testBackup()
+	restored.Users = model.UserMap{
+		"1": {ID: "1", Name: "Ana"},
+		"3": {ID: "3", Name: "Cai"},
+	}
+	restored.Questions = model.QuestionMap{"1": current.Questions["1"]}
+	restored.Events = restored.Events[:1]
+
+	report := Compare(current, restored)
+
+	assert.Equal(t, UserDiff{Added: []string{"3"}, Removed: []string{"2"}, Changed: []string{"1"}}, report.Users)
+	assert.Equal(t, model.QuestionDiff{Removed: []string{"2"}}, report.Questions)
+	assert.Equal(t, EventDiff{Current: 2, Restored: 1}, report.Events)
+	assert.Equal(t, model.SchemaVersion, report.SchemaVersion)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Empty(t, Validate(testBackup()))
+
+	invalid := testBackup()
+	invalid.Users["2"] = model.User{ID: "5", Answers: []model.Answer{{QuestionID: "9", Option: options[0]}}}
+	invalid.Questions["2"] = model.Question{Label: "Second?", Options: options[:1]}
+
+	rules := map[string]model.Severity{}
+	for _, issue := range Validate(invalid) {
+		rules[issue.Rule] = issue.Severity
+	}
+	assert.Equal(t, map[string]model.Severity{
+		"too-few-options":  model.SeverityError,
+		"user-id":          model.SeverityError,
+		"unknown-question": model.SeverityWarning,
+	}, rules)
+
+	for _, ids := range [][]string{{"1", "3"}, {"0", "1"}, {"1", "02"}, {"1", "ana"}} {
+		gaps := testBackup()
+		gaps.Users = model.UserMap{}
+		for _, id := range ids {
+			gaps.Users[id] = model.User{ID: id}
+		}
+		issues := Validate(gaps)
+		if assert.Len(t, issues, 1, ids) {
+			assert.Equal(t, "user-ids", issues[0].Rule)
+			assert.Equal(t, model.SeverityError, issues[0].Severity)
+		}
+	}
+}
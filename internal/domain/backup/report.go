@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// Report tells what restoring a backup changes compared to the current data.
+type Report struct {
+	DryRun        bool               `json:"dry_run"`
+	SchemaVersion int                `json:"schema_version"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Users         UserDiff           `json:"users"`
+	Questions     model.QuestionDiff `json:"questions"`
+	Events        EventDiff          `json:"events"`
+	Issues        model.Issues       `json:"issues,omitempty"`
+}
+
+type UserDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+type EventDiff struct {
+	Current  int `json:"current"`
+	Restored int `json:"restored"`
+}
+
+// Compare reports what replacing the current data with the restored one
+// would change.
+func Compare(current, restored model.Backup) Report {
+	report := Report{
+		SchemaVersion: restored.SchemaVersion,
+		CreatedAt:     restored.CreatedAt,
+		Questions:     model.DiffQuestions(current.Questions, restored.Questions),
+		Events:        EventDiff{Current: len(current.Events), Restored: len(restored.Events)},
+	}
+	for id, user := range restored.Users {
+		previous, exists := current.Users[id]
+		switch {
+		case !exists:
+			report.Users.Added = append(report.Users.Added, id)
+		case !reflect.DeepEqual(previous, user):
+			report.Users.Changed = append(report.Users.Changed, id)
+		}
+	}
+	for id := range current.Users {
+		if _, exists := restored.Users[id]; !exists {
+			report.Users.Removed = append(report.Users.Removed, id)
+		}
+	}
+	model.SortIDs(report.Users.Added)
+	model.SortIDs(report.Users.Removed)
+	model.SortIDs(report.Users.Changed)
+	return report
+}
+
+// Validate checks that a backup can be restored: its questions must be valid
+// and its users must be consistent with them. The user ids must run from 1
+// without gaps, as new users are given the id following the count of users.
+func Validate(backup model.Backup) model.Issues {
+	issues := model.ValidateQuestions(backup.Questions)
+	for _, id := range sortedUserIDs(backup.Users) {
+		user := backup.Users[id]
+		if n, err := strconv.Atoi(id); err != nil || n < 1 || n > len(backup.Users) || fmt.Sprint(n) != id {
+			issues = append(issues, model.Issue{Rule: "user-ids", Severity: model.SeverityError, Message: fmt.Sprintf("user id %q is not between 1 and %d, ids must have no gaps", id, len(backup.Users))})
+		}
+		if user.ID != id {
+			issues = append(issues, model.Issue{Rule: "user-id", Severity: model.SeverityError, Message: fmt.Sprintf("user stored as %s has id %q", id, user.ID)})
+		}
+		for _, answer := range user.Answers {
+			if _, exists := backup.Questions[answer.QuestionID]; !exists {
+				issues = append(issues, model.Issue{QuestionID: answer.QuestionID, Rule: "unknown-question", Severity: model.SeverityWarning, Message: fmt.Sprintf("user %s answered a question that does not exist", id)})
+			}
+		}
+	}
+	return issues
+}
+
+func sortedUserIDs(users model.UserMap) []string {
+	ids := make([]string, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	model.SortIDs(ids)
+	return ids
+}
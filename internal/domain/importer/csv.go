@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// parseCSV reads a spreadsheet export whose header is
+//
+//	question,correct,A,B,C,...
+//
+// where every column after "correct" holds the text of the option named by
+// its header, and "correct" holds the id of the right option. Empty option
+// cells are skipped so questions may have different numbers of options.
+func parseCSV(r io.Reader) ([]parsedQuestion, ErrorList) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrorList{{Line: 1, Message: "file is empty"}}
+		}
+		return nil, ErrorList{csvError(err)}
+	}
+	if len(header) < 4 || !strings.EqualFold(header[0], "question") || !strings.EqualFold(header[1], "correct") {
+		return nil, ErrorList{{Line: 1, Message: "header must be question,correct followed by at least two option ids"}}
+	}
+	optionIDs := make([]string, len(header)-2)
+	for i, id := range header[2:] {
+		optionIDs[i] = strings.TrimSpace(id)
+	}
+
+	var (
+		questions []parsedQuestion
+		errs      ErrorList
+	)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			errs = append(errs, csvError(err))
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) > len(header) {
+			errs = append(errs, Error{Line: line, Message: fmt.Sprintf("row has %d columns but the header has %d", len(record), len(header))})
+			continue
+		}
+		var (
+			correct string
+			labels  []string
+		)
+		if len(record) > 1 {
+			correct = strings.TrimSpace(record[1])
+			labels = record[2:]
+		}
+		question := parsedQuestion{
+			Question: model.Question{Label: strings.TrimSpace(record[0])},
+			line:     line,
+		}
+		foundCorrect := correct == ""
+		for i, label := range labels {
+			label = strings.TrimSpace(label)
+			if label == "" {
+				continue
+			}
+			isCorrect := optionIDs[i] == correct
+			foundCorrect = foundCorrect || isCorrect
+			question.Options = append(question.Options, model.Option{
+				ID:        optionIDs[i],
+				Label:     label,
+				IsCorrect: isCorrect,
+			})
+		}
+		if !foundCorrect {
+			errs = append(errs, Error{Line: line, Message: fmt.Sprintf("correct option %q is not one of the options", correct)})
+		}
+		questions = append(questions, question)
+	}
+	return questions, errs
+}
+
+func csvError(err error) Error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Error{Line: parseErr.Line, Message: parseErr.Err.Error()}
+	}
+	return Error{Line: 1, Message: err.Error()}
+}
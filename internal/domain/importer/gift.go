@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// parseGIFT reads the multiple choice and true/false subset of Moodle's GIFT
+// format. Questions are separated by blank lines:
+//
+//	// comments are ignored
+//	::Capital:: What is the capital of France? {=Paris ~Berlin ~London}
+//	The sun is a star {T}
+//
+// Options are named A, B, C... in the order they are written, and answers in
+// the middle of the text become a blank, as in GIFT's missing word format.
+func parseGIFT(r io.Reader) ([]parsedQuestion, ErrorList) {
+	var (
+		questions []parsedQuestion
+		errs      ErrorList
+		block     []string
+		start     int
+	)
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		question, err := parseGIFTQuestion(strings.Join(block, "\n"))
+		if err != "" {
+			errs = append(errs, Error{Line: start, Message: err})
+		} else {
+			question.line = start
+			questions = append(questions, question)
+		}
+		block = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "//") || strings.HasPrefix(text, "$CATEGORY:") {
+			continue
+		}
+		if text == "" {
+			flush()
+			continue
+		}
+		if len(block) == 0 {
+			start = line
+		}
+		block = append(block, text)
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, Error{Line: line + 1, Message: err.Error()})
+	}
+	flush()
+	return questions, errs
+}
+
+// parseGIFTQuestion returns the question or a message explaining why it
+// could not be read.
+func parseGIFTQuestion(text string) (parsedQuestion, string) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "::") {
+		end := strings.Index(text[2:], "::")
+		if end < 0 {
+			return parsedQuestion{}, "question title is not closed with ::"
+		}
+		text = strings.TrimSpace(text[end+4:])
+	}
+	for _, marker := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		text = strings.TrimSpace(strings.TrimPrefix(text, marker))
+	}
+
+	open, close := unescapedIndex(text, '{'), -1
+	if open >= 0 {
+		if end := unescapedIndex(text[open:], '}'); end >= 0 {
+			close = open + end
+		}
+	}
+	if close < 0 {
+		return parsedQuestion{}, "missing answer block {...}"
+	}
+	before := strings.TrimSpace(text[:open])
+	after := strings.TrimSpace(text[close+1:])
+	label := before
+	if after != "" {
+		label = before + " _____ " + after
+	}
+
+	options, err := parseGIFTAnswers(strings.TrimSpace(text[open+1 : close]))
+	if err != "" {
+		return parsedQuestion{}, err
+	}
+	return parsedQuestion{Question: model.Question{
+		Label:   strings.Join(strings.Fields(unescapeGIFT(label)), " "),
+		Options: options,
+	}}, ""
+}
+
+func parseGIFTAnswers(answers string) ([]model.Option, string) {
+	head := answers
+	if feedback := unescapedIndex(answers, '#'); feedback >= 0 {
+		head = answers[:feedback]
+	}
+	switch strings.ToUpper(strings.TrimSpace(head)) {
+	case "T", "TRUE":
+		return []model.Option{{ID: "A", Label: "True", IsCorrect: true}, {ID: "B", Label: "False"}}, ""
+	case "F", "FALSE":
+		return []model.Option{{ID: "A", Label: "True"}, {ID: "B", Label: "False", IsCorrect: true}}, ""
+	}
+	if strings.HasPrefix(answers, "#") {
+		return nil, "numerical questions are not supported"
+	}
+
+	var (
+		options []model.Option
+		current *strings.Builder
+		correct bool
+		escaped bool
+		comment bool
+	)
+	add := func() {
+		if current != nil {
+			options = append(options, model.Option{
+				ID:        optionID(len(options)),
+				Label:     unescapeGIFT(strings.TrimSpace(current.String())),
+				IsCorrect: correct,
+			})
+		}
+	}
+	for _, r := range answers {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '=' || r == '~':
+			add()
+			current, correct, comment = &strings.Builder{}, r == '=', false
+			continue
+		case r == '#':
+			comment = true
+		}
+		if current == nil && !comment && !unicode.IsSpace(r) {
+			return nil, "answers must start with = or ~"
+		}
+		if current != nil && !comment {
+			current.WriteRune(r)
+		}
+	}
+	add()
+
+	for _, option := range options {
+		if strings.Contains(option.Label, "->") {
+			return nil, "matching questions are not supported"
+		}
+		if strings.HasPrefix(option.Label, "%") {
+			return nil, "partial credit answers are not supported"
+		}
+	}
+	return options, ""
+}
+
+func unescapedIndex(s string, target rune) int {
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == target:
+			return i
+		}
+	}
+	return -1
+}
+
+var giftUnescaper = strings.NewReplacer(`\~`, "~", `\=`, "=", `\#`, "#", `\{`, "{", `\}`, "}", `\:`, ":", `\n`, "\n", `\\`, `\`)
+
+func unescapeGIFT(s string) string {
+	return giftUnescaper.Replace(s)
+}
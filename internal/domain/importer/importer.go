@@ -0,0 +1,120 @@
+// Package importer converts question banks authored in other formats into
+// the quiz question model.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatYAML Format = "yaml"
+	FormatGIFT Format = "gift"
+)
+
+// ParseFormat accepts a format name, as given by a user or a file extension.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "csv":
+		return FormatCSV, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "gift", "txt":
+		return FormatGIFT, nil
+	}
+	return "", fmt.Errorf("unsupported import format %q, use csv, yaml or gift", name)
+}
+
+// FormatFromFilename infers the format from the file extension.
+func FormatFromFilename(name string) (Format, error) {
+	return ParseFormat(filepath.Ext(name))
+}
+
+// Error describes a problem found at a given line of the imported file.
+type Error struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ErrorList collects every problem found in a file, so authors can fix them
+// all in one go.
+type ErrorList []Error
+
+func (el ErrorList) Error() string {
+	messages := make([]string, len(el))
+	for i, err := range el {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Parse reads questions in the given format. Questions are keyed and
+// positioned by their place in the file, starting at "1". When the content is
+// invalid the returned error is an ErrorList.
+func Parse(r io.Reader, format Format) (model.QuestionMap, error) {
+	var (
+		parsed []parsedQuestion
+		errs   ErrorList
+	)
+	switch format {
+	case FormatCSV:
+		parsed, errs = parseCSV(r)
+	case FormatYAML:
+		parsed, errs = parseYAML(r)
+	case FormatGIFT:
+		parsed, errs = parseGIFT(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+
+	questions := make(model.QuestionMap, len(parsed))
+	for i, question := range parsed {
+		errs = append(errs, validate(question)...)
+		question.Position = i + 1
+		questions[strconv.Itoa(i+1)] = question.Question
+	}
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+		return nil, errs
+	}
+	if len(questions) == 0 {
+		return nil, ErrorList{{Line: 1, Message: "no questions found"}}
+	}
+	return questions, nil
+}
+
+type parsedQuestion struct {
+	model.Question
+	line int
+}
+
+// validate applies the quiz rules that make a question unusable, leaving
+// style checks to the linter.
+func validate(question parsedQuestion) ErrorList {
+	var errs ErrorList
+	for _, issue := range model.ValidateQuestion("", question.Question).Errors() {
+		errs = append(errs, Error{Line: question.line, Message: issue.Message})
+	}
+	return errs
+}
+
+// optionID names options A, B, C... when the format does not name them.
+func optionID(i int) string {
+	if i < 26 {
+		return string(rune('A' + i))
+	}
+	return strconv.Itoa(i + 1)
+}
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	capital := model.Question{
+		Label:    "What is the capital of France?",
+		Position: 1,
+		Options: []model.Option{
+			{ID: "A", Label: "Paris", IsCorrect: true},
+			{ID: "B", Label: "Berlin"},
+			{ID: "C", Label: "London"},
+		},
+	}
+	tests := []struct {
+		name     string
+		format   Format
+		content  string
+		expected model.QuestionMap
+	}{
+		{
+			name:   "CSV",
+			format: FormatCSV,
+			content: "question,correct,A,B,C\n" +
+				"What is the capital of France?,A,Paris,Berlin,London\n" +
+				"\"Is 2 + 2 = 4, really?\",B,No,Yes,\n",
+			expected: model.QuestionMap{
+				"1": capital,
+				"2": {Label: "Is 2 + 2 = 4, really?", Position: 2, Options: []model.Option{{ID: "A", Label: "No"}, {ID: "B", Label: "Yes", IsCorrect: true}}},
+			},
+		},
+		{
+			name:   "YAML",
+			format: FormatYAML,
+			content: `
+- question: What is the capital of France?
+  options:
+    - label: Paris
+      correct: true
+    - label: Berlin
+    - label: London
+- question: Which is a prime number?
+  options:
+    - {id: X, label: "4"}
+    - {id: Y, label: "7", correct: true}
+`,
+			expected: model.QuestionMap{
+				"1": capital,
+				"2": {Label: "Which is a prime number?", Position: 2, Options: []model.Option{{ID: "X", Label: "4"}, {ID: "Y", Label: "7", IsCorrect: true}}},
+			},
+		},
+		{
+			name:   "GIFT",
+			format: FormatGIFT,
+			content: `// geography
+::Capital:: What is the capital of France? {
+  =Paris#Correct!
+  ~Berlin
+  ~London
+}
+
+The sun is a star {T}
+
+Romeo and Juliet was written by {~Dickens =Shakespeare ~Austen} in the 1590s\{s\}
+`,
+			expected: model.QuestionMap{
+				"1": capital,
+				"2": {Label: "The sun is a star", Position: 2, Options: []model.Option{{ID: "A", Label: "True", IsCorrect: true}, {ID: "B", Label: "False"}}},
+				"3": {Label: "Romeo and Juliet was written by _____ in the 1590s{s}", Position: 3, Options: []model.Option{
+					{ID: "A", Label: "Dickens"}, {ID: "B", Label: "Shakespeare", IsCorrect: true}, {ID: "C", Label: "Austen"},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, err := Parse(strings.NewReader(tt.content), tt.format)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, questions)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   Format
+		content  string
+		expected ErrorList
+	}{
+		{
+			name:   "CSV",
+			format: FormatCSV,
+			content: "question,correct,A,B\n" +
+				"Fine?,A,Yes,No\n" +
+				",A,Yes,No\n" +
+				"Which?,C,Yes,No\n",
+			expected: ErrorList{
+				{Line: 3, Message: "question text is empty"},
+				{Line: 4, Message: `correct option "C" is not one of the options`},
+				{Line: 4, Message: "question must have exactly one correct option, found 0"},
+			},
+		},
+		{
+			name:   "YAML",
+			format: FormatYAML,
+			content: `- question: Duplicated?
+  options:
+    - {id: A, label: Yes, correct: true}
+    - {id: A, label: No, correct: true}
+- question: Alone?
+  options:
+    - {label: Yes, correct: true}
+`,
+			expected: ErrorList{
+				{Line: 1, Message: `duplicate option id "A"`},
+				{Line: 1, Message: "question must have exactly one correct option, found 2"},
+				{Line: 5, Message: "question needs at least two options, found 1"},
+			},
+		},
+		{
+			name:   "YAML Syntax",
+			format: FormatYAML,
+			content: `- question: Broken
+  options: [
+`,
+			expected: ErrorList{{Line: 2, Message: "did not find expected node content"}},
+		},
+		{
+			name:   "GIFT",
+			format: FormatGIFT,
+			content: `No answers here
+
+How many? {#3}
+
+Pick {=a ~b}
+`,
+			expected: ErrorList{
+				{Line: 1, Message: "missing answer block {...}"},
+				{Line: 3, Message: "numerical questions are not supported"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			questions, err := Parse(strings.NewReader(tt.content), tt.format)
+			assert.Nil(t, questions)
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	format, err := FormatFromFilename("bank.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatYAML, format)
+
+	_, err = FormatFromFilename("bank.xlsx")
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"gopkg.in/yaml.v3"
+)
+
+type yamlQuestion struct {
+	Question string       `yaml:"question"`
+	Options  []yamlOption `yaml:"options"`
+}
+
+type yamlOption struct {
+	ID      string `yaml:"id"`
+	Label   string `yaml:"label"`
+	Correct bool   `yaml:"correct"`
+}
+
+// parseYAML reads a list of questions, each with a "question" text and a
+// list of "options" holding a "label", an optional "id" and "correct: true"
+// on the right one. Option ids default to A, B, C...
+func parseYAML(r io.Reader) ([]parsedQuestion, ErrorList) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&root); err != nil {
+		if err == io.EOF {
+			return nil, ErrorList{{Line: 1, Message: "file is empty"}}
+		}
+		return nil, ErrorList{yamlError(err)}
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil, ErrorList{{Line: 1, Message: "file must contain a list of questions"}}
+	}
+
+	var (
+		questions []parsedQuestion
+		errs      ErrorList
+	)
+	for _, node := range root.Content[0].Content {
+		var item yamlQuestion
+		if err := node.Decode(&item); err != nil {
+			errs = append(errs, yamlError(err))
+			continue
+		}
+		question := parsedQuestion{
+			Question: model.Question{Label: strings.TrimSpace(item.Question)},
+			line:     node.Line,
+		}
+		for i, option := range item.Options {
+			id := strings.TrimSpace(option.ID)
+			if id == "" {
+				id = optionID(i)
+			}
+			question.Options = append(question.Options, model.Option{
+				ID:        id,
+				Label:     strings.TrimSpace(option.Label),
+				IsCorrect: option.Correct,
+			})
+		}
+		questions = append(questions, question)
+	}
+	return questions, errs
+}
+
+// yamlError turns a decoder error such as "yaml: line 3: did not find
+// expected key" into an Error pointing at that line.
+func yamlError(err error) Error {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		message = typeErr.Errors[0]
+	}
+	var line int
+	if _, scanErr := fmt.Sscanf(message, "line %d:", &line); scanErr == nil {
+		return Error{Line: line, Message: strings.TrimSpace(message[strings.Index(message, ":")+1:])}
+	}
+	return Error{Line: 1, Message: message}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// SchemaVersion is the version of the layout of the stored data. It goes up
+// whenever stored data written by an older version needs to be converted,
+// see the migrations of the repository package.
+const SchemaVersion = 3
+
+// Backup is a consistent copy of everything the quiz stores.
+type Backup struct {
+	SchemaVersion     int               `json:"schema_version"`
+	CreatedAt         time.Time         `json:"created_at"`
+	Users             UserMap           `json:"users"`
+	Questions         QuestionMap       `json:"questions"`
+	QuestionRevisions QuestionRevisions `json:"question_revisions"`
+	Events            []Event           `json:"events"`
+}
+
+type BackupRepository interface {
+	Backup(ctx context.Context) (*Backup, error)
+	// Migrate upgrades a backup written with an older schema version to
+	// SchemaVersion.
+	Migrate(ctx context.Context, backup *Backup) error
+	// Restore replaces the data with a backup migrated to SchemaVersion.
+	Restore(ctx context.Context, backup Backup) error
+}
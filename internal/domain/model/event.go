@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventStarted       EventType = "started"
+	EventAnswered      EventType = "answered"
+	EventAnswerChanged EventType = "answer_changed"
+	EventFinished      EventType = "finished"
+)
+
+// Event is an immutable record of something a user did during the quiz.
+// Replaying a user's events in order rebuilds the user.
+type Event struct {
+	Sequence   int       `json:"sequence"`
+	UserID     string    `json:"user_id"`
+	Type       EventType `json:"type"`
+	At         time.Time `json:"at"`
+	Name       string    `json:"name,omitempty"`
+	QuestionID string    `json:"question_id,omitempty"`
+	// QuestionRevision is the revision of the question that was answered.
+	QuestionRevision int     `json:"question_revision,omitempty"`
+	Option           *Option `json:"option,omitempty"`
+	Score            float32 `json:"score,omitempty"`
+	// CorrectAnswers and TotalQuestions are what the score of a finish was
+	// computed from.
+	CorrectAnswers int `json:"correct_answers,omitempty"`
+	TotalQuestions int `json:"total_questions,omitempty"`
+}
+
+type EventRepository interface {
+	AppendEvent(ctx context.Context, event Event) (*Event, error)
+	GetUserEvents(ctx context.Context, userID string) ([]Event, error)
+}
+
+// Apply updates the user with the outcome of an event.
+func (u *User) Apply(event Event) {
+	switch event.Type {
+	case EventStarted:
+		u.ID = event.UserID
+		u.Name = event.Name
+	case EventAnswered, EventAnswerChanged:
+		answered := Answer{QuestionID: event.QuestionID, QuestionRevision: event.QuestionRevision, Option: *event.Option}
+		answers := make([]Answer, 0, len(u.Answers)+1)
+		for _, answer := range u.Answers {
+			if answer.QuestionID != event.QuestionID {
+				answers = append(answers, answer)
+				continue
+			}
+			answered.Changes = answer.Changes
+			if answer.Option.ID != answered.Option.ID {
+				answered.Changes++
+			}
+		}
+		u.Answers = append(answers, answered)
+	case EventFinished:
+		at := event.At
+		u.FinishedQuiz = true
+		u.FinishedAt = &at
+		u.Score = event.Score
+		u.CorrectAnswers = event.CorrectAnswers
+		u.TotalQuestions = event.TotalQuestions
+	}
+}
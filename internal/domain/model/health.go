@@ -0,0 +1,10 @@
+package model
+
+import "context"
+
+// HealthChecker is implemented by the repositories that can tell whether
+// their store is usable. Repositories that don't implement it are assumed
+// to always be.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor that can't be read, or that was
+// given by a listing sorted differently.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListOptions asks for a page of a listing. Items are ordered by the Sort
+// field, then by id, and the page starts after the item the Cursor was given
+// for, so items added or removed meanwhile don't shift the pages.
+type ListOptions struct {
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first one.
+	Cursor string
+	// Sort is a field of the listing's SortKeys, empty to order by id.
+	Sort string
+	Desc bool
+}
+
+// SortKeys gives the key an item is sorted by for each field of a listing.
+// Keys that are numbers compare as numbers, others as text.
+type SortKeys[T any] map[string]func(T) string
+
+// Fields lists the fields a listing can be sorted by.
+func (sk SortKeys[T]) Fields() []string {
+	fields := make([]string, 0, len(sk))
+	for field := range sk {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// TimeKey is the sort key of a time, empty for no time so it sorts first.
+func TimeKey(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// cursor points to the last item of a page.
+type cursor struct {
+	Sort string `json:"s,omitempty"`
+	Desc bool   `json:"d,omitempty"`
+	Key  string `json:"k,omitempty"`
+	ID   string `json:"i"`
+}
+
+// Paginate returns the page of items asked for by options, id giving the id
+// of an item, and the cursor of the next page, empty on the last one.
+func Paginate[T any](items []T, options ListOptions, id func(T) string, keys SortKeys[T]) ([]T, string, error) {
+	key := func(T) string { return "" }
+	if options.Sort != "" {
+		sortKey, ok := keys[options.Sort]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown sort %q, use one of %s", options.Sort, strings.Join(keys.Fields(), ", "))
+		}
+		key = sortKey
+	}
+	compare := func(keyA, idA, keyB, idB string) int {
+		c := CompareKeys(keyA, keyB)
+		if c == 0 {
+			c = CompareKeys(idA, idB)
+		}
+		if options.Desc {
+			return -c
+		}
+		return c
+	}
+
+	var last *cursor
+	if options.Cursor != "" {
+		decoded, err := decodeCursor(options.Cursor)
+		if err != nil || decoded.Sort != options.Sort || decoded.Desc != options.Desc {
+			return nil, "", ErrInvalidCursor
+		}
+		last = &decoded
+	}
+	after := make([]T, 0, len(items))
+	for _, item := range items {
+		if last == nil || compare(key(item), id(item), last.Key, last.ID) > 0 {
+			after = append(after, item)
+		}
+	}
+	sort.Slice(after, func(i, j int) bool {
+		return compare(key(after[i]), id(after[i]), key(after[j]), id(after[j])) < 0
+	})
+
+	if options.Limit <= 0 || len(after) <= options.Limit {
+		return after, "", nil
+	}
+	end := after[options.Limit-1]
+	return after[:options.Limit], encodeCursor(cursor{Sort: options.Sort, Desc: options.Desc, Key: key(end), ID: id(end)}), nil
+}
+
+// CompareKeys compares two sort keys, numerically when both are numbers.
+// Numbers sort before text.
+func CompareKeys(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func encodeCursor(c cursor) string {
+	content, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	content, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(content, &c)
+	return c, err
+}
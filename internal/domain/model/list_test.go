@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListUsers(t *testing.T) {
+	finishedAt := func(hour int) *time.Time {
+		at := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+		return &at
+	}
+	users := UserMap{
+		"1":  {ID: "1", Name: "Ana", Score: 0.5, FinishedQuiz: true, FinishedAt: finishedAt(12)},
+		"2":  {ID: "2", Name: "bruno", Score: 1, FinishedQuiz: true, FinishedAt: finishedAt(9)},
+		"3":  {ID: "3", Name: "Carla"},
+		"10": {ID: "10", Name: "Anabel", Score: 0.5, FinishedQuiz: true, FinishedAt: finishedAt(10)},
+	}
+	ids := func(page *UserPage) []string {
+		ids := []string{}
+		for _, user := range page.Users {
+			ids = append(ids, user.ID)
+		}
+		return ids
+	}
+
+	t.Run("Follows Cursors", func(t *testing.T) {
+		var listed []string
+		query := UserQuery{ListOptions: ListOptions{Limit: 3}}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 3)
+			page, err := ListUsers(users, query)
+			require.NoError(t, err)
+			listed = append(listed, ids(page)...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		assert.Equal(t, []string{"1", "2", "3", "10"}, listed, "ids are ordered numerically")
+	})
+
+	t.Run("Sorted", func(t *testing.T) {
+		for options, expected := range map[ListOptions][]string{
+			{Sort: "name"}:              {"1", "10", "2", "3"},
+			{Sort: "score", Desc: true}: {"2", "10", "1", "3"},
+			{Sort: "finished_at"}:       {"3", "2", "10", "1"},
+		} {
+			page, err := ListUsers(users, UserQuery{ListOptions: options})
+			require.NoError(t, err)
+			assert.Equal(t, expected, ids(page), options)
+		}
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		finished := true
+		page, err := ListUsers(users, UserQuery{Name: "ANA", Finished: &finished})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "10"}, ids(page))
+	})
+
+	t.Run("Pages Don't Shift", func(t *testing.T) {
+		query := UserQuery{ListOptions: ListOptions{Limit: 2, Sort: "score", Desc: true}}
+		first, err := ListUsers(users, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2", "10"}, ids(first))
+
+		changed := UserMap{"11": {ID: "11", Name: "Dora", Score: 1}}
+		for id, user := range users {
+			if id != "2" {
+				changed[id] = user
+			}
+		}
+		query.Cursor = first.NextCursor
+		next, err := ListUsers(changed, query)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "3"}, ids(next), "users added or removed before the cursor are not listed")
+	})
+
+	t.Run("Invalid Cursor", func(t *testing.T) {
+		first, err := ListUsers(users, UserQuery{ListOptions: ListOptions{Limit: 1, Sort: "name"}})
+		require.NoError(t, err)
+		for _, options := range []ListOptions{
+			{Cursor: first.NextCursor, Sort: "score"},
+			{Cursor: first.NextCursor, Sort: "name", Desc: true},
+			{Cursor: "not a cursor"},
+		} {
+			_, err := ListUsers(users, UserQuery{ListOptions: options})
+			assert.ErrorIs(t, err, ErrInvalidCursor, fmt.Sprint(options))
+		}
+	})
+
+	t.Run("Unknown Sort", func(t *testing.T) {
+		_, err := ListUsers(users, UserQuery{ListOptions: ListOptions{Sort: "answers"}})
+		assert.EqualError(t, err, `unknown sort "answers", use one of finished_at, name, score`)
+	})
+}
+
+func TestListQuestions(t *testing.T) {
+	questions := QuestionMap{
+		"1": {Label: "What is Go?"},
+		"2": {Label: "Who wrote it?"},
+		"3": {Label: "Why go?"},
+	}
+
+	page, err := ListQuestions(questions, QuestionQuery{ListOptions: ListOptions{Sort: "label", Desc: true}, Label: "go"})
+	require.NoError(t, err)
+	require.Len(t, page.Questions, 2)
+	assert.Equal(t, "3", page.Questions[0].ID)
+	assert.Equal(t, "What is Go?", page.Questions[1].Label)
+	assert.Empty(t, page.NextCursor)
+}
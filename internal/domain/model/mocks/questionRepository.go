@@ -64,3 +64,47 @@ func (mr *MockQuestionRepositoryMockRecorder) GetQuestion(ctx, id interface{}) *
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuestion", reflect.TypeOf((*MockQuestionRepository)(nil).GetQuestion), ctx, id)
 }
+
+// GetQuestionRevision mocks base method.
+func (m *MockQuestionRepository) GetQuestionRevision(ctx context.Context, id string, revision int) (*model.Question, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuestionRevision", ctx, id, revision)
+	ret0, _ := ret[0].(*model.Question)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuestionRevision indicates an expected call of GetQuestionRevision.
+func (mr *MockQuestionRepositoryMockRecorder) GetQuestionRevision(ctx, id, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuestionRevision", reflect.TypeOf((*MockQuestionRepository)(nil).GetQuestionRevision), ctx, id, revision)
+}
+
+// ListQuestions mocks base method.
+func (m *MockQuestionRepository) ListQuestions(ctx context.Context, query model.QuestionQuery) (*model.QuestionPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQuestions", ctx, query)
+	ret0, _ := ret[0].(*model.QuestionPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuestions indicates an expected call of ListQuestions.
+func (mr *MockQuestionRepositoryMockRecorder) ListQuestions(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQuestions", reflect.TypeOf((*MockQuestionRepository)(nil).ListQuestions), ctx, query)
+}
+
+// SaveQuestions mocks base method.
+func (m *MockQuestionRepository) SaveQuestions(ctx context.Context, questions model.QuestionMap) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveQuestions", ctx, questions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveQuestions indicates an expected call of SaveQuestions.
+func (mr *MockQuestionRepositoryMockRecorder) SaveQuestions(ctx, questions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveQuestions", reflect.TypeOf((*MockQuestionRepository)(nil).SaveQuestions), ctx, questions)
+}
@@ -1,6 +1,12 @@
 package model
 
-import "context"
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 type Option struct {
 	ID        string `json:"id"`
@@ -11,11 +17,153 @@ type Option struct {
 type Question struct {
 	Label   string   `json:"label"`
 	Options []Option `json:"options"`
+	// Position orders the questions in the quiz, from 1. Questions without
+	// one are asked last.
+	Position int `json:"position,omitempty"`
+	// Revision is set by the repository and goes up every time the
+	// question is edited.
+	Revision int `json:"revision,omitempty"`
 }
 
 type QuestionMap map[string]Question
 
+// Order returns the ids of the questions in the order they are asked: by
+// position, then by id.
+func (qm QuestionMap) Order() []string {
+	ids := make([]string, 0, len(qm))
+	for id := range qm {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if c := CompareKeys(PositionKey(qm[ids[i]].Position), PositionKey(qm[ids[j]].Position)); c != 0 {
+			return c < 0
+		}
+		return CompareKeys(ids[i], ids[j]) < 0
+	})
+	return ids
+}
+
+// MaxPosition returns the highest position of the questions, 0 when none
+// has one.
+func (qm QuestionMap) MaxPosition() int {
+	highest := 0
+	for _, question := range qm {
+		highest = max(highest, question.Position)
+	}
+	return highest
+}
+
+// PositionKey is the sort key of a question's position, empty for no
+// position so it sorts after every position.
+func PositionKey(position int) string {
+	if position == 0 {
+		return ""
+	}
+	return strconv.Itoa(position)
+}
+
+// QuestionItem is a question as listed, with its id.
+type QuestionItem struct {
+	ID string
+	Question
+}
+
+// QuestionQuery asks for a page of the questions.
+type QuestionQuery struct {
+	ListOptions
+	// Label keeps the questions whose label contains it, ignoring case.
+	Label string
+}
+
+// QuestionPage is a page of the questions. NextCursor is empty on the last
+// page.
+type QuestionPage struct {
+	Questions  []QuestionItem
+	NextCursor string
+}
+
+// QuestionSortKeys are the fields the questions can be sorted by.
+var QuestionSortKeys = SortKeys[QuestionItem]{
+	"label":    func(item QuestionItem) string { return strings.ToLower(item.Label) },
+	"position": func(item QuestionItem) string { return PositionKey(item.Position) },
+}
+
+// Matches tells whether the question is kept by the query's filters.
+func (q QuestionQuery) Matches(question Question) bool {
+	return q.Label == "" || strings.Contains(strings.ToLower(question.Label), strings.ToLower(q.Label))
+}
+
+// ListQuestions pages through questions as asked by query.
+func ListQuestions(questions QuestionMap, query QuestionQuery) (*QuestionPage, error) {
+	items := make([]QuestionItem, 0, len(questions))
+	for id, question := range questions {
+		if query.Matches(question) {
+			items = append(items, QuestionItem{ID: id, Question: question})
+		}
+	}
+	page, next, err := Paginate(items, query.ListOptions, func(item QuestionItem) string { return item.ID }, QuestionSortKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &QuestionPage{Questions: page, NextCursor: next}, nil
+}
+
 type QuestionRepository interface {
 	GetAllQuestions(ctx context.Context) (QuestionMap, error)
+	// ListQuestions returns a page of the questions, ErrInvalidCursor when
+	// the cursor of the query can't be followed.
+	ListQuestions(ctx context.Context, query QuestionQuery) (*QuestionPage, error)
 	GetQuestion(ctx context.Context, id string) (*Question, error)
+	SaveQuestions(ctx context.Context, questions QuestionMap) error
+	GetQuestionRevision(ctx context.Context, id string, revision int) (*Question, error)
+}
+
+// QuestionDiff lists the ids of the questions that differ between two
+// versions of a question bank.
+type QuestionDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+func DiffQuestions(old, new QuestionMap) QuestionDiff {
+	var diff QuestionDiff
+	for id, question := range new {
+		previous, exists := old[id]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, id)
+		case !reflect.DeepEqual(previous, question):
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for id := range old {
+		if _, exists := new[id]; !exists {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	SortIDs(diff.Added)
+	SortIDs(diff.Removed)
+	SortIDs(diff.Changed)
+	return diff
+}
+
+func (d QuestionDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d QuestionDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes"
+	}
+	var parts []string
+	for _, part := range []struct {
+		name string
+		ids  []string
+	}{{"added", d.Added}, {"removed", d.Removed}, {"changed", d.Changed}} {
+		if len(part.ids) > 0 {
+			parts = append(parts, part.name+" "+strings.Join(part.ids, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
 }
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffQuestions(t *testing.T) {
+	options := []Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+	old := QuestionMap{
+		"1": {Label: "First?", Options: options},
+		"2": {Label: "Second?", Options: options},
+		"3": {Label: "Third?", Options: options},
+	}
+	new := QuestionMap{
+		"1":  {Label: "First?", Options: options},
+		"3":  {Label: "Third?", Options: []Option{{ID: "A", Label: "Yes"}, {ID: "B", Label: "No", IsCorrect: true}}},
+		"4":  {Label: "Fourth?", Options: options},
+		"10": {Label: "Tenth?", Options: options},
+	}
+
+	diff := DiffQuestions(old, new)
+
+	assert.Equal(t, QuestionDiff{Added: []string{"4", "10"}, Removed: []string{"2"}, Changed: []string{"3"}}, diff)
+	assert.Equal(t, "added 4, 10; removed 2; changed 3", diff.String())
+	assert.True(t, DiffQuestions(old, old).IsEmpty())
+	assert.Equal(t, "no changes", DiffQuestions(old, old).String())
+}
+
+func TestQuestionRevisions(t *testing.T) {
+	options := []Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+	revisions := QuestionRevisions{}
+
+	questions := QuestionMap{"1": {Label: "First?", Options: options}}
+	assert.True(t, revisions.Record(questions))
+	assert.Equal(t, 1, questions["1"].Revision)
+
+	questions = QuestionMap{"1": {Label: "First?", Options: options, Revision: 7}}
+	assert.False(t, revisions.Record(questions), "the revision number in the file is ignored")
+	assert.Equal(t, 1, questions["1"].Revision)
+
+	questions = QuestionMap{"1": {Label: "First?", Options: options, Position: 2}}
+	assert.False(t, revisions.Record(questions), "moving a question is not a revision")
+	assert.Equal(t, Question{Label: "First?", Options: options, Position: 2, Revision: 1}, questions["1"])
+
+	questions = QuestionMap{
+		"1": {Label: "First, edited?", Options: options},
+		"2": {Label: "Second?", Options: options},
+	}
+	assert.True(t, revisions.Record(questions))
+	assert.Equal(t, 2, questions["1"].Revision)
+	assert.Equal(t, 1, questions["2"].Revision)
+
+	first, ok := revisions.Get("1", 1)
+	assert.True(t, ok)
+	assert.Equal(t, Question{Label: "First?", Options: options, Revision: 1}, first)
+	_, ok = revisions.Get("1", 3)
+	assert.False(t, ok)
+}
@@ -0,0 +1,46 @@
+package model
+
+import "reflect"
+
+// QuestionRevisions keeps every version a question has had, oldest first.
+// Revisions are never changed or removed once recorded, so an answer can
+// always be shown next to the question as it was when it was given.
+type QuestionRevisions map[string][]Question
+
+// Record stamps each question with its revision, recording a new one for
+// the questions that differ from their latest revision. Moving a question
+// doesn't change it, so its position is not compared. It reports whether any
+// revision was recorded.
+func (qr QuestionRevisions) Record(questions QuestionMap) bool {
+	recorded := false
+	for id, question := range questions {
+		question.Revision = 0
+		revisions := qr[id]
+		if n := len(revisions); n > 0 {
+			latest, current := revisions[n-1], question
+			latest.Revision, latest.Position, current.Position = 0, 0, 0
+			if reflect.DeepEqual(latest, current) {
+				question.Revision = revisions[n-1].Revision
+				questions[id] = question
+				continue
+			}
+			question.Revision = revisions[n-1].Revision
+		}
+		question.Revision++
+		question.Options = append([]Option(nil), question.Options...)
+		qr[id] = append(revisions, question)
+		questions[id] = question
+		recorded = true
+	}
+	return recorded
+}
+
+// Get returns a question as it was at the given revision.
+func (qr QuestionRevisions) Get(id string, revision int) (Question, bool) {
+	for _, question := range qr[id] {
+		if question.Revision == revision {
+			return question, true
+		}
+	}
+	return Question{}, false
+}
@@ -1,24 +1,123 @@
 package model
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type User struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Score        float32  `json:"score"`
-	Answers      []Answer `json:"answers"`
-	FinishedQuiz bool     `json:"finished_quiz"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Score        float32    `json:"score"`
+	Answers      []Answer   `json:"answers"`
+	FinishedQuiz bool       `json:"finished_quiz"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	// CorrectAnswers and TotalQuestions are what the score was computed
+	// from when the user finished. Both are zero for the users finished
+	// before they were saved.
+	CorrectAnswers int `json:"correct_answers,omitempty"`
+	TotalQuestions int `json:"total_questions,omitempty"`
+	// Version is incremented by every update, which is refused when the
+	// user was updated since it was read.
+	Version int `json:"version"`
 }
 type Answer struct {
 	QuestionID string `json:"question_id"`
-	Option     Option `json:"option"`
+	// QuestionRevision is the revision of the question that was answered,
+	// zero for answers given before questions had revisions.
+	QuestionRevision int    `json:"question_revision,omitempty"`
+	Option           Option `json:"option"`
+	// Changes is how many times the answer was changed to another option.
+	Changes int `json:"changes,omitempty"`
+}
+
+// CountAnswers counts the answers to the questions, and how many of them
+// are correct. Answers to questions no longer asked don't count.
+func (u User) CountAnswers(questions QuestionMap) (answered, correct int) {
+	for _, answer := range u.Answers {
+		if _, ok := questions[answer.QuestionID]; !ok {
+			continue
+		}
+		answered++
+		if answer.Option.IsCorrect {
+			correct++
+		}
+	}
+	return answered, correct
 }
 
 type UserMap map[string]User
 
+// UserQuery asks for a page of the users.
+type UserQuery struct {
+	ListOptions
+	// Name keeps the users whose name contains it, ignoring case.
+	Name string
+	// Finished keeps the users that finished the quiz, or that didn't, when
+	// set.
+	Finished *bool
+}
+
+// UserPage is a page of the users. NextCursor is empty on the last page.
+type UserPage struct {
+	Users      []User
+	NextCursor string
+}
+
+// UserSortKeys are the fields the users can be sorted by.
+var UserSortKeys = SortKeys[User]{
+	"name":        func(user User) string { return strings.ToLower(user.Name) },
+	"score":       func(user User) string { return strconv.FormatFloat(float64(user.Score), 'f', -1, 32) },
+	"finished_at": func(user User) string { return TimeKey(user.FinishedAt) },
+}
+
+// Matches tells whether the user is kept by the query's filters.
+func (q UserQuery) Matches(user User) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(user.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	return q.Finished == nil || *q.Finished == user.FinishedQuiz
+}
+
+// ListUsers pages through users as asked by query. The users of the page
+// share their answers with the given ones.
+func ListUsers(users UserMap, query UserQuery) (*UserPage, error) {
+	matching := make([]User, 0, len(users))
+	for _, user := range users {
+		if query.Matches(user) {
+			matching = append(matching, user)
+		}
+	}
+	page, next, err := Paginate(matching, query.ListOptions, func(user User) string { return user.ID }, UserSortKeys)
+	if err != nil {
+		return nil, err
+	}
+	return &UserPage{Users: page, NextCursor: next}, nil
+}
+
+// ConflictError is returned when updating a user that was updated by someone
+// else since it was read.
+type ConflictError struct {
+	UserID   string
+	Expected int
+	Actual   int
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("user %s was updated concurrently: expected version %d, found %d", e.UserID, e.Expected, e.Actual)
+}
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user User) (*User, error)
+	// UpdateUser saves the user if its version is still the stored one, and
+	// increments the version of both. Otherwise it returns a *ConflictError.
 	UpdateUser(ctx context.Context, user *User) error
 	GetUser(ctx context.Context, id string) (*User, error)
 	GetAllUsers(ctx context.Context) (UserMap, error)
+	// ListUsers returns a page of the users, ErrInvalidCursor when the
+	// cursor of the query can't be followed.
+	ListUsers(ctx context.Context, query UserQuery) (*UserPage, error)
 }
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Issue is a problem found in a question bank. Errors make the quiz unusable,
+// warnings are likely mistakes and infos are style suggestions.
+type Issue struct {
+	QuestionID string   `json:"question_id,omitempty"`
+	Rule       string   `json:"rule"`
+	Severity   Severity `json:"severity"`
+	Message    string   `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.QuestionID == "" {
+		return fmt.Sprintf("%s: %s (%s)", i.Severity, i.Message, i.Rule)
+	}
+	return fmt.Sprintf("%s: question %s: %s (%s)", i.Severity, i.QuestionID, i.Message, i.Rule)
+}
+
+type Issues []Issue
+
+func (is Issues) Errors() Issues {
+	return is.bySeverity(SeverityError)
+}
+
+func (is Issues) Warnings() Issues {
+	return is.bySeverity(SeverityWarning)
+}
+
+func (is Issues) HasErrors() bool {
+	return len(is.Errors()) > 0
+}
+
+func (is Issues) bySeverity(severity Severity) Issues {
+	var filtered Issues
+	for _, issue := range is {
+		if issue.Severity == severity {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// ValidateQuestions checks a whole question bank: every question on its own
+// plus the rules that span questions, such as positions being unique.
+func ValidateQuestions(questions QuestionMap) Issues {
+	if len(questions) == 0 {
+		return Issues{{Rule: "no-questions", Severity: SeverityError, Message: "there are no questions"}}
+	}
+
+	var issues Issues
+	labels := map[string]string{}
+	positions := map[int]string{}
+	for _, id := range questions.Order() {
+		question := questions[id]
+		issues = append(issues, ValidateQuestion(id, question)...)
+
+		if other, ok := positions[question.Position]; ok && question.Position != 0 {
+			issues = append(issues, Issue{QuestionID: id, Rule: "duplicate-position", Severity: SeverityWarning, Message: fmt.Sprintf("same position as question %s", other)})
+		} else {
+			positions[question.Position] = id
+		}
+		label := strings.ToLower(strings.TrimSpace(question.Label))
+		if other, ok := labels[label]; ok && label != "" {
+			issues = append(issues, Issue{QuestionID: id, Rule: "duplicate-question", Severity: SeverityWarning, Message: fmt.Sprintf("same text as question %s", other)})
+		} else {
+			labels[label] = id
+		}
+	}
+	return issues
+}
+
+// ValidateQuestion checks the rules that apply to a single question.
+func ValidateQuestion(id string, question Question) Issues {
+	var issues Issues
+	add := func(rule string, severity Severity, format string, args ...interface{}) {
+		issues = append(issues, Issue{QuestionID: id, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(question.Label) == "" {
+		add("empty-label", SeverityError, "question text is empty")
+	} else {
+		if question.Label != strings.TrimSpace(question.Label) {
+			add("whitespace", SeverityInfo, "question text has leading or trailing spaces")
+		}
+		if !strings.HasSuffix(strings.TrimSpace(question.Label), "?") {
+			add("question-mark", SeverityInfo, "question text does not end with a question mark")
+		}
+	}
+	if len(question.Options) < 2 {
+		add("too-few-options", SeverityError, "question needs at least two options, found %d", len(question.Options))
+	}
+
+	correct := 0
+	ids := map[string]bool{}
+	labels := map[string]bool{}
+	for i, option := range question.Options {
+		switch {
+		case option.ID == "":
+			add("missing-option-id", SeverityError, "option %q has no id", option.Label)
+		case ids[option.ID]:
+			add("duplicate-option-id", SeverityError, "duplicate option id %q", option.ID)
+		case i < 26 && option.ID != string(rune('A'+i)):
+			add("option-id-order", SeverityWarning, "option %s is in the position of option %c", option.ID, 'A'+i)
+		}
+		ids[option.ID] = true
+
+		label := strings.ToLower(strings.TrimSpace(option.Label))
+		if label == "" {
+			add("empty-option-label", SeverityError, "option %s has no text", option.ID)
+		} else if labels[label] {
+			add("duplicate-option-label", SeverityWarning, "option %s repeats the text of another option", option.ID)
+		}
+		labels[label] = true
+
+		if option.IsCorrect {
+			correct++
+		}
+	}
+	if correct != 1 {
+		add("correct-option-count", SeverityError, "question must have exactly one correct option, found %d", correct)
+	}
+	return issues
+}
+
+// SortIDs sorts question or user ids, comparing them numerically when both
+// are numbers.
+func SortIDs(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		if (errA == nil) != (errB == nil) {
+			return errA == nil
+		}
+		return ids[i] < ids[j]
+	})
+}
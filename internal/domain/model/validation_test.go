@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQuestions(t *testing.T) {
+	valid := Question{
+		Label: "What is the capital of France?",
+		Options: []Option{
+			{ID: "A", Label: "Paris", IsCorrect: true},
+			{ID: "B", Label: "Berlin"},
+		},
+	}
+
+	t.Run("ValidateQuestions Valid", func(t *testing.T) {
+		issues := ValidateQuestions(QuestionMap{"1": valid, "2": {Label: "Is the sun a star?", Options: valid.Options}})
+
+		assert.Empty(t, issues)
+	})
+
+	t.Run("ValidateQuestions Ids With Gaps", func(t *testing.T) {
+		issues := ValidateQuestions(QuestionMap{"2": valid, "intro": {Label: "Is the sun a star?", Options: valid.Options}})
+
+		assert.Empty(t, issues)
+	})
+
+	t.Run("ValidateQuestions Empty", func(t *testing.T) {
+		issues := ValidateQuestions(QuestionMap{})
+
+		assert.Equal(t, Issues{{Rule: "no-questions", Severity: SeverityError, Message: "there are no questions"}}, issues)
+	})
+
+	t.Run("ValidateQuestions Invalid", func(t *testing.T) {
+		first := valid
+		first.Position = 1
+		questions := QuestionMap{
+			"1": first,
+			"3": {
+				Label:    " Pick one",
+				Position: 1,
+				Options: []Option{
+					{ID: "A", Label: "Yes", IsCorrect: true},
+					{ID: "A", Label: "yes", IsCorrect: true},
+					{ID: "D", Label: ""},
+				},
+			},
+			"x": valid,
+		}
+
+		issues := ValidateQuestions(questions)
+
+		assert.Equal(t, Issues{
+			{QuestionID: "3", Rule: "whitespace", Severity: SeverityInfo, Message: "question text has leading or trailing spaces"},
+			{QuestionID: "3", Rule: "question-mark", Severity: SeverityInfo, Message: "question text does not end with a question mark"},
+			{QuestionID: "3", Rule: "duplicate-option-id", Severity: SeverityError, Message: `duplicate option id "A"`},
+			{QuestionID: "3", Rule: "duplicate-option-label", Severity: SeverityWarning, Message: "option A repeats the text of another option"},
+			{QuestionID: "3", Rule: "option-id-order", Severity: SeverityWarning, Message: "option D is in the position of option C"},
+			{QuestionID: "3", Rule: "empty-option-label", Severity: SeverityError, Message: "option D has no text"},
+			{QuestionID: "3", Rule: "correct-option-count", Severity: SeverityError, Message: "question must have exactly one correct option, found 2"},
+			{QuestionID: "3", Rule: "duplicate-position", Severity: SeverityWarning, Message: "same position as question 1"},
+			{QuestionID: "x", Rule: "duplicate-question", Severity: SeverityWarning, Message: "same text as question 1"},
+		}, issues)
+		assert.Len(t, issues.Errors(), 3)
+		assert.True(t, issues.HasErrors())
+	})
+}
+
+func TestSortIDs(t *testing.T) {
+	ids := []string{"10", "b", "2", "a", "1"}
+
+	SortIDs(ids)
+
+	assert.Equal(t, []string{"1", "2", "10", "a", "b"}, ids)
+}
+
+func TestQuestionOrder(t *testing.T) {
+	questions := QuestionMap{
+		"1":     {Position: 3},
+		"2":     {},
+		"10":    {Position: 1},
+		"intro": {Position: 2},
+		"a":     {},
+	}
+
+	assert.Equal(t, []string{"10", "intro", "1", "2", "a"}, questions.Order(), "questions without a position come last, by id")
+	assert.Equal(t, 3, questions.MaxPosition())
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrWebhookNotFound is returned for a webhook that does not exist.
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook subscribes a URL to some of the events of the quiz. Every payload
+// sent to it is signed with its secret.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is an event to send to a webhook, and how sending it went. It
+// stays pending until the webhook accepts it or the attempts run out.
+type Delivery struct {
+	ID        string          `json:"id"`
+	WebhookID string          `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    DeliveryStatus  `json:"status"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+	// NextAttemptAt is when a pending delivery is due.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	// ResponseStatus is the status the webhook answered the last attempt
+	// with, zero when it couldn't be reached.
+	ResponseStatus int    `json:"response_status,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook Webhook) (*Webhook, error)
+	GetWebhooks(ctx context.Context) ([]Webhook, error)
+	// DeleteWebhook returns ErrWebhookNotFound when there is no such webhook.
+	DeleteWebhook(ctx context.Context, id string) error
+	// AddDeliveries queues the deliveries, giving each an id.
+	AddDeliveries(ctx context.Context, deliveries []Delivery) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, delivery Delivery) error
+	// GetDeliveries returns the deliveries, newest first.
+	GetDeliveries(ctx context.Context) ([]Delivery, error)
+}
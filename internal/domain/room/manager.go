@@ -0,0 +1,84 @@
+package room
+
+import (
+	"crypto/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+const (
+	codeLength = 6
+	// codeAlphabet leaves out the characters that are easily mistaken for
+	// one another, such as 0 and O.
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// Manager keeps the open rooms by their join code. It is safe for
+// concurrent use.
+type Manager struct {
+	mu    sync.Mutex
+	rooms map[string]*Room
+}
+
+func NewManager() *Manager {
+	return &Manager{rooms: map[string]*Room{}}
+}
+
+// Create opens a room asking the questions, with limit to answer each of
+// them. The room stays open until its host leaves or the quiz finishes.
+func (m *Manager) Create(questions model.QuestionMap, limit time.Duration) (*Room, error) {
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code := newCode()
+	for m.rooms[code] != nil {
+		code = newCode()
+	}
+	room := newRoom(code, questions, limit, func() { m.remove(code) })
+	m.rooms[code] = room
+	return room, nil
+}
+
+// Get finds a room by its code, in any case.
+func (m *Manager) Get(code string) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	room, exists := m.rooms[strings.ToUpper(code)]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return room, nil
+}
+
+// Len is the number of open rooms.
+func (m *Manager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.rooms)
+}
+
+// remove is called by rooms as they close, with their lock held, so it
+// must not call them.
+func (m *Manager) remove(code string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rooms, code)
+}
+
+func newCode() string {
+	random := make([]byte, codeLength)
+	if _, err := rand.Read(random); err != nil {
+		panic(err)
+	}
+	code := make([]byte, codeLength)
+	for i, b := range random {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(code)
+}
@@ -0,0 +1,501 @@
+// Package room runs live quizzes, where a host takes a group of players
+// through the questions at the same time and the fastest right answers
+// score the most.
+package room
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// MaxPoints are scored by a right answer given at once. The points go down
+// with the time taken, to half of them for a right answer given as the time
+// runs out.
+const MaxPoints = 1000
+
+const (
+	// MaxPlayers is the number of players a room takes.
+	MaxPlayers = 100
+	// MaxNameLength is the length, in characters, of the longest name.
+	MaxNameLength = 40
+	// messageBuffer is the number of messages waiting to be sent to a
+	// participant before they are dropped for being too slow.
+	messageBuffer = 64
+)
+
+type Phase string
+
+const (
+	// Lobby is the phase in which players join, until the host asks the
+	// first question.
+	Lobby Phase = "lobby"
+	// Asking is the phase in which a question is answered, until every
+	// player answered, the time ran out or the host moved on.
+	Asking Phase = "question"
+	// Revealing is the phase in which the results of a question are shown,
+	// until the host moves on.
+	Revealing Phase = "results"
+	Finished  Phase = "finished"
+)
+
+var (
+	ErrNoQuestions = errors.New("there are no questions")
+	ErrNotFound    = errors.New("the room does not exist")
+	ErrNoName      = errors.New("a name is required")
+	ErrLongName    = errors.New("the name is too long")
+	ErrNameTaken   = errors.New("the name is taken")
+	ErrStarted     = errors.New("the quiz has already started")
+	ErrFull        = errors.New("the room is full")
+	ErrClosed      = errors.New("the room is closed")
+)
+
+// The types of the messages sent by the room.
+const (
+	// MessageRoom tells the host the code players join with.
+	MessageRoom = "room"
+	// MessageJoined tells a player they joined.
+	MessageJoined = "joined"
+	// MessagePlayers lists the players after one joins or leaves the lobby.
+	MessagePlayers = "players"
+	// MessageQuestion asks a question.
+	MessageQuestion = "question"
+	// MessageAnswerReceived tells a player their answer was taken.
+	MessageAnswerReceived = "answer_received"
+	// MessageProgress tells the host how many players answered so far.
+	MessageProgress = "progress"
+	// MessageResults reveals the right answer and the standings.
+	MessageResults = "results"
+	// MessageFinished gives the final standings. The room closes after it.
+	MessageFinished = "finished"
+	// MessageError tells a participant their command was refused.
+	MessageError = "error"
+)
+
+// The types of the commands sent to the room.
+const (
+	// CommandNext is sent by the host to ask the next question or, while a
+	// question is asked, to reveal its results without waiting.
+	CommandNext = "next"
+	// CommandAnswer is sent by a player to answer the question asked.
+	CommandAnswer = "answer"
+)
+
+// Message is sent by the room to the host and the players.
+type Message struct {
+	Type      string     `json:"type"`
+	Code      string     `json:"code,omitempty"`
+	Players   []string   `json:"players,omitempty"`
+	Question  *Question  `json:"question,omitempty"`
+	Answered  int        `json:"answered,omitempty"`
+	Result    *Result    `json:"result,omitempty"`
+	Standings []Standing `json:"standings,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type Question struct {
+	Number   int       `json:"number"`
+	Total    int       `json:"total"`
+	Label    string    `json:"label"`
+	Options  []Option  `json:"options"`
+	Seconds  int       `json:"seconds"`
+	Deadline time.Time `json:"deadline"`
+}
+
+type Option struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// Result is the outcome of a question. Players are also told whether they
+// got it right and the points they scored.
+type Result struct {
+	Number         int      `json:"number"`
+	CorrectOptions []string `json:"correct_options"`
+	Answered       bool     `json:"answered,omitempty"`
+	Correct        bool     `json:"correct,omitempty"`
+	Points         int      `json:"points,omitempty"`
+}
+
+// Standing is the position of a player. Players with the same score share
+// their rank.
+type Standing struct {
+	Rank  int    `json:"rank"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+}
+
+// Command is sent by the host or a player to the room.
+type Command struct {
+	Type     string `json:"type"`
+	OptionID string `json:"option_id,omitempty"`
+}
+
+// Points scores a right answer given elapsed after the question was asked.
+func Points(elapsed, limit time.Duration) int {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed > limit {
+		return 0
+	}
+	return int(math.Round(MaxPoints * (1 - float64(elapsed)/float64(limit)/2)))
+}
+
+// Participant is the host or a player of a room. The messages for them are
+// read from Messages, which is closed when they leave or the room closes.
+type Participant struct {
+	name     string
+	host     bool
+	messages chan Message
+	left     bool
+
+	score    int
+	answered bool
+	correct  bool
+	points   int
+}
+
+func newParticipant(name string, host bool) *Participant {
+	return &Participant{name: name, host: host, messages: make(chan Message, messageBuffer)}
+}
+
+func (p *Participant) Name() string {
+	return p.name
+}
+
+func (p *Participant) IsHost() bool {
+	return p.host
+}
+
+func (p *Participant) Messages() <-chan Message {
+	return p.messages
+}
+
+type question struct {
+	id string
+	model.Question
+}
+
+// Room takes its players through the questions, ordered by position, as the
+// host moves on. It is safe for concurrent use.
+type Room struct {
+	code      string
+	questions []question
+	limit     time.Duration
+	now       func() time.Time
+	onClose   func()
+
+	mu      sync.Mutex
+	phase   Phase
+	current int
+	askedAt time.Time
+	timer   *time.Timer
+	host    *Participant
+	players []*Participant
+}
+
+func newRoom(code string, questions model.QuestionMap, limit time.Duration, onClose func()) *Room {
+	ids := questions.Order()
+	r := &Room{
+		code:    code,
+		limit:   limit,
+		now:     time.Now,
+		onClose: onClose,
+		phase:   Lobby,
+		current: -1,
+		host:    newParticipant("", true),
+	}
+	for _, id := range ids {
+		r.questions = append(r.questions, question{id: id, Question: questions[id]})
+	}
+	r.send(r.host, Message{Type: MessageRoom, Code: code})
+	return r
+}
+
+func (r *Room) Code() string {
+	return r.code
+}
+
+func (r *Room) Host() *Participant {
+	return r.host
+}
+
+func (r *Room) Phase() Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phase
+}
+
+// Join adds a player to the room, which is only possible in the lobby.
+func (r *Room) Join(name string) (*Participant, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return nil, ErrNoName
+	case len([]rune(name)) > MaxNameLength:
+		return nil, ErrLongName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case r.phase == Finished:
+		return nil, ErrClosed
+	case r.phase != Lobby:
+		return nil, ErrStarted
+	case len(r.players) >= MaxPlayers:
+		return nil, ErrFull
+	}
+	for _, player := range r.players {
+		if strings.EqualFold(player.name, name) {
+			return nil, ErrNameTaken
+		}
+	}
+
+	player := newParticipant(name, false)
+	r.players = append(r.players, player)
+	r.send(player, Message{Type: MessageJoined, Code: r.code})
+	r.broadcastPlayers()
+	return player, nil
+}
+
+// Leave takes a participant out of the room. The room closes when the host
+// leaves. Players leaving after the quiz started keep their place in the
+// standings.
+func (r *Room) Leave(p *Participant) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.host {
+		r.finish()
+		return
+	}
+	r.drop(p)
+	if r.phase == Lobby {
+		for i, player := range r.players {
+			if player == p {
+				r.players = append(r.players[:i], r.players[i+1:]...)
+				break
+			}
+		}
+		r.broadcastPlayers()
+	}
+	if r.phase == Asking && r.everyoneAnswered() {
+		r.reveal()
+	}
+}
+
+// Handle runs a command of a participant, telling them when it is refused.
+func (r *Room) Handle(p *Participant, command Command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.handle(p, command); err != nil {
+		r.send(p, Message{Type: MessageError, Error: err.Error()})
+	}
+}
+
+func (r *Room) handle(p *Participant, command Command) error {
+	switch command.Type {
+	case CommandNext:
+		if !p.host {
+			return errors.New("only the host can move on")
+		}
+		return r.next()
+	case CommandAnswer:
+		if p.host {
+			return errors.New("the host can't answer")
+		}
+		return r.answer(p, command.OptionID)
+	}
+	return fmt.Errorf("unknown command %q", command.Type)
+}
+
+func (r *Room) next() error {
+	switch r.phase {
+	case Lobby, Revealing:
+		if r.current+1 == len(r.questions) {
+			r.finish()
+			return nil
+		}
+		r.ask(r.current + 1)
+	case Asking:
+		r.reveal()
+	default:
+		return ErrClosed
+	}
+	return nil
+}
+
+func (r *Room) ask(index int) {
+	r.phase = Asking
+	r.current = index
+	r.askedAt = r.now()
+	for _, player := range r.players {
+		player.answered, player.correct, player.points = false, false, 0
+	}
+	r.timer = time.AfterFunc(r.limit, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.phase == Asking && r.current == index {
+			r.reveal()
+		}
+	})
+
+	q := r.questions[index]
+	message := &Question{
+		Number:   index + 1,
+		Total:    len(r.questions),
+		Label:    q.Label,
+		Seconds:  int(r.limit.Round(time.Second) / time.Second),
+		Deadline: r.askedAt.Add(r.limit),
+	}
+	for _, option := range q.Options {
+		message.Options = append(message.Options, Option{ID: option.ID, Label: option.Label})
+	}
+	r.broadcast(Message{Type: MessageQuestion, Question: message})
+}
+
+func (r *Room) answer(p *Participant, optionID string) error {
+	if r.phase != Asking {
+		return errors.New("no question is being asked")
+	}
+	if p.answered {
+		return errors.New("the question is already answered")
+	}
+	elapsed := r.now().Sub(r.askedAt)
+	if elapsed > r.limit {
+		return errors.New("the time is up")
+	}
+	var option *model.Option
+	for i := range r.questions[r.current].Options {
+		if r.questions[r.current].Options[i].ID == optionID {
+			option = &r.questions[r.current].Options[i]
+		}
+	}
+	if option == nil {
+		return errors.New("the option does not exist")
+	}
+
+	p.answered = true
+	p.correct = option.IsCorrect
+	if p.correct {
+		p.points = Points(elapsed, r.limit)
+		p.score += p.points
+	}
+	r.send(p, Message{Type: MessageAnswerReceived})
+	if r.everyoneAnswered() {
+		r.reveal()
+		return nil
+	}
+	answered := 0
+	for _, player := range r.players {
+		if player.answered {
+			answered++
+		}
+	}
+	r.send(r.host, Message{Type: MessageProgress, Answered: answered})
+	return nil
+}
+
+func (r *Room) everyoneAnswered() bool {
+	for _, player := range r.players {
+		if !player.left && !player.answered {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Room) reveal() {
+	r.timer.Stop()
+	r.phase = Revealing
+	result := Result{Number: r.current + 1}
+	for _, option := range r.questions[r.current].Options {
+		if option.IsCorrect {
+			result.CorrectOptions = append(result.CorrectOptions, option.ID)
+		}
+	}
+	standings := r.standings()
+	hostResult := result
+	r.send(r.host, Message{Type: MessageResults, Result: &hostResult, Standings: standings})
+	for _, player := range r.players {
+		playerResult := result
+		playerResult.Answered, playerResult.Correct, playerResult.Points = player.answered, player.correct, player.points
+		r.send(player, Message{Type: MessageResults, Result: &playerResult, Standings: standings})
+	}
+}
+
+// finish sends the final standings and closes the room.
+func (r *Room) finish() {
+	if r.phase == Finished {
+		return
+	}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.phase = Finished
+	r.broadcast(Message{Type: MessageFinished, Standings: r.standings()})
+	r.drop(r.host)
+	for _, player := range r.players {
+		r.drop(player)
+	}
+	if r.onClose != nil {
+		r.onClose()
+	}
+}
+
+func (r *Room) standings() []Standing {
+	players := append([]*Participant(nil), r.players...)
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].score > players[j].score
+	})
+	standings := make([]Standing, len(players))
+	for i, player := range players {
+		standings[i] = Standing{Rank: i + 1, Name: player.name, Score: player.score}
+		if i > 0 && player.score == players[i-1].score {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
+
+func (r *Room) broadcastPlayers() {
+	names := make([]string, len(r.players))
+	for i, player := range r.players {
+		names[i] = player.name
+	}
+	r.broadcast(Message{Type: MessagePlayers, Players: names})
+}
+
+func (r *Room) broadcast(message Message) {
+	r.send(r.host, message)
+	for _, player := range r.players {
+		r.send(player, message)
+	}
+}
+
+// send queues a message for a participant, dropping them when they can't
+// keep up.
+func (r *Room) send(p *Participant, message Message) {
+	if p.left {
+		return
+	}
+	select {
+	case p.messages <- message:
+	default:
+		r.drop(p)
+	}
+}
+
+func (r *Room) drop(p *Participant) {
+	if !p.left {
+		p.left = true
+		close(p.messages)
+	}
+}
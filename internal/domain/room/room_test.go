@@ -0,0 +1,206 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var questions = model.QuestionMap{
+	"2": {Label: "Is 2 even?", Options: []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}},
+	"1": {Label: "Is 1 even?", Options: []model.Option{{ID: "A", Label: "Yes"}, {ID: "B", Label: "No", IsCorrect: true}}},
+}
+
+// receive takes the next message of a participant, failing when there is
+// none.
+func receive(t *testing.T, p *Participant) Message {
+	t.Helper()
+	select {
+	case message, open := <-p.Messages():
+		require.True(t, open, "the messages were closed")
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+		return Message{}
+	}
+}
+
+func TestPoints(t *testing.T) {
+	assert.Equal(t, MaxPoints, Points(0, 10*time.Second))
+	assert.Equal(t, 750, Points(5*time.Second, 10*time.Second))
+	assert.Equal(t, 500, Points(10*time.Second, 10*time.Second))
+	assert.Equal(t, 0, Points(11*time.Second, 10*time.Second))
+	assert.Equal(t, MaxPoints, Points(-time.Second, 10*time.Second))
+}
+
+func TestRoom(t *testing.T) {
+	manager := NewManager()
+	room, err := manager.Create(questions, 10*time.Second)
+	require.NoError(t, err)
+	now := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	room.now = func() time.Time { return now }
+	host := room.Host()
+	assert.Equal(t, Message{Type: MessageRoom, Code: room.Code()}, receive(t, host))
+
+	found, err := manager.Get(room.Code())
+	require.NoError(t, err)
+	assert.Same(t, room, found)
+
+	ana, err := room.Join(" Ana ")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Type: MessageJoined, Code: room.Code()}, receive(t, ana))
+	bob, err := room.Join("Bob")
+	require.NoError(t, err)
+	receive(t, bob)
+	_, err = room.Join("ana")
+	assert.ErrorIs(t, err, ErrNameTaken)
+	_, err = room.Join(" ")
+	assert.ErrorIs(t, err, ErrNoName)
+	assert.Equal(t, []string{"Ana"}, receive(t, host).Players)
+	assert.Equal(t, []string{"Ana", "Bob"}, receive(t, host).Players)
+	receive(t, ana)
+	assert.Equal(t, []string{"Ana", "Bob"}, receive(t, ana).Players)
+	assert.Equal(t, []string{"Ana", "Bob"}, receive(t, bob).Players)
+
+	room.Handle(ana, Command{Type: CommandNext})
+	assert.Equal(t, "only the host can move on", receive(t, ana).Error)
+
+	// The questions are asked in order, by id as none has a position.
+	room.Handle(host, Command{Type: CommandNext})
+	for _, p := range []*Participant{host, ana, bob} {
+		message := receive(t, p)
+		assert.Equal(t, MessageQuestion, message.Type)
+		assert.Equal(t, Question{
+			Number: 1, Total: 2, Label: "Is 1 even?",
+			Options:  []Option{{ID: "A", Label: "Yes"}, {ID: "B", Label: "No"}},
+			Seconds:  10,
+			Deadline: now.Add(10 * time.Second),
+		}, *message.Question)
+	}
+	_, err = room.Join("Cy")
+	assert.ErrorIs(t, err, ErrStarted)
+
+	now = now.Add(2 * time.Second)
+	room.Handle(ana, Command{Type: CommandAnswer, OptionID: "B"})
+	assert.Equal(t, MessageAnswerReceived, receive(t, ana).Type)
+	assert.Equal(t, Message{Type: MessageProgress, Answered: 1}, receive(t, host))
+	room.Handle(ana, Command{Type: CommandAnswer, OptionID: "A"})
+	assert.Equal(t, "the question is already answered", receive(t, ana).Error)
+	room.Handle(bob, Command{Type: CommandAnswer, OptionID: "C"})
+	assert.Equal(t, "the option does not exist", receive(t, bob).Error)
+
+	// The results are revealed once everyone answered.
+	now = now.Add(3 * time.Second)
+	room.Handle(bob, Command{Type: CommandAnswer, OptionID: "A"})
+	assert.Equal(t, MessageAnswerReceived, receive(t, bob).Type)
+	assert.Equal(t, Revealing, room.Phase())
+	standings := []Standing{{Rank: 1, Name: "Ana", Score: 900}, {Rank: 2, Name: "Bob", Score: 0}}
+	assert.Equal(t, Message{Type: MessageResults, Result: &Result{Number: 1, CorrectOptions: []string{"B"}}, Standings: standings}, receive(t, host))
+	assert.Equal(t, Message{Type: MessageResults, Result: &Result{Number: 1, CorrectOptions: []string{"B"}, Answered: true, Correct: true, Points: 900}, Standings: standings}, receive(t, ana))
+	assert.Equal(t, Message{Type: MessageResults, Result: &Result{Number: 1, CorrectOptions: []string{"B"}, Answered: true}, Standings: standings}, receive(t, bob))
+
+	// The host can reveal the results before everyone answered.
+	room.Handle(host, Command{Type: CommandNext})
+	for _, p := range []*Participant{host, ana, bob} {
+		assert.Equal(t, 2, receive(t, p).Question.Number)
+	}
+	now = now.Add(2 * time.Second)
+	room.Handle(bob, Command{Type: CommandAnswer, OptionID: "A"})
+	receive(t, bob)
+	receive(t, host)
+	room.Handle(host, Command{Type: CommandNext})
+	standings = []Standing{{Rank: 1, Name: "Ana", Score: 900}, {Rank: 1, Name: "Bob", Score: 900}}
+	assert.Equal(t, standings, receive(t, host).Standings)
+	assert.Equal(t, &Result{Number: 2, CorrectOptions: []string{"A"}}, receive(t, ana).Result)
+	assert.Equal(t, 900, receive(t, bob).Result.Points)
+
+	// Moving on after the last question finishes the quiz and closes the
+	// room.
+	room.Handle(host, Command{Type: CommandNext})
+	for _, p := range []*Participant{host, ana, bob} {
+		assert.Equal(t, Message{Type: MessageFinished, Standings: standings}, receive(t, p))
+		_, open := <-p.Messages()
+		assert.False(t, open)
+	}
+	assert.Equal(t, Finished, room.Phase())
+	_, err = manager.Get(room.Code())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 0, manager.Len())
+}
+
+func TestRoomCountdown(t *testing.T) {
+	room, err := NewManager().Create(questions, 50*time.Millisecond)
+	require.NoError(t, err)
+	host := room.Host()
+	receive(t, host)
+	ana, err := room.Join("Ana")
+	require.NoError(t, err)
+	receive(t, host)
+
+	room.Handle(host, Command{Type: CommandNext})
+	receive(t, host)
+	message := receive(t, host)
+	assert.Equal(t, MessageResults, message.Type)
+	assert.Equal(t, []Standing{{Rank: 1, Name: "Ana"}}, message.Standings)
+
+	receive(t, ana)
+	receive(t, ana)
+	receive(t, ana)
+	assert.Equal(t, &Result{Number: 1, CorrectOptions: []string{"B"}}, receive(t, ana).Result)
+	room.Handle(ana, Command{Type: CommandAnswer, OptionID: "B"})
+	assert.Equal(t, "no question is being asked", receive(t, ana).Error)
+}
+
+func TestRoomLeave(t *testing.T) {
+	manager := NewManager()
+	room, err := manager.Create(questions, time.Minute)
+	require.NoError(t, err)
+	host := room.Host()
+	receive(t, host)
+	ana, err := room.Join("Ana")
+	require.NoError(t, err)
+	bob, err := room.Join("Bob")
+	require.NoError(t, err)
+	receive(t, host)
+	receive(t, host)
+
+	// Players leaving the lobby are forgotten.
+	room.Leave(bob)
+	assert.Equal(t, []string{"Ana"}, receive(t, host).Players)
+
+	// The results are revealed once the players left all answered.
+	cy, err := room.Join("Cy")
+	require.NoError(t, err)
+	receive(t, host)
+	room.Handle(host, Command{Type: CommandNext})
+	receive(t, host)
+	room.Handle(ana, Command{Type: CommandAnswer, OptionID: "A"})
+	receive(t, host)
+	room.Leave(cy)
+	assert.Equal(t, MessageResults, receive(t, host).Type)
+
+	// The room closes when the host leaves.
+	room.Leave(host)
+	assert.Equal(t, MessageFinished, receive(t, host).Type)
+	assert.Equal(t, 0, manager.Len())
+	_, err = room.Join("Dan")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestManager(t *testing.T) {
+	manager := NewManager()
+	_, err := manager.Create(model.QuestionMap{}, time.Minute)
+	assert.ErrorIs(t, err, ErrNoQuestions)
+
+	room, err := manager.Create(questions, time.Minute)
+	require.NoError(t, err)
+	assert.Regexp(t, "^[A-HJ-NP-Z2-9]{6}$", room.Code())
+	_, err = manager.Get(room.Code())
+	assert.NoError(t, err)
+	_, err = manager.Get("nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, manager.Len())
+}
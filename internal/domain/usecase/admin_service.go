@@ -0,0 +1,548 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/backup"
+	"github.com/MFCaballero/simple-quiz/internal/domain/importer"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminService struct {
+	userRepo     model.UserRepository
+	questionRepo model.QuestionRepository
+	eventRepo    model.EventRepository
+	backupRepo   model.BackupRepository
+	// leaderboard is the one of the UserService, which must forget the
+	// users it ranked when a backup is restored.
+	leaderboard *Leaderboard
+	logger      *slog.Logger
+}
+
+func NewAdminService(userRepo model.UserRepository, questionRepo model.QuestionRepository, eventRepo model.EventRepository, backupRepo model.BackupRepository, leaderboard *Leaderboard, logger *slog.Logger) *AdminService {
+	return &AdminService{
+		userRepo:     userRepo,
+		questionRepo: questionRepo,
+		eventRepo:    eventRepo,
+		backupRepo:   backupRepo,
+		leaderboard:  leaderboard,
+		logger:       logger,
+	}
+}
+
+func (as *AdminService) GetQuestionStats(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured getting question statistics"
+
+	users, err := as.userRepo.GetAllUsers(r.Context())
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+	questions, err := as.questionRepo.GetAllQuestions(r.Context())
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(analyzeItems(questions, users)); err != nil {
+		as.logger.ErrorContext(r.Context(), "encoding question statistics to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (as *AdminService) ExportResults(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured exporting results"
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "jsonl" {
+		http.Error(w, "format must be csv or jsonl", http.StatusBadRequest)
+		return
+	}
+
+	users, err := as.userRepo.GetAllUsers(r.Context())
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+	questions, err := as.questionRepo.GetAllQuestions(r.Context())
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+	questionIDs := questions.Order()
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=results.%s", format))
+	if format == "jsonl" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		for _, id := range sortedIDs(users) {
+			if err := encoder.Encode(toResultRow(users[id])); err != nil {
+				as.logger.ErrorContext(r.Context(), "encoding results to json lines", "error", err)
+				return
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	header := []string{"id", "name", "finished", "score", "correct_answers", "finished_at"}
+	for _, questionID := range questionIDs {
+		header = append(header, "question_"+questionID)
+	}
+	if err := writer.Write(header); err != nil {
+		as.logger.ErrorContext(r.Context(), "writing results csv", "error", err)
+		return
+	}
+	for _, id := range sortedIDs(users) {
+		row := toResultRow(users[id])
+		record := []string{
+			row.ID,
+			csvCell(row.Name),
+			strconv.FormatBool(row.Finished),
+			strconv.FormatFloat(float64(row.Score), 'f', -1, 32),
+			strconv.Itoa(row.CorrectAnswers),
+			"",
+		}
+		if row.FinishedAt != nil {
+			record[5] = row.FinishedAt.Format(time.RFC3339)
+		}
+		for _, questionID := range questionIDs {
+			record = append(record, csvCell(row.Answers[questionID]))
+		}
+		if err := writer.Write(record); err != nil {
+			as.logger.ErrorContext(r.Context(), "writing results csv", "error", err)
+			return
+		}
+		writer.Flush()
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		as.logger.ErrorContext(r.Context(), "writing results csv", "error", err)
+	}
+}
+
+const maxImportSize = 10 << 20
+
+// ListUsers serves a page of the users as result rows, ordered by id unless
+// sorted otherwise, optionally filtered by name and by whether they finished.
+func (as *AdminService) ListUsers(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	options, err := parseListOptions(values, model.UserSortKeys.Fields())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	finished, err := parseBoolFilter(values, "finished")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := as.userRepo.ListUsers(r.Context(), model.UserQuery{ListOptions: options, Name: values.Get("name"), Finished: finished})
+	if err != nil {
+		writeStatusError(w, listError(err, "An error occured listing users"))
+		return
+	}
+
+	response := UserPage{Users: make([]ResultRow, len(page.Users)), NextCursor: page.NextCursor}
+	for i, user := range page.Users {
+		response.Users[i] = toResultRow(user)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		as.logger.ErrorContext(r.Context(), "encoding users to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (as *AdminService) ImportQuestions(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured importing questions"
+	values := r.URL.Query()
+
+	format, err := importer.ParseFormat(values.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := values.Get("dry_run") == "true"
+	replace := values.Get("replace") == "true"
+
+	imported, err := importer.Parse(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		var errs importer.ErrorList
+		if !errors.As(err, &errs) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(ImportReport{DryRun: dryRun, Errors: errs}); err != nil {
+			as.logger.ErrorContext(r.Context(), "encoding import report to json", "error", err)
+		}
+		return
+	}
+
+	questions := model.QuestionMap{}
+	if !replace {
+		questions, err = as.questionRepo.GetAllQuestions(r.Context())
+		if err != nil {
+			http.Error(w, errMessage, http.StatusInternalServerError)
+			return
+		}
+	}
+	report := ImportReport{
+		DryRun:   dryRun,
+		Replaced: replace,
+	}
+	next, position := nextQuestionID(questions), questions.MaxPosition()
+	for _, id := range imported.Order() {
+		newID := strconv.Itoa(next)
+		question := imported[id]
+		question.Position += position
+		questions[newID] = question
+		report.QuestionIDs = append(report.QuestionIDs, newID)
+		next++
+	}
+	report.Imported = len(imported)
+	report.Total = len(questions)
+	if issues := model.ValidateQuestions(questions).Errors(); len(issues) > 0 {
+		report.Issues = issues
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			as.logger.ErrorContext(r.Context(), "encoding import report to json", "error", err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun {
+		if err := as.questionRepo.SaveQuestions(r.Context(), questions); err != nil {
+			http.Error(w, errMessage, http.StatusInternalServerError)
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		as.logger.ErrorContext(r.Context(), "encoding import report to json", "error", err)
+	}
+}
+
+type ImportReport struct {
+	DryRun      bool               `json:"dry_run"`
+	Replaced    bool               `json:"replaced"`
+	Imported    int                `json:"imported"`
+	Total       int                `json:"total"`
+	QuestionIDs []string           `json:"question_ids,omitempty"`
+	Errors      importer.ErrorList `json:"errors,omitempty"`
+	Issues      model.Issues       `json:"issues,omitempty"`
+}
+
+// nextQuestionID returns the number following the highest numeric question
+// id, so imported questions don't take the id of an existing one.
+func nextQuestionID(questions model.QuestionMap) int {
+	highest := 0
+	for id := range questions {
+		if n, err := strconv.Atoi(id); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return highest + 1
+}
+
+func (as *AdminService) GetUserTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "user")
+	errMessage := "An error occured getting user's timeline"
+
+	if _, err := as.userRepo.GetUser(r.Context(), userID); err != nil {
+		http.Error(w, errMessage, http.StatusNotFound)
+		return
+	}
+	events, err := as.eventRepo.GetUserEvents(r.Context(), userID)
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	timeline := Timeline{
+		UserID: userID,
+		Steps:  make([]TimelineStep, len(events)),
+	}
+	var user model.User
+	for i, event := range events {
+		user.Apply(event)
+		if event.Type == model.EventAnswerChanged {
+			timeline.AnswerChanges++
+		}
+		timeline.Steps[i] = TimelineStep{
+			Event:    event,
+			Answered: len(user.Answers),
+		}
+	}
+	timeline.User = user
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(timeline); err != nil {
+		as.logger.ErrorContext(r.Context(), "encoding user timeline to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (as *AdminService) Backup(w http.ResponseWriter, r *http.Request) {
+	data, err := as.backupRepo.Backup(r.Context())
+	if err != nil {
+		http.Error(w, "An error occured backing up the quiz", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("quiz-backup-%s.tar.gz", data.CreatedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := backup.Write(w, *data); err != nil {
+		as.logger.ErrorContext(r.Context(), "writing backup", "error", err)
+	}
+}
+
+// maxBackupSize bounds the archives accepted by Restore.
+const maxBackupSize = 64 << 20
+
+func (as *AdminService) Restore(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured restoring the backup"
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	restored, err := backup.Read(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := as.backupRepo.Migrate(r.Context(), restored); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	current, err := as.backupRepo.Backup(r.Context())
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	report := backup.Compare(*current, *restored)
+	report.DryRun = dryRun
+	report.Issues = backup.Validate(*restored)
+	status := http.StatusOK
+	switch {
+	case report.Issues.HasErrors():
+		status = http.StatusUnprocessableEntity
+	case !dryRun:
+		if err := as.backupRepo.Restore(r.Context(), *restored); err != nil {
+			http.Error(w, errMessage, http.StatusInternalServerError)
+			return
+		}
+		if as.leaderboard != nil {
+			as.leaderboard.Reset()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		as.logger.ErrorContext(r.Context(), "encoding restore report to json", "error", err)
+	}
+}
+
+// Timeline is the replay of a user's events. User is the state rebuilt from
+// the events, which can be compared with the stored user during a dispute.
+type Timeline struct {
+	UserID        string         `json:"user_id"`
+	AnswerChanges int            `json:"answer_changes"`
+	Steps         []TimelineStep `json:"steps"`
+	User          model.User     `json:"user"`
+}
+
+type TimelineStep struct {
+	Event    model.Event `json:"event"`
+	Answered int         `json:"answered"`
+}
+
+type ResultRow struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Finished       bool              `json:"finished"`
+	Score          float32           `json:"score"`
+	CorrectAnswers int               `json:"correct_answers"`
+	FinishedAt     *time.Time        `json:"finished_at,omitempty"`
+	Answers        map[string]string `json:"answers"`
+}
+
+// UserPage is a page of the users.
+type UserPage struct {
+	Users      []ResultRow `json:"users"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// csvCell keeps a spreadsheet from reading a cell as a formula, prefixing
+// it with a quote when it starts like one. Spreadsheets drop a leading tab
+// or carriage return before reading a formula, so those are prefixed too.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func toResultRow(user model.User) ResultRow {
+	row := ResultRow{
+		ID:         user.ID,
+		Name:       user.Name,
+		Finished:   user.FinishedQuiz,
+		Score:      user.Score,
+		FinishedAt: user.FinishedAt,
+		Answers:    make(map[string]string, len(user.Answers)),
+	}
+	for _, answer := range user.Answers {
+		row.Answers[answer.QuestionID] = answer.Option.ID
+		if answer.Option.IsCorrect {
+			row.CorrectAnswers++
+		}
+	}
+	if user.TotalQuestions > 0 {
+		// The answers to questions removed since the user finished didn't
+		// count.
+		row.CorrectAnswers = user.CorrectAnswers
+	}
+	return row
+}
+
+type ItemAnalysis struct {
+	Participants int             `json:"participants"`
+	Questions    []QuestionStats `json:"questions"`
+}
+
+type QuestionStats struct {
+	QuestionID     string        `json:"question_id"`
+	Question       string        `json:"question"`
+	Responses      int           `json:"responses"`
+	Difficulty     float64       `json:"difficulty"`
+	Discrimination float64       `json:"discrimination"`
+	Options        []OptionStats `json:"options"`
+}
+
+type OptionStats struct {
+	ID            string  `json:"id"`
+	Label         string  `json:"label"`
+	IsCorrect     bool    `json:"is_correct"`
+	Selections    int     `json:"selections"`
+	SelectionRate float64 `json:"selection_rate"`
+}
+
+// analyzeItems runs a classical item analysis over the users that finished
+// the quiz. Difficulty is the proportion of correct responses (p-value) and
+// discrimination is the point-biserial correlation between answering the
+// question correctly and the number of correct answers in the whole quiz.
+func analyzeItems(questions model.QuestionMap, users model.UserMap) ItemAnalysis {
+	type response struct {
+		optionID string
+		correct  bool
+		total    float64
+	}
+	responses := make(map[string][]response, len(questions))
+	participants := 0
+	for _, user := range users {
+		if !user.FinishedQuiz {
+			continue
+		}
+		participants++
+		var total float64
+		for _, answer := range user.Answers {
+			if answer.Option.IsCorrect {
+				total++
+			}
+		}
+		for _, answer := range user.Answers {
+			responses[answer.QuestionID] = append(responses[answer.QuestionID], response{
+				optionID: answer.Option.ID,
+				correct:  answer.Option.IsCorrect,
+				total:    total,
+			})
+		}
+	}
+
+	analysis := ItemAnalysis{
+		Participants: participants,
+		Questions:    make([]QuestionStats, 0, len(questions)),
+	}
+	for _, id := range questions.Order() {
+		question := questions[id]
+		stats := QuestionStats{
+			QuestionID: id,
+			Question:   question.Label,
+			Responses:  len(responses[id]),
+			Options:    make([]OptionStats, len(question.Options)),
+		}
+		selections := map[string]int{}
+		var correct, sum, sumSquares, sumCorrect float64
+		for _, resp := range responses[id] {
+			selections[resp.optionID]++
+			sum += resp.total
+			sumSquares += resp.total * resp.total
+			if resp.correct {
+				correct++
+				sumCorrect += resp.total
+			}
+		}
+		for i, option := range question.Options {
+			stats.Options[i] = OptionStats{
+				ID:         option.ID,
+				Label:      option.Label,
+				IsCorrect:  option.IsCorrect,
+				Selections: selections[option.ID],
+			}
+			if stats.Responses > 0 {
+				stats.Options[i].SelectionRate = float64(selections[option.ID]) / float64(stats.Responses)
+			}
+		}
+		if stats.Responses > 0 {
+			n := float64(stats.Responses)
+			p := correct / n
+			stats.Difficulty = p
+			mean := sum / n
+			stdDev := math.Sqrt(sumSquares/n - mean*mean)
+			if p > 0 && p < 1 && stdDev > 0 {
+				meanCorrect := sumCorrect / correct
+				meanWrong := (sum - sumCorrect) / (n - correct)
+				stats.Discrimination = (meanCorrect - meanWrong) / stdDev * math.Sqrt(p*(1-p))
+			}
+		}
+		analysis.Questions = append(analysis.Questions, stats)
+	}
+	return analysis
+}
+
+// sortedIDs returns the keys of a question or user map in order.
+func sortedIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	model.SortIDs(ids)
+	return ids
+}
@@ -0,0 +1,543 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/backup"
+	"github.com/MFCaballero/simple-quiz/internal/domain/importer"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetQuestionStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+
+	adminService := NewAdminService(mockUserRepo, mockQuestionRepo, nil, nil, nil, nil)
+	optionA := model.Option{ID: "A", Label: "Option A", IsCorrect: true}
+	optionB := model.Option{ID: "B", Label: "Option B"}
+	optionC := model.Option{ID: "C", Label: "Option C"}
+	mockQuestions := model.QuestionMap{
+		"1":  {Label: "Question 1", Options: []model.Option{optionA, optionB, optionC}},
+		"2":  {Label: "Question 2", Options: []model.Option{{ID: "A", Label: "Option A"}, {ID: "B", Label: "Option B", IsCorrect: true}}},
+		"10": {Label: "Question 10", Options: []model.Option{optionA, optionB}},
+	}
+	wrongQ2 := model.Answer{QuestionID: "2", Option: model.Option{ID: "A", Label: "Option A"}}
+	mockUsers := model.UserMap{
+		"1": {ID: "1", FinishedQuiz: true, Answers: []model.Answer{
+			{QuestionID: "1", Option: optionA},
+			{QuestionID: "2", Option: model.Option{ID: "B", Label: "Option B", IsCorrect: true}},
+		}},
+		"2": {ID: "2", FinishedQuiz: true, Answers: []model.Answer{{QuestionID: "1", Option: optionA}, wrongQ2}},
+		"3": {ID: "3", FinishedQuiz: true, Answers: []model.Answer{{QuestionID: "1", Option: optionB}, wrongQ2}},
+		"4": {ID: "4", FinishedQuiz: true, Answers: []model.Answer{{QuestionID: "1", Option: optionC}, wrongQ2}},
+		"5": {ID: "5", Answers: []model.Answer{{QuestionID: "1", Option: optionC}}},
+	}
+
+	t.Run("GetQuestionStats Success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+
+		rr := setupRouterAndRequest(t, adminService.GetQuestionStats, "GET", "/admin/stats", "/admin/stats", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var responseBody ItemAnalysis
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, responseBody.Participants)
+		assert.Len(t, responseBody.Questions, 3)
+
+		first := responseBody.Questions[0]
+		assert.Equal(t, "1", first.QuestionID)
+		assert.Equal(t, 4, first.Responses)
+		assert.Equal(t, 0.5, first.Difficulty)
+		assert.InDelta(t, 0.9045, first.Discrimination, 0.0001)
+		assert.Equal(t, []OptionStats{
+			{ID: "A", Label: "Option A", IsCorrect: true, Selections: 2, SelectionRate: 0.5},
+			{ID: "B", Label: "Option B", Selections: 1, SelectionRate: 0.25},
+			{ID: "C", Label: "Option C", Selections: 1, SelectionRate: 0.25},
+		}, first.Options)
+
+		second := responseBody.Questions[1]
+		assert.Equal(t, "2", second.QuestionID)
+		assert.Equal(t, 0.25, second.Difficulty)
+		assert.InDelta(t, 0.8704, second.Discrimination, 0.0001)
+		assert.Equal(t, 0.75, second.Options[0].SelectionRate)
+
+		unanswered := responseBody.Questions[2]
+		assert.Equal(t, "10", unanswered.QuestionID)
+		assert.Equal(t, 0, unanswered.Responses)
+		assert.Equal(t, float64(0), unanswered.Difficulty)
+		assert.Equal(t, float64(0), unanswered.Discrimination)
+	})
+
+	t.Run("GetQuestionStats Failure - Internal Server Error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.GetQuestionStats, "GET", "/admin/stats", "/admin/stats", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestExportResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+
+	adminService := NewAdminService(mockUserRepo, mockQuestionRepo, nil, nil, nil, nil)
+	finishedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockQuestions := model.QuestionMap{
+		"1": {Label: "Question 1"},
+		"2": {Label: "Question 2"},
+	}
+	mockUsers := model.UserMap{
+		"10": {ID: "10", Name: "Bob", Answers: []model.Answer{
+			{QuestionID: "2", Option: model.Option{ID: "C"}},
+		}},
+		"2": {ID: "2", Name: "Ana", FinishedQuiz: true, Score: 0.5, FinishedAt: &finishedAt, Answers: []model.Answer{
+			{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}},
+			{QuestionID: "2", Option: model.Option{ID: "B"}},
+		}},
+	}
+
+	t.Run("ExportResults Success - CSV", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+
+		rr := setupRouterAndRequest(t, adminService.ExportResults, "GET", "/admin/results", "/admin/results?format=csv", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		expectedBody := "id,name,finished,score,correct_answers,finished_at,question_1,question_2\n" +
+			"2,Ana,true,0.5,1,2024-01-01T10:00:00Z,A,B\n" +
+			"10,Bob,false,0,0,,,C\n"
+		assert.Equal(t, expectedBody, rr.Body.String())
+	})
+
+	t.Run("ExportResults Success - CSV Formulas Escaped", func(t *testing.T) {
+		users := model.UserMap{}
+		for i, name := range []string{"=HYPERLINK(\"http://evil.example\")", "+1", "-2", "@SUM(A1)", "\t=1+1", "\r=1+1", "Ana = Bob"} {
+			id := fmt.Sprint(i + 1)
+			users[id] = model.User{ID: id, Name: name}
+		}
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(users, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{}, nil)
+
+		rr := setupRouterAndRequest(t, adminService.ExportResults, "GET", "/admin/results", "/admin/results?format=csv", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		expectedBody := "id,name,finished,score,correct_answers,finished_at\n" +
+			"1,\"'=HYPERLINK(\"\"http://evil.example\"\")\",false,0,0,\n" +
+			"2,'+1,false,0,0,\n" +
+			"3,'-2,false,0,0,\n" +
+			"4,'@SUM(A1),false,0,0,\n" +
+			"5,'\t=1+1,false,0,0,\n" +
+			"6,\"'\r=1+1\",false,0,0,\n" +
+			"7,Ana = Bob,false,0,0,\n"
+		assert.Equal(t, expectedBody, rr.Body.String())
+	})
+
+	t.Run("ExportResults Success - JSON Lines", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+
+		rr := setupRouterAndRequest(t, adminService.ExportResults, "GET", "/admin/results", "/admin/results?format=jsonl", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		assert.Len(t, lines, 2)
+		var firstRow ResultRow
+		err := json.Unmarshal([]byte(lines[0]), &firstRow)
+		assert.NoError(t, err)
+		assert.Equal(t, ResultRow{
+			ID:             "2",
+			Name:           "Ana",
+			Finished:       true,
+			Score:          0.5,
+			CorrectAnswers: 1,
+			FinishedAt:     &finishedAt,
+			Answers:        map[string]string{"1": "A", "2": "B"},
+		}, firstRow)
+	})
+
+	t.Run("ExportResults Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, adminService.ExportResults, "GET", "/admin/results", "/admin/results?format=xlsx", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ExportResults Failure - Internal Server Error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.ExportResults, "GET", "/admin/results", "/admin/results", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	adminService := NewAdminService(mockUserRepo, nil, nil, nil, nil, logging.Discard())
+
+	t.Run("ListUsers Success", func(t *testing.T) {
+		finished := true
+		query := model.UserQuery{ListOptions: model.ListOptions{Limit: 50, Sort: "score", Desc: true}, Name: "an", Finished: &finished}
+		mockUserRepo.EXPECT().ListUsers(gomock.Any(), query).Return(&model.UserPage{
+			Users: []model.User{{ID: "2", Name: "Ana", FinishedQuiz: true, Score: 1, Answers: []model.Answer{
+				{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}},
+			}}},
+			NextCursor: "abc",
+		}, nil)
+
+		rr := setupRouterAndRequest(t, adminService.ListUsers, "GET", "/admin/users", "/admin/users?sort=-score&name=an&finished=true", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"users":[{"id":"2","name":"Ana","finished":true,"score":1,"correct_answers":1,"answers":{"1":"A"}}],"next_cursor":"abc"}`, rr.Body.String())
+	})
+
+	t.Run("ListUsers Failure - Bad Request", func(t *testing.T) {
+		for _, query := range []string{"?finished=maybe", "?limit=1001", "?sort=answers"} {
+			rr := setupRouterAndRequest(t, adminService.ListUsers, "GET", "/admin/users", "/admin/users"+query, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("ListUsers Failure - Invalid Cursor", func(t *testing.T) {
+		mockUserRepo.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Return(nil, model.ErrInvalidCursor)
+
+		rr := setupRouterAndRequest(t, adminService.ListUsers, "GET", "/admin/users", "/admin/users?cursor=abc", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Equal(t, "cursor is invalid for this listing, start again without it\n", rr.Body.String())
+	})
+}
+
+func TestImportQuestions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+
+	adminService := NewAdminService(nil, mockQuestionRepo, nil, nil, nil, nil)
+	content := []byte("question,correct,A,B\nIs the sun a star?,A,Yes,No\n")
+	imported := model.Question{
+		Label:   "Is the sun a star?",
+		Options: []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}},
+	}
+
+	t.Run("ImportQuestions Success - Appends Questions", func(t *testing.T) {
+		mockQuestions := model.QuestionMap{
+			"1": {Label: "Question 1", Options: imported.Options, Position: 2},
+			"2": {Label: "Question 2", Options: imported.Options, Position: 1},
+		}
+		appended := imported
+		appended.Position = 3
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockQuestionRepo.EXPECT().SaveQuestions(gomock.Any(), model.QuestionMap{
+			"1": {Label: "Question 1", Options: imported.Options, Position: 2},
+			"2": {Label: "Question 2", Options: imported.Options, Position: 1},
+			"3": appended,
+		}).Return(nil)
+
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=csv", content)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var responseBody ImportReport
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, ImportReport{Imported: 1, Total: 3, QuestionIDs: []string{"3"}}, responseBody)
+	})
+
+	t.Run("ImportQuestions Success - Dry Run Replacing Questions", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=csv&dry_run=true&replace=true", content)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var responseBody ImportReport
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, ImportReport{DryRun: true, Replaced: true, Imported: 1, Total: 1, QuestionIDs: []string{"1"}}, responseBody)
+	})
+
+	t.Run("ImportQuestions Failure - Invalid Content", func(t *testing.T) {
+		invalidContent := []byte("question,correct,A,B\nIs the sun a star?,C,Yes,No\n")
+
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=csv", invalidContent)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var responseBody ImportReport
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, importer.ErrorList{
+			{Line: 2, Message: `correct option "C" is not one of the options`},
+			{Line: 2, Message: "question must have exactly one correct option, found 0"},
+		}, responseBody.Errors)
+	})
+
+	t.Run("ImportQuestions Failure - Invalid Quiz", func(t *testing.T) {
+		mockQuestions := model.QuestionMap{
+			"1": {Label: "Question 1"},
+		}
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=csv", content)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var responseBody ImportReport
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, model.Issues{
+			{QuestionID: "1", Rule: "too-few-options", Severity: model.SeverityError, Message: "question needs at least two options, found 0"},
+			{QuestionID: "1", Rule: "correct-option-count", Severity: model.SeverityError, Message: "question must have exactly one correct option, found 0"},
+		}, responseBody.Issues)
+	})
+
+	t.Run("ImportQuestions Failure - Unknown Format", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=xlsx", content)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ImportQuestions Failure - Internal Server Error", func(t *testing.T) {
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{}, nil)
+		mockQuestionRepo.EXPECT().SaveQuestions(gomock.Any(), gomock.Any()).Return(errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.ImportQuestions, "POST", "/admin/questions/import", "/admin/questions/import?format=csv", content)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestGetUserTimeline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
+
+	adminService := NewAdminService(mockUserRepo, nil, mockEventRepo, nil, nil, nil)
+	started := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	optionA := model.Option{ID: "A", Label: "Option A", IsCorrect: true}
+	optionB := model.Option{ID: "B", Label: "Option B"}
+	mockEvents := []model.Event{
+		{Sequence: 1, UserID: "1", Type: model.EventStarted, At: started, Name: "Alice"},
+		{Sequence: 3, UserID: "1", Type: model.EventAnswered, At: started.Add(time.Minute), QuestionID: "1", Option: &optionB},
+		{Sequence: 4, UserID: "1", Type: model.EventAnswered, At: started.Add(2 * time.Minute), QuestionID: "2", Option: &optionA},
+		{Sequence: 6, UserID: "1", Type: model.EventAnswerChanged, At: started.Add(3 * time.Minute), QuestionID: "1", Option: &optionA},
+		{Sequence: 7, UserID: "1", Type: model.EventFinished, At: started.Add(4 * time.Minute), Score: 1},
+	}
+
+	t.Run("GetUserTimeline Success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&model.User{ID: "1", Name: "Alice"}, nil)
+		mockEventRepo.EXPECT().GetUserEvents(gomock.Any(), "1").Return(mockEvents, nil)
+
+		rr := setupRouterAndRequest(t, adminService.GetUserTimeline, "GET", "/admin/users/{user}/timeline", "/admin/users/1/timeline", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var timeline Timeline
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &timeline))
+		assert.Equal(t, "1", timeline.UserID)
+		assert.Equal(t, 1, timeline.AnswerChanges)
+		answered := make([]int, len(timeline.Steps))
+		for i, step := range timeline.Steps {
+			answered[i] = step.Answered
+		}
+		assert.Equal(t, []int{0, 1, 2, 2, 2}, answered)
+
+		finishedAt := started.Add(4 * time.Minute)
+		assert.Equal(t, model.User{
+			ID:           "1",
+			Name:         "Alice",
+			Answers:      []model.Answer{{QuestionID: "2", Option: optionA}, {QuestionID: "1", Option: optionA, Changes: 1}},
+			FinishedQuiz: true,
+			FinishedAt:   &finishedAt,
+			Score:        1,
+		}, timeline.User)
+	})
+
+	t.Run("GetUserTimeline Failure - User Not Found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), "2").Return(nil, errors.New("user with id 2 not found"))
+
+		rr := setupRouterAndRequest(t, adminService.GetUserTimeline, "GET", "/admin/users/{user}/timeline", "/admin/users/2/timeline", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("GetUserTimeline Failure - Internal Server Error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(&model.User{ID: "1"}, nil)
+		mockEventRepo.EXPECT().GetUserEvents(gomock.Any(), "1").Return(nil, errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.GetUserTimeline, "GET", "/admin/users/{user}/timeline", "/admin/users/1/timeline", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestBackup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackupRepo := mock_model.NewMockBackupRepository(ctrl)
+
+	adminService := NewAdminService(nil, nil, nil, mockBackupRepo, nil, nil)
+	mockBackup := &model.Backup{
+		SchemaVersion: model.SchemaVersion,
+		CreatedAt:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Users:         model.UserMap{"1": {ID: "1", Name: "Ana"}},
+		Questions: model.QuestionMap{
+			"1": {Label: "Question 1?", Options: []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}, Revision: 1},
+		},
+		QuestionRevisions: model.QuestionRevisions{},
+	}
+
+	t.Run("Backup Success", func(t *testing.T) {
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(mockBackup, nil)
+
+		rr := setupRouterAndRequest(t, adminService.Backup, "GET", "/admin/backup", "/admin/backup", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="quiz-backup-20240501-100000.tar.gz"`, rr.Header().Get("Content-Disposition"))
+		restored, err := backup.Read(rr.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, mockBackup, restored)
+	})
+
+	t.Run("Backup Failure - Internal Server Error", func(t *testing.T) {
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.Backup, "GET", "/admin/backup", "/admin/backup", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackupRepo := mock_model.NewMockBackupRepository(ctrl)
+
+	adminService := NewAdminService(nil, nil, nil, mockBackupRepo, NewLeaderboard(), nil)
+	options := []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+	current := &model.Backup{
+		SchemaVersion: model.SchemaVersion,
+		Users:         model.UserMap{"1": {ID: "1", Name: "Ana"}, "2": {ID: "2", Name: "Bob"}},
+		Questions:     model.QuestionMap{"1": {Label: "Question 1?", Options: options, Revision: 1}},
+	}
+	restored := model.Backup{
+		SchemaVersion:     model.SchemaVersion,
+		CreatedAt:         time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Users:             model.UserMap{"1": {ID: "1", Name: "Ana"}},
+		Questions:         current.Questions,
+		QuestionRevisions: model.QuestionRevisions{},
+	}
+	archive := func(b model.Backup) []byte {
+		content := &bytes.Buffer{}
+		assert.NoError(t, backup.Write(content, b))
+		return content.Bytes()
+	}
+
+	t.Run("Restore Success - Dry Run", func(t *testing.T) {
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).Return(nil)
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(current, nil)
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore?dry_run=true", archive(restored))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var report backup.Report
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, backup.UserDiff{Removed: []string{"2"}}, report.Users)
+		assert.True(t, report.Questions.IsEmpty())
+	})
+
+	t.Run("Restore Success", func(t *testing.T) {
+		adminService.leaderboard.loaded = true
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).Return(nil)
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(current, nil)
+		mockBackupRepo.EXPECT().Restore(gomock.Any(), restored).Return(nil)
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", archive(restored))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.False(t, adminService.leaderboard.loaded)
+	})
+
+	t.Run("Restore Failure - Invalid Backup", func(t *testing.T) {
+		invalid := restored
+		invalid.Questions = model.QuestionMap{"1": {Label: "Question 1?", Options: options[:1]}}
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).Return(nil)
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(current, nil)
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", archive(invalid))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var report backup.Report
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+		assert.True(t, report.Issues.HasErrors())
+	})
+
+	t.Run("Restore Success - Validated After Migrating", func(t *testing.T) {
+		old := restored
+		old.SchemaVersion = 1
+		old.Questions = model.QuestionMap{"1": {Label: "Question 1?", Options: options[:1]}}
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, b *model.Backup) error {
+			b.SchemaVersion = model.SchemaVersion
+			b.Questions = restored.Questions
+			return nil
+		})
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(current, nil)
+		mockBackupRepo.EXPECT().Restore(gomock.Any(), restored).Return(nil)
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", archive(old))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("Restore Failure - Migration Fails", func(t *testing.T) {
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).Return(errors.New("migrating to schema version 2: broken"))
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", archive(restored))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "migrating to schema version 2")
+	})
+
+	t.Run("Restore Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", []byte("not an archive"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Restore Failure - Internal Server Error", func(t *testing.T) {
+		mockBackupRepo.EXPECT().Migrate(gomock.Any(), gomock.Any()).Return(nil)
+		mockBackupRepo.EXPECT().Backup(gomock.Any()).Return(current, nil)
+		mockBackupRepo.EXPECT().Restore(gomock.Any(), gomock.Any()).Return(errors.New("Internal Server Error"))
+
+		rr := setupRouterAndRequest(t, adminService.Restore, "POST", "/admin/restore", "/admin/restore", archive(restored))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
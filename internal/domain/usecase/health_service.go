@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// readinessTimeout bounds how long the repositories get to answer a
+// readiness check.
+const readinessTimeout = 2 * time.Second
+
+// APIVersions are the versions of the API served, from the newest. Clients
+// pick the newest one they support.
+var APIVersions = []string{"v2", "v1"}
+
+// HealthService tells an orchestrator whether the server is alive, whether
+// it can serve requests and which build it runs.
+type HealthService struct {
+	checks []healthCheck
+	logger *slog.Logger
+}
+
+type healthCheck struct {
+	name    string
+	checker model.HealthChecker
+}
+
+// NewHealthService checks the readiness of the repositories implementing
+// model.HealthChecker.
+func NewHealthService(userRepo model.UserRepository, questionRepo model.QuestionRepository, eventRepo model.EventRepository, logger *slog.Logger) *HealthService {
+	hs := &HealthService{logger: logger}
+	for _, repo := range []struct {
+		name string
+		repo any
+	}{
+		{"users", userRepo},
+		{"questions", questionRepo},
+		{"events", eventRepo},
+	} {
+		if checker, ok := repo.repo.(model.HealthChecker); ok {
+			hs.checks = append(hs.checks, healthCheck{name: repo.name, checker: checker})
+		}
+	}
+	return hs
+}
+
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type Version struct {
+	Path        string   `json:"path"`
+	Version     string   `json:"version"`
+	GoVersion   string   `json:"go_version"`
+	Revision    string   `json:"revision,omitempty"`
+	Time        string   `json:"time,omitempty"`
+	Modified    bool     `json:"modified,omitempty"`
+	APIVersions []string `json:"api_versions"`
+}
+
+// Healthz answers as long as the process is able to serve requests at all.
+func (hs *HealthService) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// Readyz checks every repository and answers 503 when one of them fails.
+func (hs *HealthService) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	readiness := Readiness{Status: "ready", Checks: map[string]string{}}
+	status := http.StatusOK
+	for _, check := range hs.checks {
+		if err := check.checker.CheckHealth(ctx); err != nil {
+			hs.logger.WarnContext(r.Context(), "readiness check failed", "check", check.name, "error", err)
+			readiness.Checks[check.name] = err.Error()
+			readiness.Status = "not ready"
+			status = http.StatusServiceUnavailable
+			continue
+		}
+		readiness.Checks[check.name] = "ok"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(readiness); err != nil {
+		hs.logger.ErrorContext(r.Context(), "encoding readiness to json", "error", err)
+	}
+}
+
+// Version reports the build of the server, as recorded by the go tool.
+func (hs *HealthService) Version(w http.ResponseWriter, r *http.Request) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		http.Error(w, "Build information is not available", http.StatusNotFound)
+		return
+	}
+	version := Version{
+		Path:        info.Main.Path,
+		Version:     info.Main.Version,
+		GoVersion:   info.GoVersion,
+		APIVersions: APIVersions,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			version.Revision = setting.Value
+		case "vcs.time":
+			version.Time = setting.Value
+		case "vcs.modified":
+			version.Modified = setting.Value == "true"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(version); err != nil {
+		hs.logger.ErrorContext(r.Context(), "encoding version to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
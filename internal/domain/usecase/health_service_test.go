@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkedUserRepository is a user repository that can be health checked.
+type checkedUserRepository struct {
+	*mock_model.MockUserRepository
+	err error
+}
+
+func (ur *checkedUserRepository) CheckHealth(ctx context.Context) error {
+	return ur.err
+}
+
+type checkedEventRepository struct {
+	*mock_model.MockEventRepository
+	err error
+}
+
+func (er *checkedEventRepository) CheckHealth(ctx context.Context) error {
+	return er.err
+}
+
+func TestReadyz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := &checkedUserRepository{MockUserRepository: mock_model.NewMockUserRepository(ctrl)}
+	events := &checkedEventRepository{MockEventRepository: mock_model.NewMockEventRepository(ctrl)}
+	// The question repository mock can't be checked, so it isn't.
+	healthService := NewHealthService(users, mock_model.NewMockQuestionRepository(ctrl), events, logging.Discard())
+
+	t.Run("Ready", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, healthService.Readyz, "GET", "/readyz", "/readyz", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var readiness Readiness
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &readiness))
+		assert.Equal(t, Readiness{Status: "ready", Checks: map[string]string{"users": "ok", "events": "ok"}}, readiness)
+	})
+
+	t.Run("Not Ready", func(t *testing.T) {
+		events.err = errors.New("data directory: no such file or directory")
+		defer func() { events.err = nil }()
+
+		rr := setupRouterAndRequest(t, healthService.Readyz, "GET", "/readyz", "/readyz", nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		var readiness Readiness
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &readiness))
+		assert.Equal(t, "not ready", readiness.Status)
+		assert.Equal(t, "ok", readiness.Checks["users"])
+		assert.Equal(t, "data directory: no such file or directory", readiness.Checks["events"])
+	})
+}
+
+func TestHealthzAndVersion(t *testing.T) {
+	healthService := NewHealthService(nil, nil, nil, logging.Discard())
+
+	rr := setupRouterAndRequest(t, healthService.Healthz, "GET", "/healthz", "/healthz", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "ok", rr.Body.String())
+
+	rr = setupRouterAndRequest(t, healthService.Version, "GET", "/version", "/version", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var version Version
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &version))
+	assert.NotEmpty(t, version.GoVersion)
+	assert.Equal(t, []string{"v2", "v1"}, version.APIVersions)
+}
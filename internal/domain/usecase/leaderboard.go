@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// Leaderboard keeps the users that finished the quiz sorted by score, so the
+// ranking can be served without reading every user on each request. It is
+// loaded lazily from the user repository and updated as users finish. It
+// counts the users that haven't finished yet too.
+type Leaderboard struct {
+	mu      sync.RWMutex
+	loaded  bool
+	entries []LeaderboardEntry
+	// active holds the ids of the users that haven't finished.
+	active map[string]bool
+}
+
+type LeaderboardEntry struct {
+	Rank       int        `json:"rank"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Score      float32    `json:"score"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// RankChange is a move of a quizzer in the ranking of everyone that
+// finished. From is 0 for a quizzer that just entered it.
+type RankChange struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	From   int    `json:"from,omitempty"`
+	To     int    `json:"to"`
+}
+
+type LeaderboardQuery struct {
+	From   *time.Time
+	To     *time.Time
+	Offset int
+	Limit  int
+	UserID string
+}
+
+type LeaderboardPage struct {
+	Entries    []LeaderboardEntry `json:"entries"`
+	Total      int                `json:"total"`
+	NextOffset int                `json:"next_offset,omitempty"`
+	User       *LeaderboardEntry  `json:"user,omitempty"`
+}
+
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{}
+}
+
+func (lb *Leaderboard) load(ctx context.Context, repo model.UserRepository) error {
+	lb.mu.RLock()
+	loaded := lb.loaded
+	lb.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.loaded {
+		return nil
+	}
+	users, err := repo.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	entries := make([]LeaderboardEntry, 0, len(users))
+	active := map[string]bool{}
+	for _, user := range users {
+		if user.FinishedQuiz {
+			entries = append(entries, toLeaderboardEntry(user))
+		} else {
+			active[user.ID] = true
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return rankedBefore(entries[i], entries[j]) })
+	lb.entries = entries
+	lb.active = active
+	lb.loaded = true
+	return nil
+}
+
+// Active returns how many users started the quiz and haven't finished it.
+func (lb *Leaderboard) Active(ctx context.Context, repo model.UserRepository) (int, error) {
+	if err := lb.load(ctx, repo); err != nil {
+		return 0, err
+	}
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return len(lb.active), nil
+}
+
+// Started counts a user that just started the quiz, unless loading the
+// leaderboard already did. Like Upsert, it is a no-op until the leaderboard
+// has been loaded.
+func (lb *Leaderboard) Started(user model.User) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.loaded && !user.FinishedQuiz {
+		lb.active[user.ID] = true
+	}
+}
+
+// Reset drops the ranking, which is loaded again on the next query.
+func (lb *Leaderboard) Reset() {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	lb.loaded = false
+	lb.entries = nil
+	lb.active = nil
+}
+
+// Upsert places a finished user in the ranking and reports the quizzers
+// whose rank changed. It is a no-op until the leaderboard has been loaded,
+// since loading will pick the user up anyway.
+func (lb *Leaderboard) Upsert(user model.User) []RankChange {
+	if !user.FinishedQuiz {
+		return nil
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if !lb.loaded {
+		return nil
+	}
+	before := ranks(lb.entries)
+
+	delete(lb.active, user.ID)
+	for i, entry := range lb.entries {
+		if entry.UserID == user.ID {
+			lb.entries = append(lb.entries[:i], lb.entries[i+1:]...)
+			break
+		}
+	}
+	entry := toLeaderboardEntry(user)
+	i := sort.Search(len(lb.entries), func(i int) bool { return rankedBefore(entry, lb.entries[i]) })
+	lb.entries = append(lb.entries, LeaderboardEntry{})
+	copy(lb.entries[i+1:], lb.entries[i:])
+	lb.entries[i] = entry
+
+	var changes []RankChange
+	after := ranks(lb.entries)
+	for _, entry := range lb.entries {
+		if from := before[entry.UserID]; from != after[entry.UserID] {
+			changes = append(changes, RankChange{UserID: entry.UserID, Name: entry.Name, From: from, To: after[entry.UserID]})
+		}
+	}
+	return changes
+}
+
+// ranks gives the rank of each user of the sorted entries, users with the
+// same score sharing theirs.
+func ranks(entries []LeaderboardEntry) map[string]int {
+	ranks := make(map[string]int, len(entries))
+	rank := 0
+	for i, entry := range entries {
+		if i == 0 || entry.Score != entries[i-1].Score {
+			rank = i + 1
+		}
+		ranks[entry.UserID] = rank
+	}
+	return ranks
+}
+
+// Query returns a page of the ranking restricted to the users that finished
+// within the requested window. Users with the same score share a rank, and a
+// page is extended past its limit so that ties are never split.
+func (lb *Leaderboard) Query(q LeaderboardQuery) LeaderboardPage {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	ranked := make([]LeaderboardEntry, 0, len(lb.entries))
+	for _, entry := range lb.entries {
+		if !inWindow(entry.FinishedAt, q.From, q.To) {
+			continue
+		}
+		entry.Rank = len(ranked) + 1
+		if len(ranked) > 0 && ranked[len(ranked)-1].Score == entry.Score {
+			entry.Rank = ranked[len(ranked)-1].Rank
+		}
+		ranked = append(ranked, entry)
+	}
+
+	page := LeaderboardPage{
+		Entries: []LeaderboardEntry{},
+		Total:   len(ranked),
+	}
+	for i := range ranked {
+		if ranked[i].UserID == q.UserID {
+			page.User = &ranked[i]
+			break
+		}
+	}
+	if q.Offset >= len(ranked) {
+		return page
+	}
+
+	end := q.Offset + q.Limit
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	for end < len(ranked) && ranked[end].Rank == ranked[end-1].Rank {
+		end++
+	}
+	page.Entries = ranked[q.Offset:end]
+	if end < len(ranked) {
+		page.NextOffset = end
+	}
+	return page
+}
+
+func toLeaderboardEntry(user model.User) LeaderboardEntry {
+	return LeaderboardEntry{
+		UserID:     user.ID,
+		Name:       user.Name,
+		Score:      user.Score,
+		FinishedAt: user.FinishedAt,
+	}
+}
+
+// rankedBefore orders by score, then by who finished first.
+func rankedBefore(a, b LeaderboardEntry) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	aFinished, bFinished := a.FinishedAt != nil, b.FinishedAt != nil
+	if aFinished != bFinished {
+		return aFinished
+	}
+	if aFinished && !a.FinishedAt.Equal(*b.FinishedAt) {
+		return a.FinishedAt.Before(*b.FinishedAt)
+	}
+	return a.UserID < b.UserID
+}
+
+func inWindow(at, from, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+	if at == nil {
+		return false
+	}
+	if from != nil && at.Before(*from) {
+		return false
+	}
+	if to != nil && at.After(*to) {
+		return false
+	}
+	return true
+}
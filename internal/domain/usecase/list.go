@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 1000
+)
+
+// parseListOptions reads the limit, cursor and sort query parameters of a
+// listing. sort is a field, prefixed with - to sort in descending order.
+func parseListOptions(values url.Values, fields []string) (model.ListOptions, error) {
+	options := model.ListOptions{Limit: defaultPageLimit, Cursor: values.Get("cursor")}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return options, fmt.Errorf("limit must be a number between 1 and %d", maxPageLimit)
+		}
+		options.Limit = n
+	}
+	if sort := values.Get("sort"); sort != "" {
+		options.Sort, options.Desc = strings.CutPrefix(sort, "-")
+		if !slices.Contains(fields, options.Sort) {
+			return options, fmt.Errorf("sort must be one of %s, prefixed with - for descending order", strings.Join(fields, ", "))
+		}
+	}
+	return options, nil
+}
+
+// parseBoolFilter reads a true or false query parameter, nil when absent.
+func parseBoolFilter(values url.Values, param string) (*bool, error) {
+	value := values.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", param)
+	}
+	return &b, nil
+}
+
+// listError reports an error of a repository listing, a cursor that can't be
+// followed being the client's.
+func listError(err error, errMessage string) *statusError {
+	if errors.Is(err, model.ErrInvalidCursor) {
+		return &statusError{status: http.StatusBadRequest, message: "cursor is invalid for this listing, start again without it"}
+	}
+	return &statusError{status: http.StatusInternalServerError, message: errMessage}
+}
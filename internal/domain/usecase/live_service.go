@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/hub"
+)
+
+// The types of the events streamed by the LiveService.
+const (
+	LiveAnswered    = "answered"
+	LiveFinished    = "finished"
+	LiveLeaderboard = "leaderboard"
+	// LiveReset tells the client some events were lost, so whatever it built
+	// from them should be fetched again.
+	LiveReset = "reset"
+)
+
+const (
+	// liveEventsKept is how many events are kept for clients coming back,
+	// and how many can wait for a client before it is dropped.
+	liveEventsKept = 256
+	// liveHeartbeat keeps idle streams from being closed by proxies.
+	liveHeartbeat = 15 * time.Second
+	liveWriteWait = 10 * time.Second
+	// liveRetry is the time browsers wait before reconnecting.
+	liveRetry = 3 * time.Second
+	// liveLeaderboardTop is how far down the ranking moves are streamed, so
+	// a finish near the bottom doesn't stream a move for everyone below.
+	liveLeaderboardTop = 10
+)
+
+// AnsweredEvent tells a quizzer answered a question, without telling which
+// option, so the stream can't be used to cheat.
+type AnsweredEvent struct {
+	UserID     string `json:"user_id"`
+	Name       string `json:"name"`
+	QuestionID string `json:"question_id"`
+	Changed    bool   `json:"changed,omitempty"`
+	Answered   int    `json:"answered"`
+	Total      int    `json:"total"`
+}
+
+type FinishedEvent struct {
+	UserID string  `json:"user_id"`
+	Name   string  `json:"name"`
+	Score  float32 `json:"score"`
+	Rank   int     `json:"rank,omitempty"`
+}
+
+type LeaderboardEvent struct {
+	Changes []RankChange `json:"changes"`
+}
+
+// LiveService streams what the quizzers do as Server-Sent Events.
+type LiveService struct {
+	hub    *hub.Hub
+	logger *slog.Logger
+}
+
+func NewLiveService(events *hub.Hub, logger *slog.Logger) *LiveService {
+	return &LiveService{hub: events, logger: logger}
+}
+
+// Stream sends the events as they are published. A client reconnecting
+// with the Last-Event-ID header, or the last_event_id query parameter, gets
+// the events it missed, or a reset event when they are no longer kept.
+func (ls *LiveService) Stream(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			http.Error(w, "An error occured streaming events: the last event id must be a number", http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+
+	sub := ls.hub.Subscribe(lastID)
+	defer sub.Close()
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(format string, args ...any) bool {
+		rc.SetWriteDeadline(time.Now().Add(liveWriteWait))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+	if !send("retry: %d\n\n", liveRetry.Milliseconds()) {
+		return
+	}
+	if sub.Missed() && !send("event: %s\ndata: {}\n\n", LiveReset) {
+		return
+	}
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if !send(": heartbeat\n\n") {
+				return
+			}
+		case event, open := <-sub.Events():
+			if !open {
+				// The client fell behind. Closing the stream makes it
+				// reconnect and catch up from the events kept.
+				ls.logger.WarnContext(r.Context(), "dropped a slow event stream")
+				return
+			}
+			if !send("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data) {
+				return
+			}
+		}
+	}
+}
+
+// publish streams an event, logging when it can't.
+func publish(ctx context.Context, events *hub.Hub, logger *slog.Logger, eventType string, data any) {
+	if err := events.Publish(eventType, data); err != nil {
+		logger.ErrorContext(ctx, "publishing live event", "type", eventType, "error", err)
+	}
+}
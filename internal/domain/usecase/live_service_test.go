@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/hub"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readSSE reads the next event of a stream, skipping comments and the retry
+// delay.
+func readSSE(t *testing.T, stream *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "":
+			if event != (sseEvent{}) {
+				return event
+			}
+		case "id":
+			event.ID = value
+		case "event":
+			event.Event = value
+		case "data":
+			event.Data = value
+		}
+	}
+}
+
+func TestLiveServiceStream(t *testing.T) {
+	events := hub.New(2)
+	liveService := NewLiveService(events, logging.Discard())
+	server := httptest.NewServer(http.HandlerFunc(liveService.Stream))
+	defer server.Close()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, events.Publish(LiveAnswered, AnsweredEvent{UserID: fmt.Sprint(i)}))
+	}
+
+	t.Run("Stream Success - Resumed", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "1")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		stream := bufio.NewReader(resp.Body)
+		assert.Equal(t, sseEvent{ID: "2", Event: LiveAnswered, Data: `{"user_id":"2","name":"","question_id":"","answered":0,"total":0}`}, readSSE(t, stream))
+		assert.Equal(t, "3", readSSE(t, stream).ID)
+
+		require.Eventually(t, func() bool { return events.Subscribers() == 1 }, time.Second, 10*time.Millisecond)
+		require.NoError(t, events.Publish(LiveFinished, FinishedEvent{UserID: "1", Name: "Ana", Score: 0.5, Rank: 1}))
+		assert.Equal(t, sseEvent{ID: "4", Event: LiveFinished, Data: `{"user_id":"1","name":"Ana","score":0.5,"rank":1}`}, readSSE(t, stream))
+	})
+
+	t.Run("Stream Success - Events Missed", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "?last_event_id=1")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		stream := bufio.NewReader(resp.Body)
+		assert.Equal(t, sseEvent{Event: LiveReset, Data: "{}"}, readSSE(t, stream))
+		assert.Equal(t, "3", readSSE(t, stream).ID)
+	})
+
+	t.Run("Stream Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, liveService.Stream, "GET", "/admin/live", "/admin/live?last_event_id=last", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestLiveEventsPublished(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, logging.Discard())
+	sub := userService.live.Subscribe(0)
+	defer sub.Close()
+
+	mockQuestions := model.QuestionMap{
+		"1": {Label: "Question 1", Options: []model.Option{{ID: "A", IsCorrect: true}, {ID: "B"}}},
+		"2": {Label: "Question 2", Options: []model.Option{{ID: "A"}, {ID: "B", IsCorrect: true}}},
+	}
+	mockUser := &model.User{ID: "1", Name: "Ana", Answers: []model.Answer{{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}}}}
+	finishedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil).AnyTimes()
+	mockEventRepo.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).Return(&model.Event{}, nil).AnyTimes()
+	mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(mockUser, nil).AnyTimes()
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil).AnyTimes()
+	mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(model.UserMap{
+		"1": *mockUser,
+		"2": {ID: "2", Name: "Bob", FinishedQuiz: true, Score: 0.5, FinishedAt: &finishedAt},
+	}, nil)
+
+	rr := setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", "/users/1/answer", []byte(`{"question_id": "2", "option_id": "B"}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+	event := <-sub.Events()
+	assert.Equal(t, LiveAnswered, event.Type)
+	assert.JSONEq(t, `{"user_id":"1","name":"Ana","question_id":"2","answered":2,"total":2}`, string(event.Data))
+
+	rr = setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", "/users/1/finish", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	event = <-sub.Events()
+	assert.Equal(t, LiveFinished, event.Type)
+	assert.JSONEq(t, `{"user_id":"1","name":"Ana","score":1,"rank":1}`, string(event.Data))
+	event = <-sub.Events()
+	assert.Equal(t, LiveLeaderboard, event.Type)
+	var leaderboard LeaderboardEvent
+	require.NoError(t, json.Unmarshal(event.Data, &leaderboard))
+	assert.Equal(t, []RankChange{{UserID: "1", Name: "Ana", To: 1}, {UserID: "2", Name: "Bob", From: 1, To: 2}}, leaderboard.Changes)
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"math"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/room"
+	"github.com/MFCaballero/simple-quiz/internal/hub"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+)
+
+// scoreBuckets split scores, which go from 0 to 1, in tenths.
+var scoreBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+// quizMetrics counts what the quizzers do.
+type quizMetrics struct {
+	answers  *metrics.Counter
+	finished *metrics.Counter
+	scores   *metrics.Histogram
+}
+
+func newQuizMetrics(registry *metrics.Registry) *quizMetrics {
+	return &quizMetrics{
+		answers:  registry.NewCounter("quiz_answers_submitted_total", "Answers saved, changes of an answer included."),
+		finished: registry.NewCounter("quiz_quizzes_finished_total", "Quizzes finished."),
+		scores:   registry.NewHistogram("quiz_scores", "Scores of the finished quizzes.", scoreBuckets),
+	}
+}
+
+// registerActiveUsers exposes how many quizzers started the quiz and haven't
+// finished it yet, as counted by the leaderboard. The stored users are only
+// read when the leaderboard isn't loaded.
+func registerActiveUsers(registry *metrics.Registry, leaderboard *Leaderboard, userRepo model.UserRepository) {
+	registry.NewGaugeFunc("quiz_active_users", "Quizzers that started the quiz and haven't finished it.", func() float64 {
+		active, err := leaderboard.Active(context.Background(), userRepo)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(active)
+	})
+}
+
+// registerOpenRooms exposes how many live quiz rooms are open.
+func registerOpenRooms(registry *metrics.Registry, rooms *room.Manager) {
+	registry.NewGaugeFunc("quiz_rooms_open", "Live quiz rooms open.", func() float64 {
+		return float64(rooms.Len())
+	})
+}
+
+// registerLiveSubscribers exposes how many clients follow the live events.
+func registerLiveSubscribers(registry *metrics.Registry, live *hub.Hub) {
+	registry.NewGaugeFunc("quiz_live_subscribers", "Clients following the live events.", func() float64 {
+		return float64(live.Subscribers())
+	})
+}
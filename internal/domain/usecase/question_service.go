@@ -1,9 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
@@ -12,10 +13,10 @@ import (
 
 type QuestionService struct {
 	repository model.QuestionRepository
-	logger     *log.Logger
+	logger     *slog.Logger
 }
 
-func NewQuestionService(repo model.QuestionRepository, logger *log.Logger) *QuestionService {
+func NewQuestionService(repo model.QuestionRepository, logger *slog.Logger) *QuestionService {
 	return &QuestionService{
 		repository: repo,
 		logger:     logger,
@@ -23,43 +24,116 @@ func NewQuestionService(repo model.QuestionRepository, logger *log.Logger) *Ques
 }
 
 func (qs *QuestionService) GetAllQuestions(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	errMessage := "An error occured getting all questions"
-
-	questions, err := qs.repository.GetAllQuestions(ctx)
+	questions, err := qs.Questions(r.Context())
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		writeStatusError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(qs.toQuestionsDTO(questions)); err != nil {
-		qs.logger.Printf("error encoding questions to json: %v", err)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(questions); err != nil {
+		qs.logger.ErrorContext(r.Context(), "encoding questions to json", "error", err)
+		http.Error(w, "An error occured getting all questions", http.StatusInternalServerError)
 		return
 	}
 }
 
+// Questions returns the questions by id, without their answers.
+func (qs *QuestionService) Questions(ctx context.Context) (map[string]QuestionDTO, error) {
+	questions, err := qs.repository.GetAllQuestions(ctx)
+	if err != nil {
+		return nil, &statusError{status: http.StatusInternalServerError, message: "An error occured getting all questions"}
+	}
+	return qs.toQuestionsDTO(questions), nil
+}
+
+// OrderedQuestions returns the questions in the order they are asked,
+// without their answers.
+func (qs *QuestionService) OrderedQuestions(ctx context.Context) ([]QuestionDTO, error) {
+	questions, err := qs.repository.GetAllQuestions(ctx)
+	if err != nil {
+		return nil, &statusError{status: http.StatusInternalServerError, message: "An error occured getting all questions"}
+	}
+	ordered := make([]QuestionDTO, 0, len(questions))
+	for _, id := range questions.Order() {
+		question := questions[id]
+		dto := qs.toQuestionDTO(&question)
+		dto.ID = id
+		ordered = append(ordered, dto)
+	}
+	return ordered, nil
+}
+
 func (qs *QuestionService) GetQuestion(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	id := chi.URLParam(r, "question")
-	errMessage := fmt.Sprintf("An error occured getting question with id %s", id)
+	question, err := qs.Question(r.Context(), id)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(question); err != nil {
+		qs.logger.ErrorContext(r.Context(), "encoding question to json", "error", err)
+		http.Error(w, fmt.Sprintf("An error occured getting question with id %s", id), http.StatusInternalServerError)
+		return
+	}
+}
 
-	question, err := qs.repository.GetQuestion(ctx, id)
+// ListQuestions serves a page of the questions, in the order they are asked
+// unless sorted otherwise, optionally only those whose label contains the
+// label parameter.
+func (qs *QuestionService) ListQuestions(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	options, err := parseListOptions(values, model.QuestionSortKeys.Fields())
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if options.Sort == "" {
+		options.Sort = "position"
+	}
+	page, err := qs.repository.ListQuestions(r.Context(), model.QuestionQuery{ListOptions: options, Label: values.Get("label")})
+	if err != nil {
+		writeStatusError(w, listError(err, "An error occured listing questions"))
+		return
+	}
+
+	response := QuestionPage{Questions: make([]QuestionDTO, len(page.Questions)), NextCursor: page.NextCursor}
+	for i, item := range page.Questions {
+		response.Questions[i] = qs.toQuestionDTO(&item.Question)
+		response.Questions[i].ID = item.ID
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(qs.toQuestionDTO(question)); err != nil {
-		qs.logger.Printf("error encoding question to json: %v", err)
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		qs.logger.ErrorContext(r.Context(), "encoding questions to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
+// Question returns a question without its answer. A question that does not
+// exist is reported without a message.
+func (qs *QuestionService) Question(ctx context.Context, id string) (*QuestionDTO, error) {
+	question, err := qs.repository.GetQuestion(ctx, id)
+	if err != nil {
+		return nil, &statusError{status: http.StatusNotFound}
+	}
+	dto := qs.toQuestionDTO(question)
+	return &dto, nil
+}
+
 type QuestionDTO struct {
-	Label   string      `json:"label"`
-	Options []OptionDTO `json:"options"`
+	// ID is only set in the pages of questions.
+	ID    string `json:"id,omitempty"`
+	Label string `json:"label"`
+	// Position orders the questions, 0 for those asked last.
+	Position int         `json:"position"`
+	Options  []OptionDTO `json:"options"`
+}
+
+// QuestionPage is a page of the questions, served by /v2.
+type QuestionPage struct {
+	Questions  []QuestionDTO `json:"questions"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 type OptionDTO struct {
 	ID    string `json:"id"`
@@ -83,7 +157,8 @@ func (qs *QuestionService) toQuestionDTO(question *model.Question) QuestionDTO {
 		}
 	}
 	return QuestionDTO{
-		Label:   question.Label,
-		Options: optionsDTO,
+		Label:    question.Label,
+		Position: question.Position,
+		Options:  optionsDTO,
 	}
 }
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"testing"
 
@@ -19,12 +19,12 @@ func TestGetAllQuestions(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
-	questionService := NewQuestionService(mockQuestionRepo, log.Default())
+	questionService := NewQuestionService(mockQuestionRepo, slog.Default())
 
 	t.Run("GetAllQuestions Success", func(t *testing.T) {
 		mockQuestions := model.QuestionMap{
-			"1": {Label: "Question 1", Options: []model.Option{{ID: "A", Label: "Option A"}}},
-			"2": {Label: "Question 2", Options: []model.Option{{ID: "B", Label: "Option B"}}},
+			"1": {Label: "Question 1", Position: 2, Options: []model.Option{{ID: "A", Label: "Option A"}}},
+			"2": {Label: "Question 2", Position: 1, Options: []model.Option{{ID: "B", Label: "Option B"}}},
 		}
 		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
 
@@ -33,8 +33,8 @@ func TestGetAllQuestions(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 
 		expectedResponseBody := map[string]QuestionDTO{
-			"1": {Label: "Question 1", Options: []OptionDTO{{ID: "A", Label: "Option A"}}},
-			"2": {Label: "Question 2", Options: []OptionDTO{{ID: "B", Label: "Option B"}}},
+			"1": {Label: "Question 1", Position: 2, Options: []OptionDTO{{ID: "A", Label: "Option A"}}},
+			"2": {Label: "Question 2", Position: 1, Options: []OptionDTO{{ID: "B", Label: "Option B"}}},
 		}
 		var responseBody map[string]QuestionDTO
 		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
@@ -56,7 +56,7 @@ func TestGetQuestion(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
-	questionService := NewQuestionService(mockQuestionRepo, log.Default())
+	questionService := NewQuestionService(mockQuestionRepo, slog.Default())
 
 	t.Run("GetQuestion Success", func(t *testing.T) {
 		mockQuestionID := "1"
@@ -89,3 +89,62 @@ func TestGetQuestion(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rr.Code)
 	})
 }
+
+func TestListQuestions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	questionService := NewQuestionService(mockQuestionRepo, slog.Default())
+
+	t.Run("ListQuestions Success", func(t *testing.T) {
+		query := model.QuestionQuery{ListOptions: model.ListOptions{Limit: 1, Cursor: "abc", Sort: "label", Desc: true}, Label: "go"}
+		mockQuestionRepo.EXPECT().ListQuestions(gomock.Any(), query).Return(&model.QuestionPage{
+			Questions:  []model.QuestionItem{{ID: "3", Question: model.Question{Label: "Why go?", Position: 2, Options: []model.Option{{ID: "A", Label: "Fun", IsCorrect: true}}}}},
+			NextCursor: "def",
+		}, nil)
+
+		rr := setupRouterAndRequest(t, questionService.ListQuestions, "GET", "/questions", "/questions?limit=1&cursor=abc&sort=-label&label=go", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"questions":[{"id":"3","label":"Why go?","position":2,"options":[{"id":"A","label":"Fun"}]}],"next_cursor":"def"}`, rr.Body.String())
+	})
+
+	t.Run("ListQuestions Success - In Position Order By Default", func(t *testing.T) {
+		query := model.QuestionQuery{ListOptions: model.ListOptions{Limit: 50, Sort: "position"}}
+		mockQuestionRepo.EXPECT().ListQuestions(gomock.Any(), query).Return(&model.QuestionPage{}, nil)
+
+		rr := setupRouterAndRequest(t, questionService.ListQuestions, "GET", "/questions", "/questions", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("ListQuestions Failure - Bad Request", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"?limit=0":     "limit must be a number between 1 and 1000",
+			"?sort=id":     "sort must be one of label, position, prefixed with - for descending order",
+			"?sort=-score": "sort must be one of label, position, prefixed with - for descending order",
+		} {
+			rr := setupRouterAndRequest(t, questionService.ListQuestions, "GET", "/questions", "/questions"+query, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+			assert.Equal(t, message+"\n", rr.Body.String(), query)
+		}
+	})
+
+	t.Run("ListQuestions Failure - Invalid Cursor", func(t *testing.T) {
+		mockQuestionRepo.EXPECT().ListQuestions(gomock.Any(), gomock.Any()).Return(nil, model.ErrInvalidCursor)
+
+		rr := setupRouterAndRequest(t, questionService.ListQuestions, "GET", "/questions", "/questions?cursor=abc", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ListQuestions Failure - Internal Server Error", func(t *testing.T) {
+		mockQuestionRepo.EXPECT().ListQuestions(gomock.Any(), gomock.Any()).Return(nil, errors.New("disk failure"))
+
+		rr := setupRouterAndRequest(t, questionService.ListQuestions, "GET", "/questions", "/questions", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
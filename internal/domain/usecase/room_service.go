@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/room"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultQuestionSeconds = 20
+	minQuestionSeconds     = 5
+	maxQuestionSeconds     = 120
+
+	// The connections are pinged so dead ones are noticed, and closed when
+	// they don't answer in time.
+	pingPeriod     = 30 * time.Second
+	pongWait       = pingPeriod + 10*time.Second
+	writeWait      = 10 * time.Second
+	maxCommandSize = 1024
+)
+
+// RoomService runs live quiz rooms over WebSockets. The host opens a room
+// and takes the players that joined it through the questions.
+type RoomService struct {
+	rooms        *room.Manager
+	questionRepo model.QuestionRepository
+	upgrader     websocket.Upgrader
+	logger       *slog.Logger
+}
+
+func NewRoomService(rooms *room.Manager, questionRepo model.QuestionRepository, logger *slog.Logger) *RoomService {
+	return &RoomService{
+		rooms:        rooms,
+		questionRepo: questionRepo,
+		logger:       logger,
+	}
+}
+
+// HostRoom opens a room with every question and hosts it over the
+// connection, until it closes. The seconds query parameter sets the time to
+// answer each question.
+func (rs *RoomService) HostRoom(w http.ResponseWriter, r *http.Request) {
+	seconds := defaultQuestionSeconds
+	if value := r.URL.Query().Get("seconds"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < minQuestionSeconds || n > maxQuestionSeconds {
+			http.Error(w, "An error occured hosting room: seconds must be a number from 5 to 120", http.StatusBadRequest)
+			return
+		}
+		seconds = n
+	}
+
+	questions, err := rs.questionRepo.GetAllQuestions(r.Context())
+	if err != nil {
+		http.Error(w, "An error occured hosting room", http.StatusInternalServerError)
+		return
+	}
+	quizRoom, err := rs.rooms.Create(questions, time.Duration(seconds)*time.Second)
+	if errors.Is(err, room.ErrNoQuestions) {
+		http.Error(w, "An error occured hosting room: there are no questions", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "An error occured hosting room", http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := rs.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered the request.
+		quizRoom.Leave(quizRoom.Host())
+		return
+	}
+	rs.logger.InfoContext(r.Context(), "room opened", "code", quizRoom.Code(), "questions", len(questions), "seconds", seconds)
+	rs.serve(conn, quizRoom, quizRoom.Host())
+	rs.logger.InfoContext(r.Context(), "room closed", "code", quizRoom.Code())
+}
+
+// JoinRoom adds a player, named by the name query parameter, to the room
+// and plays over the connection until they leave or the room closes.
+func (rs *RoomService) JoinRoom(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured joining room"
+	quizRoom, err := rs.rooms.Get(chi.URLParam(r, "code"))
+	if err != nil {
+		http.Error(w, errMessage+": "+err.Error(), http.StatusNotFound)
+		return
+	}
+	player, err := quizRoom.Join(r.URL.Query().Get("name"))
+	switch {
+	case errors.Is(err, room.ErrNoName), errors.Is(err, room.ErrLongName):
+		http.Error(w, errMessage+": "+err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, errMessage+": "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	conn, err := rs.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		quizRoom.Leave(player)
+		return
+	}
+	rs.serve(conn, quizRoom, player)
+}
+
+// serve sends the messages of the room to the participant and hands their
+// commands to the room, until either side goes away.
+func (rs *RoomService) serve(conn *websocket.Conn, quizRoom *room.Room, participant *room.Participant) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rs.write(conn, participant)
+	}()
+
+	conn.SetReadLimit(maxCommandSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, content, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var command room.Command
+		if err := json.Unmarshal(content, &command); err != nil {
+			// The room refuses the empty command, telling the participant.
+			command = room.Command{}
+		}
+		quizRoom.Handle(participant, command)
+	}
+	quizRoom.Leave(participant)
+	<-done
+	conn.Close()
+}
+
+// write sends the messages of the participant until there are no more, and
+// pings the connection meanwhile.
+func (rs *RoomService) write(conn *websocket.Conn, participant *room.Participant) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case message, open := <-participant.Messages():
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !open {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				// Give the participant a moment to answer the close
+				// before the reader gives up on them.
+				conn.UnderlyingConn().SetReadDeadline(time.Now().Add(writeWait))
+				return
+			}
+			if err := conn.WriteJSON(message); err != nil {
+				conn.Close()
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
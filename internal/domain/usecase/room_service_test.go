@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/domain/room"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoomService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{
+		"1": {Label: "Question 1", Options: []model.Option{{ID: "A", Label: "Option A", IsCorrect: true}, {ID: "B", Label: "Option B"}}},
+	}, nil).AnyTimes()
+	roomService := NewRoomService(room.NewManager(), mockQuestionRepo, logging.Discard())
+	mux := chi.NewRouter()
+	mux.Get("/rooms/host", roomService.HostRoom)
+	mux.Get("/rooms/{code}/join", roomService.JoinRoom)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(t *testing.T, path string) *websocket.Conn {
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL+path, nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return conn
+	}
+	read := func(t *testing.T, conn *websocket.Conn) room.Message {
+		var message room.Message
+		require.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+
+	t.Run("Room Success", func(t *testing.T) {
+		host := dial(t, "/rooms/host?seconds=5")
+		opened := read(t, host)
+		require.Equal(t, room.MessageRoom, opened.Type)
+
+		ana := dial(t, "/rooms/"+strings.ToLower(opened.Code)+"/join?name=Ana")
+		assert.Equal(t, room.Message{Type: room.MessageJoined, Code: opened.Code}, read(t, ana))
+		assert.Equal(t, []string{"Ana"}, read(t, ana).Players)
+		assert.Equal(t, []string{"Ana"}, read(t, host).Players)
+
+		require.NoError(t, host.WriteJSON(room.Command{Type: room.CommandNext}))
+		assert.Equal(t, "Question 1", read(t, host).Question.Label)
+		question := read(t, ana).Question
+		assert.Equal(t, 5, question.Seconds)
+
+		require.NoError(t, ana.WriteMessage(websocket.TextMessage, []byte("not json")))
+		assert.Equal(t, `unknown command ""`, read(t, ana).Error)
+		require.NoError(t, ana.WriteJSON(room.Command{Type: room.CommandAnswer, OptionID: "A"}))
+		assert.Equal(t, room.MessageAnswerReceived, read(t, ana).Type)
+		results := read(t, ana)
+		assert.True(t, results.Result.Correct)
+		assert.Greater(t, results.Result.Points, room.MaxPoints/2)
+		assert.Equal(t, room.MessageResults, read(t, host).Type)
+
+		require.NoError(t, host.WriteJSON(room.Command{Type: room.CommandNext}))
+		assert.Equal(t, room.MessageFinished, read(t, host).Type)
+		assert.Equal(t, room.MessageFinished, read(t, ana).Type)
+		_, _, err := ana.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+
+		resp, err := http.Get(server.URL + "/rooms/" + opened.Code + "/join?name=Bob")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("HostRoom Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, roomService.HostRoom, "GET", "/rooms/host", "/rooms/host?seconds=1", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("JoinRoom Failure - Conflict", func(t *testing.T) {
+		host := dial(t, "/rooms/host")
+		code := read(t, host).Code
+		dial(t, "/rooms/"+code+"/join?name=Ana")
+
+		rr := setupRouterAndRequest(t, roomService.JoinRoom, "GET", "/rooms/{code}/join", "/rooms/"+code+"/join?name=ana", nil)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, "An error occured joining room: the name is taken\n", rr.Body.String())
+
+		rr = setupRouterAndRequest(t, roomService.JoinRoom, "GET", "/rooms/{code}/join", "/rooms/"+code+"/join", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -1,19 +1,59 @@
 package usecase
 
 import (
-	"log"
+	"log/slog"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/room"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/MFCaballero/simple-quiz/internal/hub"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
 )
 
 type Services struct {
 	*UserService
 	*QuestionService
+	*AdminService
+	*HealthService
+	*RoomService
+	*LiveService
+	*WebhookService
+	// dispatcher is nil when no webhooks are served.
+	dispatcher *webhook.Dispatcher
 }
 
-func LoadServices(userRepo model.UserRepository, questionRepo model.QuestionRepository, logger *log.Logger) Services {
+// LoadServices builds the services on the repositories. Webhooks are only
+// served with a webhookRepo, notifying the hosts webhookHosts allows.
+func LoadServices(userRepo model.UserRepository, questionRepo model.QuestionRepository, eventRepo model.EventRepository, backupRepo model.BackupRepository, webhookRepo model.WebhookRepository, webhookHosts webhook.Hosts, registry *metrics.Registry, logger *slog.Logger) Services {
+	var dispatcher *webhook.Dispatcher
+	var webhooks webhookQueue
+	if webhookRepo != nil {
+		dispatcher = webhook.NewDispatcher(webhookRepo, webhookHosts, logger)
+		webhooks = dispatcher
+	}
+	live := hub.New(liveEventsKept)
+	registerLiveSubscribers(registry, live)
+	userService := NewUserService(userRepo, questionRepo, eventRepo, registry, live, webhooks, logger)
+	registerActiveUsers(registry, userService.leaderboard, userRepo)
+	adminService := NewAdminService(userRepo, questionRepo, eventRepo, backupRepo, userService.leaderboard, logger)
+	rooms := room.NewManager()
+	registerOpenRooms(registry, rooms)
 	return Services{
-		UserService:     NewUserService(userRepo, questionRepo, logger),
+		UserService:     userService,
 		QuestionService: NewQuestionService(questionRepo, logger),
+		AdminService:    adminService,
+		HealthService:   NewHealthService(userRepo, questionRepo, eventRepo, logger),
+		RoomService:     NewRoomService(rooms, questionRepo, logger),
+		LiveService:     NewLiveService(live, logger),
+		WebhookService:  NewWebhookService(webhookRepo, webhookHosts, logger),
+		dispatcher:      dispatcher,
+	}
+}
+
+// Close stops what the services run in the background, sending webhooks.
+func (s Services) Close() error {
+	if s.dispatcher == nil {
+		return nil
 	}
+	return s.dispatcher.Close()
 }
@@ -1,206 +1,496 @@
 package usecase
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/MFCaballero/simple-quiz/internal/hub"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
 	"github.com/go-chi/chi/v5"
 )
 
 type UserService struct {
 	userRepo     model.UserRepository
 	questionRepo model.QuestionRepository
-	logger       *log.Logger
+	eventRepo    model.EventRepository
+	leaderboard  *Leaderboard
+	metrics      *quizMetrics
+	live         *hub.Hub
+	// webhooks is nil when no webhooks are served.
+	webhooks     webhookQueue
+	answerPolicy AnswerPolicy
+	logger       *slog.Logger
 }
 
-func NewUserService(userRepo model.UserRepository, questionRepo model.QuestionRepository, logger *log.Logger) *UserService {
+// AnswerPolicy restricts how quizzers can change their answers, so that
+// answering again can't be used to probe for the correct option.
+type AnswerPolicy struct {
+	// LockOnSubmit rejects any change to an answer once it is submitted.
+	LockOnSubmit bool
+	// MaxChanges is how many times an answer can be changed to another
+	// option, zero for no limit.
+	MaxChanges int
+}
+
+// allowsChange reports whether the previous answer can be changed to another
+// option.
+func (p AnswerPolicy) allowsChange(previous model.Answer) bool {
+	if p.LockOnSubmit {
+		return false
+	}
+	return p.MaxChanges <= 0 || previous.Changes < p.MaxChanges
+}
+
+// NewUserService builds the user service, registering its metrics to
+// registry and publishing to live. A nil registry or live hub is replaced
+// by one of its own, and webhooks are only sent with a webhooks queue.
+func NewUserService(userRepo model.UserRepository, questionRepo model.QuestionRepository, eventRepo model.EventRepository, registry *metrics.Registry, live *hub.Hub, webhooks webhookQueue, logger *slog.Logger) *UserService {
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+	if live == nil {
+		live = hub.New(liveEventsKept)
+	}
 	return &UserService{
 		userRepo:     userRepo,
 		questionRepo: questionRepo,
+		eventRepo:    eventRepo,
+		leaderboard:  NewLeaderboard(),
+		metrics:      newQuizMetrics(registry),
+		live:         live,
+		webhooks:     webhooks,
 		logger:       logger,
 	}
 }
 
+// SetAnswerPolicy sets how quizzers can change their answers. Every change
+// is allowed until it is set.
+func (us *UserService) SetAnswerPolicy(policy AnswerPolicy) {
+	us.answerPolicy = policy
+}
+
 func (us *UserService) Login(w http.ResponseWriter, r *http.Request) {
 	var loginRequest LoginRequest
-	errMessage := "An error occured logging user"
 	if err := json.NewDecoder(r.Body).Decode(&loginRequest); err != nil {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
-
-	newUser, err := us.userRepo.CreateUser(r.Context(), model.User{
-		Name: loginRequest.Name,
-	})
+	response, err := us.StartQuiz(r.Context(), loginRequest)
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		writeStatusError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(map[string]string{"user_id": newUser.ID}); err != nil {
-		us.logger.Printf("error encoding to json: %v", err)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		us.logger.ErrorContext(r.Context(), "encoding login response to json", "user_id", response.UserID, "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
-func (us *UserService) GetAnswered(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "user")
-	user, err := us.userRepo.GetUser(r.Context(), userID)
-	errMessage := "An error occured getting user's answers"
-
+// StartQuiz creates a quizzer.
+func (us *UserService) StartQuiz(ctx context.Context, request LoginRequest) (*LoginResponse, error) {
+	errMessage := "An error occured logging user"
+	newUser, err := us.userRepo.CreateUser(ctx, model.User{
+		Name: request.Name,
+	})
 	if err != nil {
-		http.Error(w, errMessage, http.StatusNotFound)
-		return
+		return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
+	}
+	us.recordEvent(ctx, model.Event{
+		UserID: newUser.ID,
+		Type:   model.EventStarted,
+		At:     time.Now().UTC(),
+		Name:   newUser.Name,
+	})
+	us.leaderboard.Started(*newUser)
+	return &LoginResponse{UserID: newUser.ID}, nil
+}
+
+// recordEvent appends the event to the log of what the users did. The user
+// is saved first, so the request succeeded whether or not the event is
+// recorded: failing it would only have the client send it again, recording
+// what it did twice. A lost event is logged instead.
+func (us *UserService) recordEvent(ctx context.Context, event model.Event) {
+	if _, err := us.eventRepo.AppendEvent(ctx, event); err != nil {
+		us.logger.ErrorContext(ctx, "recording event", "user_id", event.UserID, "type", event.Type, "error", err)
 	}
-	questions, err := us.questionRepo.GetAllQuestions(r.Context())
+}
+
+func (us *UserService) GetAnswered(w http.ResponseWriter, r *http.Request) {
+	response, err := us.AnsweredQuestions(r.Context(), chi.URLParam(r, "user"))
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		writeStatusError(w, err)
 		return
 	}
-	response := make([]Answer, len(user.Answers))
-	for _, answer := range user.Answers {
-		i, err := strconv.Atoi(answer.QuestionID)
-		if err != nil {
-			http.Error(w, errMessage, http.StatusInternalServerError)
-			return
-		}
-		response[i-1] = Answer{
-			Question:   questions[answer.QuestionID].Label,
-			QuestionID: answer.QuestionID,
-			Option:     answer.Option.Label,
-			OptionID:   answer.Option.ID,
-		}
-	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		us.logger.Printf("error encoding user %s answers to json: %v", userID, err)
+		us.logger.ErrorContext(r.Context(), "encoding user answers to json", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
-func (us *UserService) PostAnswers(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "user")
-	user, err := us.userRepo.GetUser(r.Context(), userID)
-	errMessage := "An error occured posting user's answers"
+// AnswerSortKeys are the fields the answers can be sorted by, besides the id
+// of their question.
+var AnswerSortKeys = model.SortKeys[Answer]{
+	"position": func(answer Answer) string { return model.PositionKey(answer.Position) },
+	"question": func(answer Answer) string { return strings.ToLower(answer.Question) },
+}
 
+// ListAnswered serves a page of the answers of the user, in question order
+// unless sorted otherwise, optionally only those whose question contains the
+// question parameter.
+func (us *UserService) ListAnswered(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	options, err := parseListOptions(values, AnswerSortKeys.Fields())
 	if err != nil {
-		http.Error(w, errMessage, http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	questions, err := us.questionRepo.GetAllQuestions(r.Context())
+	if options.Sort == "" {
+		options.Sort = "position"
+	}
+	answers, err := us.AnsweredQuestions(r.Context(), chi.URLParam(r, "user"))
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+		writeStatusError(w, err)
 		return
 	}
-	if len(user.Answers) != len(questions) {
-		http.Error(w, "Missing questions to answer before finishing", http.StatusForbidden)
+	if filter := strings.ToLower(values.Get("question")); filter != "" {
+		answers = slices.DeleteFunc(answers, func(answer Answer) bool {
+			return !strings.Contains(strings.ToLower(answer.Question), filter)
+		})
+	}
+	page, next, err := model.Paginate(answers, options, func(answer Answer) string { return answer.QuestionID }, AnswerSortKeys)
+	if err != nil {
+		writeStatusError(w, listError(err, "An error occured getting user's answers"))
 		return
 	}
-	user.FinishedQuiz = true
-	totalQuestions := len(questions)
-	totalCorrectAnswers := 0
-	for _, answer := range user.Answers {
-		if answer.Option.IsCorrect {
-			totalCorrectAnswers++
-		}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(AnswerPage{Answers: page, NextCursor: next}); err != nil {
+		us.logger.ErrorContext(r.Context(), "encoding user answers to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+}
 
-	user.Score = float32(totalCorrectAnswers) / float32(totalQuestions)
+// AnsweredQuestions lists the answers of the user, in question order. The
+// answers to questions removed since come last, by id.
+func (us *UserService) AnsweredQuestions(ctx context.Context, userID string) ([]Answer, error) {
+	errMessage := "An error occured getting user's answers"
+	user, err := us.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, &statusError{status: http.StatusNotFound, message: errMessage}
+	}
+	questions, err := us.questionRepo.GetAllQuestions(ctx)
+	if err != nil {
+		return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
+	}
+	response := make([]Answer, len(user.Answers))
+	for i, answer := range user.Answers {
+		question, err := us.answeredQuestion(ctx, questions, answer)
+		if err != nil {
+			return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
+		}
+		response[i] = Answer{
+			Question:   question.Label,
+			QuestionID: answer.QuestionID,
+			Position:   questions[answer.QuestionID].Position,
+			Option:     answer.Option.Label,
+			OptionID:   answer.Option.ID,
+		}
+	}
+	sort.Slice(response, func(i, j int) bool {
+		if c := model.CompareKeys(model.PositionKey(response[i].Position), model.PositionKey(response[j].Position)); c != 0 {
+			return c < 0
+		}
+		return model.CompareKeys(response[i].QuestionID, response[j].QuestionID) < 0
+	})
+	return response, nil
+}
 
-	if err := us.userRepo.UpdateUser(r.Context(), user); err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+func (us *UserService) PostAnswers(w http.ResponseWriter, r *http.Request) {
+	if err := us.FinishQuiz(r.Context(), chi.URLParam(r, "user")); err != nil {
+		writeStatusError(w, err)
 		return
 	}
-
 	w.Write([]byte("Quiz completed successfully!"))
 }
 
+// FinishQuiz scores the answers of the user, who can't change them after.
+func (us *UserService) FinishQuiz(ctx context.Context, userID string) error {
+	errMessage := "An error occured posting user's answers"
+
+	if us.live.Subscribers() > 0 {
+		// The moves in the ranking are only known once it is loaded, which
+		// must happen before the user is saved as finished.
+		if err := us.leaderboard.load(ctx, us.userRepo); err != nil {
+			us.logger.WarnContext(ctx, "loading leaderboard for the live events", "error", err)
+		}
+	}
+	var event model.Event
+	user, err := us.updateUser(ctx, userID, errMessage, func(user *model.User) error {
+		if user.FinishedQuiz {
+			return &statusError{status: http.StatusForbidden, message: "User has already finished the quiz"}
+		}
+		questions, err := us.questionRepo.GetAllQuestions(ctx)
+		if err != nil {
+			return &statusError{status: http.StatusInternalServerError, message: errMessage}
+		}
+		answered, correct := user.CountAnswers(questions)
+		if answered != len(questions) {
+			return &statusError{status: http.StatusForbidden, message: "Missing questions to answer before finishing"}
+		}
+		event = model.Event{
+			UserID:         user.ID,
+			Type:           model.EventFinished,
+			At:             time.Now().UTC(),
+			Score:          float32(correct) / float32(len(questions)),
+			CorrectAnswers: correct,
+			TotalQuestions: len(questions),
+		}
+		user.Apply(event)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	changes := us.leaderboard.Upsert(*user)
+	us.metrics.finished.Inc()
+	us.metrics.scores.Observe(float64(user.Score))
+	us.recordEvent(ctx, event)
+	us.publishFinished(ctx, *user, changes)
+	if us.webhooks != nil {
+		// The quiz is finished either way, a webhook not notified is only
+		// logged.
+		if err := us.webhooks.Enqueue(ctx, webhook.EventQuizFinished, FinishedWebhook{
+			UserID:     user.ID,
+			Name:       user.Name,
+			Score:      user.Score,
+			FinishedAt: *user.FinishedAt,
+		}); err != nil {
+			us.logger.ErrorContext(ctx, "queueing webhooks", "event", webhook.EventQuizFinished, "error", err)
+		}
+	}
+	return nil
+}
+
 func (us *UserService) AnswerQuestion(w http.ResponseWriter, r *http.Request) {
 	var answerRequest AnswerRequest
-	errMessage := "An error occured answering question"
-
 	if err := json.NewDecoder(r.Body).Decode(&answerRequest); err != nil {
-		http.Error(w, errMessage, http.StatusBadRequest)
+		http.Error(w, "An error occured answering question", http.StatusBadRequest)
+		return
+	}
+	if err := us.Answer(r.Context(), chi.URLParam(r, "user"), answerRequest); err != nil {
+		writeStatusError(w, err)
 		return
 	}
+}
 
-	userID := chi.URLParam(r, "user")
-	user, err := us.userRepo.GetUser(r.Context(), userID)
+// Answer saves the answer of the user to a question, replacing any previous
+// answer to it as far as the answer policy allows. Answering again with the
+// same option is always accepted and doesn't count as a change. Whether the
+// option is correct never changes the outcome, so answering can't tell it.
+func (us *UserService) Answer(ctx context.Context, userID string, answerRequest AnswerRequest) error {
+	errMessage := "An error occured answering question"
+	var (
+		event          model.Event
+		totalQuestions int
+	)
+	user, err := us.updateUser(ctx, userID, errMessage, func(user *model.User) error {
+		if user.FinishedQuiz {
+			return &statusError{status: http.StatusForbidden, message: "User has already finished the quiz"}
+		}
 
+		questions, err := us.questionRepo.GetAllQuestions(ctx)
+		if err != nil {
+			return &statusError{status: http.StatusInternalServerError, message: "Failed to retrieve questions"}
+		}
+		totalQuestions = len(questions)
+		question, ok := questions[answerRequest.QuestionID]
+		if !ok {
+			return &statusError{status: http.StatusBadRequest, message: errMessage}
+		}
+		var chosen *model.Option
+		for _, option := range question.Options {
+			if option.ID == answerRequest.OptionID {
+				chosen = &model.Option{
+					ID:        answerRequest.OptionID,
+					Label:     option.Label,
+					IsCorrect: option.IsCorrect,
+				}
+			}
+		}
+		if chosen == nil {
+			return &statusError{status: http.StatusBadRequest, message: errMessage}
+		}
+
+		eventType := model.EventAnswered
+		for _, previous := range user.Answers {
+			if previous.QuestionID != answerRequest.QuestionID {
+				continue
+			}
+			if previous.Option.ID != chosen.ID && !us.answerPolicy.allowsChange(previous) {
+				return &statusError{status: http.StatusForbidden, message: "The answer to this question can't be changed anymore"}
+			}
+			eventType = model.EventAnswerChanged
+		}
+		event = model.Event{
+			UserID:           user.ID,
+			Type:             eventType,
+			At:               time.Now().UTC(),
+			QuestionID:       answerRequest.QuestionID,
+			QuestionRevision: question.Revision,
+			Option:           chosen,
+		}
+		user.Apply(event)
+		return nil
+	})
 	if err != nil {
-		http.Error(w, errMessage, http.StatusNotFound)
-		return
+		return err
 	}
+	us.metrics.answers.Inc()
+	us.recordEvent(ctx, event)
+	publish(ctx, us.live, us.logger, LiveAnswered, AnsweredEvent{
+		UserID:     user.ID,
+		Name:       user.Name,
+		QuestionID: event.QuestionID,
+		Changed:    event.Type == model.EventAnswerChanged,
+		Answered:   len(user.Answers),
+		Total:      totalQuestions,
+	})
+	return nil
+}
 
-	if user.FinishedQuiz {
-		http.Error(w, "User has already finished the quiz", http.StatusForbidden)
-		return
+// publishFinished streams that the user finished and how the top of the
+// ranking moved.
+func (us *UserService) publishFinished(ctx context.Context, user model.User, changes []RankChange) {
+	finished := FinishedEvent{UserID: user.ID, Name: user.Name, Score: user.Score}
+	if page := us.leaderboard.Query(LeaderboardQuery{UserID: user.ID, Limit: 1}); page.User != nil {
+		finished.Rank = page.User.Rank
 	}
+	publish(ctx, us.live, us.logger, LiveFinished, finished)
 
-	questions, err := us.questionRepo.GetAllQuestions(r.Context())
-	if err != nil {
-		http.Error(w, "Failed to retrieve questions", http.StatusInternalServerError)
-		return
+	var top []RankChange
+	for _, change := range changes {
+		if change.UserID == user.ID || change.To <= liveLeaderboardTop || (change.From != 0 && change.From <= liveLeaderboardTop) {
+			top = append(top, change)
+		}
 	}
-	question, ok := questions[answerRequest.QuestionID]
-	if !ok {
-		http.Error(w, errMessage, http.StatusBadRequest)
-		return
+	if len(top) > 0 {
+		publish(ctx, us.live, us.logger, LiveLeaderboard, LeaderboardEvent{Changes: top})
 	}
-	answers := []model.Answer{}
-	for _, answer := range user.Answers {
-		if answer.QuestionID != answerRequest.QuestionID {
-			answers = append(answers, answer)
+}
+
+// maxUpdateAttempts bounds how many times updateUser starts over when other
+// requests keep changing the user first.
+const maxUpdateAttempts = 10
+
+// updateUser reads the user, applies change to it and saves it. When another
+// request saved the user in between, the update starts over from the newly
+// saved user, so no change is lost. Errors returned by change are passed
+// through, any other error is a *statusError using errMessage.
+func (us *UserService) updateUser(ctx context.Context, userID, errMessage string, change func(user *model.User) error) (*model.User, error) {
+	for attempt := 1; ; attempt++ {
+		user, err := us.userRepo.GetUser(ctx, userID)
+		if err != nil {
+			return nil, &statusError{status: http.StatusNotFound, message: errMessage}
 		}
-	}
-	var isOptionValid bool
-	for _, option := range question.Options {
-		if option.ID == answerRequest.OptionID {
-			answers = append(answers, model.Answer{
-				QuestionID: answerRequest.QuestionID,
-				Option: model.Option{
-					ID:        answerRequest.OptionID,
-					Label:     option.Label,
-					IsCorrect: option.IsCorrect,
-				}})
-			isOptionValid = true
+		if err := change(user); err != nil {
+			return nil, err
 		}
+
+		err = us.userRepo.UpdateUser(ctx, user)
+		var conflict *model.ConflictError
+		switch {
+		case err == nil:
+			return user, nil
+		case !errors.As(err, &conflict):
+			return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
+		case attempt == maxUpdateAttempts:
+			return nil, &statusError{status: http.StatusConflict, message: "The user is being changed by another request, try again"}
+		}
+		// Wait a little, for a random time so the requests that conflicted
+		// don't retry in lockstep.
+		time.Sleep(time.Duration(rand.Intn(attempt*int(time.Millisecond)) + 1))
 	}
-	if !isOptionValid {
-		http.Error(w, errMessage, http.StatusBadRequest)
+}
+
+// statusError is an error that is reported to the client with the given
+// status code.
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// ErrorStatus gives the HTTP status an error of the services is reported
+// with, and its message, for the APIs other than the HTTP one.
+func ErrorStatus(err error) (int, string) {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.status, se.message
 	}
-	user.Answers = answers
-	if err := us.userRepo.UpdateUser(r.Context(), user); err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
+	return http.StatusInternalServerError, err.Error()
+}
+
+// writeStatusError reports err to the client. A statusError without a message
+// is sent without a body.
+func writeStatusError(w http.ResponseWriter, err error) {
+	status, message := ErrorStatus(err)
+	if message == "" {
+		w.WriteHeader(status)
 		return
 	}
+	http.Error(w, message, status)
 }
 
 func (us *UserService) GetScoreData(w http.ResponseWriter, r *http.Request) {
-	userID := chi.URLParam(r, "user")
+	scoreData, err := us.Score(r.Context(), chi.URLParam(r, "user"))
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(scoreData); err != nil {
+		us.logger.ErrorContext(r.Context(), "encoding score data to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Score compares the score of a user that finished to the other quizzers.
+func (us *UserService) Score(ctx context.Context, userID string) (*ScoreData, error) {
 	errMessage := "An error occured getting user's score data"
 
-	users, err := us.userRepo.GetAllUsers(r.Context())
+	users, err := us.userRepo.GetAllUsers(ctx)
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
-		return
+		return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
 	}
 	user, ok := users[userID]
 	if !ok {
-		http.Error(w, errMessage, http.StatusNotFound)
-		return
+		return nil, &statusError{status: http.StatusNotFound, message: errMessage}
 	}
 	if !user.FinishedQuiz {
-		http.Error(w, "user has not finished quiz", http.StatusForbidden)
-		return
+		return nil, &statusError{status: http.StatusForbidden, message: "user has not finished quiz"}
 	}
 
 	var (
@@ -222,38 +512,122 @@ func (us *UserService) GetScoreData(w http.ResponseWriter, r *http.Request) {
 		averageScore = totalScore / float32(otherUsers)
 		relativePerformance = (user.Score - averageScore) / averageScore
 	}
-	questions, err := us.questionRepo.GetAllQuestions(r.Context())
+	questions, err := us.questionRepo.GetAllQuestions(ctx)
 	if err != nil {
-		http.Error(w, errMessage, http.StatusInternalServerError)
-		return
+		return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
 	}
 
+	correct, total := user.CorrectAnswers, user.TotalQuestions
+	if total == 0 {
+		// The user finished before the counts were saved.
+		_, correct = user.CountAnswers(questions)
+		total = len(questions)
+	}
 	scoreData := ScoreData{
 		Score:               user.Score,
-		TotalQuestions:      len(questions),
-		CorrectAnswers:      int(user.Score * float32(len(questions))),
+		TotalQuestions:      total,
+		CorrectAnswers:      correct,
 		BetterThan:          betterThan,
 		RelativePerformance: relativePerformance,
 	}
 	for _, answer := range user.Answers {
+		question, err := us.answeredQuestion(ctx, questions, answer)
+		if err != nil {
+			return nil, &statusError{status: http.StatusInternalServerError, message: errMessage}
+		}
 		scoreData.AnswersDetail = append(scoreData.AnswersDetail, AnswersDetail{
-			Question:  questions[answer.QuestionID].Label,
+			Question:  question.Label,
 			Answer:    answer.Option.Label,
 			IsCorrect: answer.Option.IsCorrect,
 		})
 	}
 
+	return &scoreData, nil
+}
+
+// answeredQuestion returns the question as it was when the answer was given,
+// which differs from the current one when the question was edited since.
+func (us *UserService) answeredQuestion(ctx context.Context, questions model.QuestionMap, answer model.Answer) (model.Question, error) {
+	question, exists := questions[answer.QuestionID]
+	if answer.QuestionRevision == 0 || (exists && question.Revision == answer.QuestionRevision) {
+		return question, nil
+	}
+	revision, err := us.questionRepo.GetQuestionRevision(ctx, answer.QuestionID, answer.QuestionRevision)
+	if err != nil {
+		return model.Question{}, err
+	}
+	return *revision, nil
+}
+
+func (us *UserService) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured getting the leaderboard"
+
+	query, err := parseLeaderboardQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := us.leaderboard.load(r.Context(), us.userRepo); err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(scoreData); err != nil {
-		us.logger.Printf("error encoding score data to json: %v", err)
+	if err := json.NewEncoder(w).Encode(us.leaderboard.Query(query)); err != nil {
+		us.logger.ErrorContext(r.Context(), "encoding leaderboard to json", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
+const (
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+func parseLeaderboardQuery(r *http.Request) (LeaderboardQuery, error) {
+	values := r.URL.Query()
+	query := LeaderboardQuery{
+		Limit:  defaultLeaderboardLimit,
+		UserID: values.Get("user"),
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxLeaderboardLimit {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", maxLeaderboardLimit)
+		}
+		query.Limit = n
+	}
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return query, errors.New("offset must be a positive number")
+		}
+		query.Offset = n
+	}
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, fmt.Errorf("%s must be a RFC3339 timestamp", param.name)
+		}
+		*param.dst = &t
+	}
+	return query, nil
+}
+
 type LoginRequest struct {
 	Name string `json:"name"`
 }
+type LoginResponse struct {
+	UserID string `json:"user_id"`
+}
 type AnswerRequest struct {
 	QuestionID string `json:"question_id"`
 	OptionID   string `json:"option_id"`
@@ -275,6 +649,15 @@ type AnswersDetail struct {
 type Answer struct {
 	Question   string `json:"question"`
 	QuestionID string `json:"question_id"`
-	Option     string `json:"option"`
-	OptionID   string `json:"option_id"`
+	// Position is the position of the question, 0 when it has none or was
+	// removed.
+	Position int    `json:"position,omitempty"`
+	Option   string `json:"option"`
+	OptionID string `json:"option_id"`
+}
+
+// AnswerPage is a page of the answers of a user, served by /v2.
+type AnswerPage struct {
+	Answers    []Answer `json:"answers"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
@@ -9,12 +9,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
 	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLogin(t *testing.T) {
@@ -22,13 +25,15 @@ func TestLogin(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
 
-	userService := NewUserService(mockUserRepo, nil, nil)
+	userService := NewUserService(mockUserRepo, nil, mockEventRepo, nil, nil, nil, logging.Discard())
 	ctx := context.Background()
 
 	t.Run("Login Success", func(t *testing.T) {
 
-		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Return(&model.User{ID: "1"}, nil)
+		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Return(&model.User{ID: "1", Name: "John Doe"}, nil)
+		mockEventRepo.EXPECT().AppendEvent(ctx, eventMatcher{UserID: "1", Type: model.EventStarted, Name: "John Doe"}).Return(&model.Event{}, nil)
 		requestBody := map[string]string{"name": "John Doe"}
 		jsonBody, _ := json.Marshal(requestBody)
 
@@ -62,6 +67,24 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
+	t.Run("Login Success - Event Not Recorded", func(t *testing.T) {
+		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Return(&model.User{ID: "1"}, nil)
+		mockEventRepo.EXPECT().AppendEvent(ctx, gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+		validRequestBody := map[string]string{"name": "John Doe"}
+		jsonBody, _ := json.Marshal(validRequestBody)
+
+		req, err := http.NewRequest("POST", "/users/login", bytes.NewBuffer(jsonBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+
+		http.HandlerFunc(userService.Login).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code, "the user is saved, the lost event is only logged")
+	})
+
 	t.Run("Login Failure - Internal Server Error", func(t *testing.T) {
 		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Return(nil, errors.New("Internal Server Error"))
 		validRequestBody := map[string]string{"name": "John Doe"}
@@ -101,6 +124,39 @@ func setupRouterAndRequest(
 	return rr
 }
 
+// eventMatcher matches an event by its content, ignoring when it happened.
+type eventMatcher struct {
+	UserID     string
+	Type       model.EventType
+	Name       string
+	QuestionID string
+	OptionID   string
+	Score      float32
+}
+
+func (m eventMatcher) Matches(x interface{}) bool {
+	event, ok := x.(model.Event)
+	if !ok || event.At.IsZero() {
+		return false
+	}
+	var optionID string
+	if event.Option != nil {
+		optionID = event.Option.ID
+	}
+	return m == eventMatcher{
+		UserID:     event.UserID,
+		Type:       event.Type,
+		Name:       event.Name,
+		QuestionID: event.QuestionID,
+		OptionID:   optionID,
+		Score:      event.Score,
+	}
+}
+
+func (m eventMatcher) String() string {
+	return fmt.Sprintf("is event %+v", eventMatcher(m))
+}
+
 func TestGetAnswered(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -108,7 +164,7 @@ func TestGetAnswered(t *testing.T) {
 	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
 
-	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil, nil, nil, nil, nil)
 	mockUserID := "1"
 	mockUser := &model.User{
 		ID: mockUserID,
@@ -140,6 +196,56 @@ func TestGetAnswered(t *testing.T) {
 		assert.Equal(t, expectedResponseBody, responseBody)
 	})
 
+	t.Run("GetAnswered Success - In Position Order", func(t *testing.T) {
+		mockUser := &model.User{
+			ID: mockUserID,
+			Answers: []model.Answer{
+				{QuestionID: "7", Option: model.Option{ID: "A", Label: "Option A"}},
+				{QuestionID: "intro", Option: model.Option{ID: "B", Label: "Option B"}},
+			},
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{
+			"7":     {Label: "Question 7", Position: 2},
+			"intro": {Label: "Intro", Position: 1},
+		}, nil)
+
+		answers, err := userService.AnsweredQuestions(context.Background(), mockUserID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Answer{
+			{Question: "Intro", QuestionID: "intro", Position: 1, Option: "Option B", OptionID: "B"},
+			{Question: "Question 7", QuestionID: "7", Position: 2, Option: "Option A", OptionID: "A"},
+		}, answers)
+	})
+
+	t.Run("GetAnswered Success - Question Edited After Answering", func(t *testing.T) {
+		mockUser := &model.User{
+			ID: mockUserID,
+			Answers: []model.Answer{
+				{QuestionID: "1", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Option A"}},
+				{QuestionID: "2", QuestionRevision: 1, Option: model.Option{ID: "B", Label: "Option B"}},
+			},
+		}
+		mockQuestions := model.QuestionMap{
+			"1": {Label: "Question 1, edited", Revision: 2},
+			"2": {Label: "Question 2", Revision: 1},
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockQuestionRepo.EXPECT().GetQuestionRevision(gomock.Any(), "1", 1).Return(&model.Question{Label: "Question 1", Revision: 1}, nil)
+
+		rr := setupRouterAndRequest(t, userService.GetAnswered, "GET", "/users/{user}/answered", fmt.Sprintf("/users/%s/answered", mockUserID), nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var responseBody []Answer
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+		assert.Equal(t, []Answer{
+			{Question: "Question 1", QuestionID: "1", Option: "Option A", OptionID: "A"},
+			{Question: "Question 2", QuestionID: "2", Option: "Option B", OptionID: "B"},
+		}, responseBody)
+	})
+
 	t.Run("GetAnswered Failure - User Not Found", func(t *testing.T) {
 		mockUserID := "nonExistentUserID"
 		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(nil, errors.New("User not found"))
@@ -160,14 +266,71 @@ func TestGetAnswered(t *testing.T) {
 	})
 }
 
+func TestListAnswered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil, nil, nil, nil, logging.Discard())
+	mockUser := &model.User{
+		ID: "1",
+		Answers: []model.Answer{
+			{QuestionID: "1", Option: model.Option{ID: "A", Label: "Option A"}},
+			{QuestionID: "2", Option: model.Option{ID: "B", Label: "Option B"}},
+			{QuestionID: "3", Option: model.Option{ID: "C", Label: "Option C"}},
+		},
+	}
+	mockQuestions := model.QuestionMap{
+		"1": {Label: "Zebras?"},
+		"2": {Label: "Apples?"},
+		"3": {Label: "Zucchini?"},
+	}
+
+	t.Run("ListAnswered Success - Follows Cursor", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(mockUser, nil).Times(2)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil).Times(2)
+
+		rr := setupRouterAndRequest(t, userService.ListAnswered, "GET", "/users/{user}/answered", "/users/1/answered?limit=1&sort=-question&question=z", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var page AnswerPage
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		require.Len(t, page.Answers, 1)
+		assert.Equal(t, "3", page.Answers[0].QuestionID)
+		require.NotEmpty(t, page.NextCursor)
+
+		rr = setupRouterAndRequest(t, userService.ListAnswered, "GET", "/users/{user}/answered", "/users/1/answered?limit=1&sort=-question&question=z&cursor="+page.NextCursor, nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"answers":[{"question":"Zebras?","question_id":"1","option":"Option A","option_id":"A"}]}`, rr.Body.String())
+	})
+
+	t.Run("ListAnswered Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, userService.ListAnswered, "GET", "/users/{user}/answered", "/users/1/answered?sort=option", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("ListAnswered Failure - Not Found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), "9").Return(nil, errors.New("user not found"))
+
+		rr := setupRouterAndRequest(t, userService.ListAnswered, "GET", "/users/{user}/answered", "/users/9/answered", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
 func TestAnswerQuestion(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
 
-	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, logging.Discard())
 	mockUserID := "1"
 	mockUser := &model.User{
 		ID: mockUserID,
@@ -185,6 +348,7 @@ func TestAnswerQuestion(t *testing.T) {
 			Options: []model.Option{
 				{ID: "B", Label: "Option B", IsCorrect: true},
 			},
+			Revision: 3,
 		},
 	}
 
@@ -192,6 +356,7 @@ func TestAnswerQuestion(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
 		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), eventMatcher{UserID: mockUserID, Type: model.EventAnswered, QuestionID: "2", OptionID: "B"}).Return(&model.Event{}, nil)
 		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
 
 		reqBody, err := json.Marshal(mockAnswerRequest)
@@ -226,6 +391,82 @@ func TestAnswerQuestion(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 	})
 
+	t.Run("AnswerQuestion Success - Answer Changed", func(t *testing.T) {
+		mockUser := &model.User{
+			ID: mockUserID,
+			Answers: []model.Answer{
+				{QuestionID: "2", Option: model.Option{ID: "A", Label: "Option A"}},
+			},
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), eventMatcher{UserID: mockUserID, Type: model.EventAnswerChanged, QuestionID: "2", OptionID: "B"}).Return(&model.Event{}, nil)
+		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
+
+		reqBody, err := json.Marshal(mockAnswerRequest)
+		assert.NoError(t, err)
+		rr := setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", fmt.Sprintf("/users/%s/answer", mockUserID), reqBody)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []model.Answer{{QuestionID: "2", QuestionRevision: 3, Option: model.Option{ID: "B", Label: "Option B", IsCorrect: true}, Changes: 1}}, mockUser.Answers)
+	})
+
+	t.Run("AnswerQuestion Success - Event Not Recorded", func(t *testing.T) {
+		mockUser := &model.User{ID: mockUserID}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+
+		reqBody, err := json.Marshal(mockAnswerRequest)
+		assert.NoError(t, err)
+		rr := setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", fmt.Sprintf("/users/%s/answer", mockUserID), reqBody)
+
+		assert.Equal(t, http.StatusOK, rr.Code, "the answer is saved, the lost event is only logged")
+		assert.Len(t, mockUser.Answers, 1)
+	})
+
+	t.Run("AnswerQuestion Success - Retried On Conflict", func(t *testing.T) {
+		stale := &model.User{ID: mockUserID, Version: 1}
+		fresh := &model.User{ID: mockUserID, Version: 2, Answers: []model.Answer{
+			{QuestionID: "1", Option: model.Option{ID: "A", Label: "Option A"}},
+		}}
+		conflict := &model.ConflictError{UserID: mockUserID, Expected: 1, Actual: 2}
+		gomock.InOrder(
+			mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(stale, nil),
+			mockUserRepo.EXPECT().UpdateUser(gomock.Any(), stale).Return(conflict),
+			mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(fresh, nil),
+			mockUserRepo.EXPECT().UpdateUser(gomock.Any(), fresh).Return(nil),
+		)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil).Times(2)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), eventMatcher{UserID: mockUserID, Type: model.EventAnswered, QuestionID: "2", OptionID: "B"}).Return(&model.Event{}, nil)
+
+		reqBody, err := json.Marshal(mockAnswerRequest)
+		assert.NoError(t, err)
+		rr := setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", fmt.Sprintf("/users/%s/answer", mockUserID), reqBody)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []model.Answer{
+			{QuestionID: "1", Option: model.Option{ID: "A", Label: "Option A"}},
+			{QuestionID: "2", QuestionRevision: 3, Option: model.Option{ID: "B", Label: "Option B", IsCorrect: true}},
+		}, fresh.Answers)
+	})
+
+	t.Run("AnswerQuestion Failure - Conflict Persists", func(t *testing.T) {
+		conflict := &model.ConflictError{UserID: mockUserID, Expected: 1, Actual: 2}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).DoAndReturn(func(ctx context.Context, id string) (*model.User, error) {
+			return &model.User{ID: id, Version: 1}, nil
+		}).Times(maxUpdateAttempts)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil).Times(maxUpdateAttempts)
+		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(conflict).Times(maxUpdateAttempts)
+
+		reqBody, err := json.Marshal(mockAnswerRequest)
+		assert.NoError(t, err)
+		rr := setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", fmt.Sprintf("/users/%s/answer", mockUserID), reqBody)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
 	t.Run("AnswerQuestion Failure - User Already Finished Quiz", func(t *testing.T) {
 
 		mockUser.FinishedQuiz = true
@@ -248,7 +489,6 @@ func TestAnswerQuestion(t *testing.T) {
 
 		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
 		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
-		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
 
 		reqBody, err := json.Marshal(mockAnswerRequest)
 		assert.NoError(t, err)
@@ -259,6 +499,84 @@ func TestAnswerQuestion(t *testing.T) {
 	})
 }
 
+func TestAnswerPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
+	mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{
+		"1": {
+			Label: "Question 1",
+			Options: []model.Option{
+				{ID: "A", Label: "Option A", IsCorrect: true},
+				{ID: "B", Label: "Option B"},
+				{ID: "C", Label: "Option C"},
+			},
+		},
+	}, nil).AnyTimes()
+	mockEventRepo.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).Return(&model.Event{}, nil).AnyTimes()
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	answer := func(userService *UserService, user *model.User, questionID, optionID string) *httptest.ResponseRecorder {
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+		reqBody, err := json.Marshal(AnswerRequest{QuestionID: questionID, OptionID: optionID})
+		require.NoError(t, err)
+		return setupRouterAndRequest(t, userService.AnswerQuestion, "POST", "/users/{user}/answer", fmt.Sprintf("/users/%s/answer", user.ID), reqBody)
+	}
+
+	t.Run("Responses Don't Tell Correct Options", func(t *testing.T) {
+		userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, nil)
+
+		correct := answer(userService, &model.User{ID: "1"}, "1", "A")
+		incorrect := answer(userService, &model.User{ID: "2"}, "1", "B")
+		assert.Equal(t, http.StatusOK, correct.Code)
+		assert.Equal(t, correct.Code, incorrect.Code)
+		assert.Equal(t, correct.Header(), incorrect.Header())
+		assert.Equal(t, correct.Body.String(), incorrect.Body.String())
+
+		unknownQuestion := answer(userService, &model.User{ID: "3"}, "2", "A")
+		unknownOption := answer(userService, &model.User{ID: "4"}, "1", "D")
+		assert.Equal(t, http.StatusBadRequest, unknownQuestion.Code)
+		assert.Equal(t, unknownQuestion.Code, unknownOption.Code)
+		assert.Equal(t, unknownQuestion.Body.String(), unknownOption.Body.String())
+	})
+
+	t.Run("Lock On Submit", func(t *testing.T) {
+		userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, nil)
+		userService.SetAnswerPolicy(AnswerPolicy{LockOnSubmit: true})
+		user := &model.User{ID: "1"}
+
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "B").Code)
+		toCorrect := answer(userService, user, "1", "A")
+		toIncorrect := answer(userService, user, "1", "C")
+		assert.Equal(t, http.StatusForbidden, toCorrect.Code)
+		assert.Equal(t, toCorrect.Code, toIncorrect.Code)
+		assert.Equal(t, toCorrect.Body.String(), toIncorrect.Body.String())
+
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "B").Code)
+		assert.Equal(t, []model.Answer{{QuestionID: "1", Option: model.Option{ID: "B", Label: "Option B"}}}, user.Answers)
+	})
+
+	t.Run("Max Changes", func(t *testing.T) {
+		userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, nil)
+		userService.SetAnswerPolicy(AnswerPolicy{MaxChanges: 2})
+		user := &model.User{ID: "1"}
+
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "B").Code)
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "C").Code)
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "C").Code)
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "B").Code)
+		assert.Equal(t, http.StatusForbidden, answer(userService, user, "1", "A").Code)
+		assert.Equal(t, http.StatusOK, answer(userService, user, "1", "B").Code)
+		assert.Equal(t, []model.Answer{{QuestionID: "1", Option: model.Option{ID: "B", Label: "Option B"}, Changes: 2}}, user.Answers)
+
+		other := &model.User{ID: "2"}
+		assert.Equal(t, http.StatusOK, answer(userService, other, "1", "A").Code)
+	})
+}
+
 func TestGetScoreData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -266,7 +584,7 @@ func TestGetScoreData(t *testing.T) {
 	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
 
-	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil, nil, nil, nil, nil)
 	mockUserID := "1"
 	mockUser := &model.User{
 		ID:           mockUserID,
@@ -311,6 +629,46 @@ func TestGetScoreData(t *testing.T) {
 		assert.Equal(t, expectedResponseBody, responseBody)
 	})
 
+	t.Run("GetScoreData Success - Counts Saved On Finish", func(t *testing.T) {
+		answers := make([]model.Answer, 10)
+		questions := model.QuestionMap{}
+		for i := range answers {
+			id := fmt.Sprint(i + 1)
+			answers[i] = model.Answer{QuestionID: id, Option: model.Option{ID: "A", IsCorrect: i < 7}}
+			questions[id] = model.Question{Label: "Question " + id}
+		}
+		// Three of the questions were removed since the user finished.
+		delete(questions, "8")
+		delete(questions, "9")
+		delete(questions, "10")
+		mockUsers := model.UserMap{
+			mockUserID: {ID: mockUserID, FinishedQuiz: true, Score: 0.7, CorrectAnswers: 7, TotalQuestions: 10, Answers: answers},
+		}
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(questions, nil)
+
+		rr := setupRouterAndRequest(t, userService.GetScoreData, "GET", "/users/{user}/score", fmt.Sprintf("/users/%s/score", mockUserID), nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var responseBody ScoreData
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responseBody))
+		assert.Equal(t, 7, responseBody.CorrectAnswers, "0.7 times 10 is not rounded down to 6")
+		assert.Equal(t, 10, responseBody.TotalQuestions)
+	})
+
+	t.Run("GetScoreData Failure - Answered Revision Not Found", func(t *testing.T) {
+		mockUsers := model.UserMap{
+			mockUserID: {ID: mockUserID, FinishedQuiz: true, Answers: []model.Answer{{QuestionID: "1", QuestionRevision: 1}}},
+		}
+		mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{"1": {Label: "Question 1", Revision: 2}}, nil)
+		mockQuestionRepo.EXPECT().GetQuestionRevision(gomock.Any(), "1", 1).Return(nil, errors.New("question with id 1 has no revision 1"))
+
+		rr := setupRouterAndRequest(t, userService.GetScoreData, "GET", "/users/{user}/score", fmt.Sprintf("/users/%s/score", mockUserID), nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
 	t.Run("GetScoreData Failure - User Not Found", func(t *testing.T) {
 		mockUserID := "nonExistentUserID"
 		mockUsers := model.UserMap{}
@@ -347,8 +705,9 @@ func TestPostAnswers(t *testing.T) {
 
 	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
 	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
 
-	userService := NewUserService(mockUserRepo, mockQuestionRepo, nil)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, logging.Discard())
 
 	mockQuestions := model.QuestionMap{
 		"1": {Label: "Question 1", Options: []model.Option{{ID: "A", IsCorrect: true}, {ID: "B", IsCorrect: false}}},
@@ -366,6 +725,7 @@ func TestPostAnswers(t *testing.T) {
 		}
 		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
 		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), eventMatcher{UserID: mockUserID, Type: model.EventFinished, Score: 0.5}).Return(&model.Event{}, nil)
 		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
 
 		rr := setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", fmt.Sprintf("/users/%s/finish", mockUserID), nil)
@@ -373,9 +733,30 @@ func TestPostAnswers(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rr.Code)
 		assert.Equal(t, "Quiz completed successfully!", rr.Body.String())
 		assert.True(t, mockUser.FinishedQuiz)
+		assert.NotNil(t, mockUser.FinishedAt)
 		assert.Equal(t, float32(0.5), mockUser.Score) // 1 correct answer out of 2 questions
 	})
 
+	t.Run("PostAnswers Failure - Already Finished", func(t *testing.T) {
+		mockUserID := "1"
+		finishedAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+		mockUser := &model.User{
+			ID:           mockUserID,
+			Answers:      []model.Answer{{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}}, {QuestionID: "2", Option: model.Option{ID: "B", IsCorrect: true}}},
+			FinishedQuiz: true,
+			FinishedAt:   &finishedAt,
+			Score:        0.5,
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+
+		rr := setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", fmt.Sprintf("/users/%s/finish", mockUserID), nil)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Equal(t, "User has already finished the quiz\n", rr.Body.String())
+		assert.Equal(t, &finishedAt, mockUser.FinishedAt, "the first finish is kept")
+		assert.Equal(t, float32(0.5), mockUser.Score)
+	})
+
 	t.Run("PostAnswers Failure - User Not Found", func(t *testing.T) {
 		mockUserID := "nonExistentUserID"
 		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(nil, errors.New("User not found"))
@@ -385,6 +766,47 @@ func TestPostAnswers(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rr.Code)
 	})
 
+	t.Run("PostAnswers Success - Answers To Removed Questions Ignored", func(t *testing.T) {
+		mockUserID := "3"
+		mockUser := &model.User{
+			ID: mockUserID,
+			Answers: []model.Answer{
+				{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}},
+				{QuestionID: "9", Option: model.Option{ID: "A", IsCorrect: true}},
+				{QuestionID: "2", Option: model.Option{ID: "A", IsCorrect: false}},
+			},
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+		mockEventRepo.EXPECT().AppendEvent(gomock.Any(), eventMatcher{UserID: mockUserID, Type: model.EventFinished, Score: 0.5}).Return(&model.Event{}, nil)
+		mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
+
+		rr := setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", fmt.Sprintf("/users/%s/finish", mockUserID), nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, float32(0.5), mockUser.Score)
+		assert.Equal(t, 1, mockUser.CorrectAnswers)
+		assert.Equal(t, 2, mockUser.TotalQuestions)
+	})
+
+	t.Run("PostAnswers Failure - Only Removed Questions Answered", func(t *testing.T) {
+		mockUserID := "4"
+		mockUser := &model.User{
+			ID: mockUserID,
+			Answers: []model.Answer{
+				{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}},
+				{QuestionID: "9", Option: model.Option{ID: "A", IsCorrect: true}},
+			},
+		}
+		mockUserRepo.EXPECT().GetUser(gomock.Any(), mockUserID).Return(mockUser, nil)
+		mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(mockQuestions, nil)
+
+		rr := setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", fmt.Sprintf("/users/%s/finish", mockUserID), nil)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code, "question 2 is not answered")
+		assert.False(t, mockUser.FinishedQuiz)
+	})
+
 	t.Run("PostAnswers Failure - Internal Server Error", func(t *testing.T) {
 		mockUserID := "2"
 		mockUser := &model.User{
@@ -422,3 +844,182 @@ func TestPostAnswers(t *testing.T) {
 		assert.Equal(t, float32(0), mockUser.Score)
 	})
 }
+
+func TestGetLeaderboard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+
+	userService := NewUserService(mockUserRepo, nil, nil, nil, nil, nil, nil)
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	third := first.Add(48 * time.Hour)
+	mockUsers := model.UserMap{
+		"1": {ID: "1", Name: "Ana", FinishedQuiz: true, Score: 0.8, FinishedAt: &second},
+		"2": {ID: "2", Name: "Bob", FinishedQuiz: true, Score: 1, FinishedAt: &third},
+		"3": {ID: "3", Name: "Cid", FinishedQuiz: true, Score: 0.8, FinishedAt: &first},
+		"4": {ID: "4", Name: "Dan", FinishedQuiz: true, Score: 0.4, FinishedAt: &first},
+		"5": {ID: "5", Name: "Eve"},
+	}
+	mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(mockUsers, nil).Times(1)
+
+	t.Run("GetLeaderboard Success - Ties Are Not Split", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard?limit=2&user=4", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		expectedResponseBody := LeaderboardPage{
+			Entries: []LeaderboardEntry{
+				{Rank: 1, UserID: "2", Name: "Bob", Score: 1, FinishedAt: &third},
+				{Rank: 2, UserID: "3", Name: "Cid", Score: 0.8, FinishedAt: &first},
+				{Rank: 2, UserID: "1", Name: "Ana", Score: 0.8, FinishedAt: &second},
+			},
+			Total:      4,
+			NextOffset: 3,
+			User:       &LeaderboardEntry{Rank: 4, UserID: "4", Name: "Dan", Score: 0.4, FinishedAt: &first},
+		}
+		var responseBody LeaderboardPage
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResponseBody, responseBody)
+	})
+
+	t.Run("GetLeaderboard Success - Time Window", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard?to=2024-01-02T00:00:00Z", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var responseBody LeaderboardPage
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, responseBody.Total)
+		assert.Equal(t, "3", responseBody.Entries[0].UserID)
+		assert.Equal(t, 1, responseBody.Entries[0].Rank)
+		assert.Equal(t, 3, responseBody.Entries[2].Rank)
+	})
+
+	t.Run("GetLeaderboard Success - Finished User Is Ranked", func(t *testing.T) {
+		finishedAt := third.Add(time.Hour)
+		changes := userService.leaderboard.Upsert(model.User{ID: "5", Name: "Eve", FinishedQuiz: true, Score: 0.9, FinishedAt: &finishedAt})
+		assert.Equal(t, []RankChange{
+			{UserID: "5", Name: "Eve", To: 2},
+			{UserID: "3", Name: "Cid", From: 2, To: 3},
+			{UserID: "1", Name: "Ana", From: 2, To: 3},
+			{UserID: "4", Name: "Dan", From: 4, To: 5},
+		}, changes)
+
+		rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard?offset=1&limit=1", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var responseBody LeaderboardPage
+		err := json.Unmarshal(rr.Body.Bytes(), &responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, responseBody.Total)
+		assert.Equal(t, []LeaderboardEntry{{Rank: 2, UserID: "5", Name: "Eve", Score: 0.9, FinishedAt: &finishedAt}}, responseBody.Entries)
+	})
+
+	t.Run("GetLeaderboard Failure - Bad Request", func(t *testing.T) {
+		rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard?limit=0", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("GetLeaderboard Failure - Bad From And To", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard?from=yesterday&to=today", nil)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Equal(t, "from must be a RFC3339 timestamp\n", rr.Body.String())
+		}
+	})
+}
+
+func TestGetLeaderboardInternalServerError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+
+	userService := NewUserService(mockUserRepo, nil, nil, nil, nil, nil, nil)
+	mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(nil, errors.New("Internal Server Error"))
+
+	rr := setupRouterAndRequest(t, userService.GetLeaderboard, "GET", "/leaderboard", "/leaderboard", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestLeaderboardActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	leaderboard := NewLeaderboard()
+	finishedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ana := model.User{ID: "1", Name: "Ana"}
+	// Ana was saved before the leaderboard loaded, but is counted as started
+	// after it did.
+	mockUserRepo.EXPECT().GetAllUsers(gomock.Any()).Return(model.UserMap{
+		"1": ana,
+		"2": {ID: "2", Name: "Bob", FinishedQuiz: true, Score: 1, FinishedAt: &finishedAt},
+	}, nil).Times(1)
+
+	active, err := leaderboard.Active(context.Background(), mockUserRepo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, active)
+
+	leaderboard.Started(ana)
+	leaderboard.Started(model.User{ID: "3", Name: "Cid"})
+	active, err = leaderboard.Active(context.Background(), mockUserRepo)
+	require.NoError(t, err)
+	assert.Equal(t, 2, active)
+
+	ana.FinishedQuiz, ana.Score, ana.FinishedAt = true, 0.5, &finishedAt
+	leaderboard.Upsert(ana)
+	active, err = leaderboard.Active(context.Background(), mockUserRepo)
+	require.NoError(t, err)
+	assert.Equal(t, 1, active)
+}
+
+// TestEventsRebuildUser checks replaying the events recorded while taking
+// the quiz gives back the user as saved.
+func TestEventsRebuildUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, nil, logging.Discard())
+
+	saved := &model.User{ID: "1", Name: "Ana"}
+	var events []model.Event
+	mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{
+		"1": {Label: "Question 1", Revision: 2, Options: []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}},
+		"2": {Label: "Question 2", Options: []model.Option{{ID: "A", Label: "Yes"}, {ID: "B", Label: "No", IsCorrect: true}}},
+	}, nil).AnyTimes()
+	mockUserRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(saved, nil)
+	mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(saved, nil).AnyTimes()
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any(), saved).Return(nil).AnyTimes()
+	mockEventRepo.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, event model.Event) (*model.Event, error) {
+		events = append(events, event)
+		return &event, nil
+	}).AnyTimes()
+
+	ctx := context.Background()
+	_, err := userService.StartQuiz(ctx, LoginRequest{Name: "Ana"})
+	require.NoError(t, err)
+	for _, answer := range []AnswerRequest{{QuestionID: "1", OptionID: "B"}, {QuestionID: "2", OptionID: "B"}, {QuestionID: "1", OptionID: "A"}} {
+		require.NoError(t, userService.Answer(ctx, "1", answer))
+	}
+	require.NoError(t, userService.FinishQuiz(ctx, "1"))
+
+	var replayed model.User
+	for _, event := range events {
+		replayed.Apply(event)
+	}
+	replayed.Version = saved.Version
+	assert.Equal(t, *saved, replayed)
+	assert.Equal(t, float32(1), replayed.Score)
+}
@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookQueue queues events for the webhooks subscribed to them, as
+// webhook.Dispatcher does.
+type webhookQueue interface {
+	Enqueue(ctx context.Context, event string, data any) error
+}
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 1000
+)
+
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret signs the payloads, one is generated when it is left empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDTO is a webhook as listed, its secret is only given back when it
+// is created.
+type WebhookDTO struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FinishedWebhook is the data of the quiz.finished webhook event.
+type FinishedWebhook struct {
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	Score      float32   `json:"score"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+type WebhookService struct {
+	webhookRepo model.WebhookRepository
+	hosts       webhook.Hosts
+	logger      *slog.Logger
+}
+
+func NewWebhookService(webhookRepo model.WebhookRepository, hosts webhook.Hosts, logger *slog.Logger) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		hosts:       hosts,
+		logger:      logger,
+	}
+}
+
+func (ws *WebhookService) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var request WebhookRequest
+	errMessage := "An error occured creating webhook"
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, errMessage, http.StatusBadRequest)
+		return
+	}
+	if err := validateWebhook(request, ws.hosts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if request.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			ws.logger.ErrorContext(r.Context(), "creating webhook", "error", err)
+			http.Error(w, errMessage, http.StatusInternalServerError)
+			return
+		}
+		request.Secret = secret
+	}
+
+	created, err := ws.webhookRepo.CreateWebhook(r.Context(), model.Webhook{
+		URL:       request.URL,
+		Events:    request.Events,
+		Secret:    request.Secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+
+	response := toWebhookDTO(*created)
+	response.Secret = created.Secret
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		ws.logger.ErrorContext(r.Context(), "encoding webhook to json", "error", err)
+		return
+	}
+}
+
+func (ws *WebhookService) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := ws.webhookRepo.GetWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "An error occured getting webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]WebhookDTO, len(webhooks))
+	for i, webhook := range webhooks {
+		response[i] = toWebhookDTO(webhook)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		ws.logger.ErrorContext(r.Context(), "encoding webhooks to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// DeleteWebhook removes a webhook. Its pending deliveries fail instead of
+// being sent.
+func (ws *WebhookService) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	errMessage := "An error occured deleting webhook"
+	if err := ws.webhookRepo.DeleteWebhook(r.Context(), chi.URLParam(r, "webhook")); err != nil {
+		if errors.Is(err, model.ErrWebhookNotFound) {
+			http.Error(w, errMessage, http.StatusNotFound)
+			return
+		}
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries lists the deliveries, newest first, optionally only those of
+// a webhook or with a status.
+func (ws *WebhookService) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit := defaultDeliveriesLimit
+	if value := values.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxDeliveriesLimit {
+			http.Error(w, fmt.Sprintf("limit must be a number between 1 and %d", maxDeliveriesLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	status := model.DeliveryStatus(values.Get("status"))
+	if status != "" && !slices.Contains([]model.DeliveryStatus{model.DeliveryPending, model.DeliveryDelivered, model.DeliveryFailed}, status) {
+		http.Error(w, "status must be pending, delivered or failed", http.StatusBadRequest)
+		return
+	}
+	webhookID := values.Get("webhook")
+
+	deliveries, err := ws.webhookRepo.GetDeliveries(r.Context())
+	if err != nil {
+		http.Error(w, "An error occured getting deliveries", http.StatusInternalServerError)
+		return
+	}
+	response := []model.Delivery{}
+	for _, delivery := range deliveries {
+		if len(response) == limit {
+			break
+		}
+		if (webhookID == "" || delivery.WebhookID == webhookID) && (status == "" || delivery.Status == status) {
+			response = append(response, delivery)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		ws.logger.ErrorContext(r.Context(), "encoding deliveries to json", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func validateWebhook(request WebhookRequest, hosts webhook.Hosts) error {
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if err := hosts.Check(target.Hostname()); err != nil {
+		return err
+	}
+	if len(request.Events) == 0 {
+		return fmt.Errorf("events must list some of %s", strings.Join(webhook.Events, ", "))
+	}
+	for _, event := range request.Events {
+		if !slices.Contains(webhook.Events, event) {
+			return fmt.Errorf("unknown event %q, events must be some of %s", event, strings.Join(webhook.Events, ", "))
+		}
+	}
+	return nil
+}
+
+func toWebhookDTO(webhook model.Webhook) WebhookDTO {
+	return WebhookDTO{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	mock_model "github.com/MFCaballero/simple-quiz/internal/domain/model/mocks"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWebhookRepo := mock_model.NewMockWebhookRepository(ctrl)
+	webhookService := NewWebhookService(mockWebhookRepo, webhook.Hosts{}, logging.Discard())
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	hr := model.Webhook{ID: "1", URL: "https://hr.example.com/quiz", Events: []string{webhook.EventQuizFinished}, Secret: "s3cret", CreatedAt: createdAt}
+
+	t.Run("CreateWebhook Success", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, created model.Webhook) (*model.Webhook, error) {
+			assert.Equal(t, "https://hr.example.com/quiz", created.URL)
+			assert.Len(t, created.Secret, 48, "a secret is generated")
+			created.ID = "1"
+			return &created, nil
+		})
+
+		rr := setupRouterAndRequest(t, webhookService.CreateWebhook, "POST", "/admin/webhooks", "/admin/webhooks", []byte(`{"url": "https://hr.example.com/quiz", "events": ["quiz.finished"]}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response WebhookDTO
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "1", response.ID)
+		assert.NotEmpty(t, response.Secret, "the secret is given back once")
+	})
+
+	t.Run("CreateWebhook Failure - Invalid", func(t *testing.T) {
+		for body, message := range map[string]string{
+			`{"url": "ftp://hr.example.com", "events": ["quiz.finished"]}`:          "url must be an absolute http or https URL",
+			`{"url": "/quiz", "events": ["quiz.finished"]}`:                         "url must be an absolute http or https URL",
+			`{"url": "https://hr.example.com"}`:                                     "events must list some of quiz.finished",
+			`{"url": "https://hr.example.com", "events": ["quiz.started"]}`:         `unknown event "quiz.started", events must be some of quiz.finished`,
+			`{"url": "http://localhost:9090", "events": ["quiz.finished"]}`:         "host localhost is not public",
+			`{"url": "http://169.254.169.254/latest", "events": ["quiz.finished"]}`: "host 169.254.169.254 is not public",
+			`{"url": "https://[::1]/quiz", "events": ["quiz.finished"]}`:            "host ::1 is not public",
+		} {
+			rr := setupRouterAndRequest(t, webhookService.CreateWebhook, "POST", "/admin/webhooks", "/admin/webhooks", []byte(body))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+			assert.Equal(t, message+"\n", rr.Body.String())
+		}
+	})
+
+	t.Run("GetWebhooks Success", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().GetWebhooks(gomock.Any()).Return([]model.Webhook{hr}, nil)
+
+		rr := setupRouterAndRequest(t, webhookService.GetWebhooks, "GET", "/admin/webhooks", "/admin/webhooks", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[{"id":"1","url":"https://hr.example.com/quiz","events":["quiz.finished"],"created_at":"2024-01-01T10:00:00Z"}]`, rr.Body.String())
+	})
+
+	t.Run("DeleteWebhook Success", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().DeleteWebhook(gomock.Any(), "1").Return(nil)
+
+		rr := setupRouterAndRequest(t, webhookService.DeleteWebhook, "DELETE", "/admin/webhooks/{webhook}", "/admin/webhooks/1", nil)
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("DeleteWebhook Failure - Not Found", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().DeleteWebhook(gomock.Any(), "9").Return(model.ErrWebhookNotFound)
+
+		rr := setupRouterAndRequest(t, webhookService.DeleteWebhook, "DELETE", "/admin/webhooks/{webhook}", "/admin/webhooks/9", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("GetDeliveries Success", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().GetDeliveries(gomock.Any()).Return([]model.Delivery{
+			{ID: "4", WebhookID: "2", Status: model.DeliveryFailed},
+			{ID: "3", WebhookID: "1", Status: model.DeliveryFailed},
+			{ID: "2", WebhookID: "1", Status: model.DeliveryDelivered},
+			{ID: "1", WebhookID: "1", Status: model.DeliveryFailed},
+		}, nil)
+
+		rr := setupRouterAndRequest(t, webhookService.GetDeliveries, "GET", "/admin/webhooks/deliveries", "/admin/webhooks/deliveries?webhook=1&status=failed&limit=1", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var deliveries []model.Delivery
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "3", deliveries[0].ID)
+	})
+
+	t.Run("GetDeliveries Failure - Bad Request", func(t *testing.T) {
+		for _, query := range []string{"?limit=0", "?limit=many", "?status=lost"} {
+			rr := setupRouterAndRequest(t, webhookService.GetDeliveries, "GET", "/admin/webhooks/deliveries", "/admin/webhooks/deliveries"+query, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("GetDeliveries Failure - Internal Server Error", func(t *testing.T) {
+		mockWebhookRepo.EXPECT().GetDeliveries(gomock.Any()).Return(nil, errors.New("disk failure"))
+
+		rr := setupRouterAndRequest(t, webhookService.GetDeliveries, "GET", "/admin/webhooks/deliveries", "/admin/webhooks/deliveries", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+// queuedEvent is an event queued by a recordingQueue.
+type queuedEvent struct {
+	Event string
+	Data  any
+}
+
+type recordingQueue struct {
+	queued []queuedEvent
+	err    error
+}
+
+func (rq *recordingQueue) Enqueue(ctx context.Context, event string, data any) error {
+	rq.queued = append(rq.queued, queuedEvent{Event: event, Data: data})
+	return rq.err
+}
+
+func TestFinishQueuesWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mock_model.NewMockUserRepository(ctrl)
+	mockQuestionRepo := mock_model.NewMockQuestionRepository(ctrl)
+	mockEventRepo := mock_model.NewMockEventRepository(ctrl)
+	queue := &recordingQueue{err: errors.New("disk failure")}
+	userService := NewUserService(mockUserRepo, mockQuestionRepo, mockEventRepo, nil, nil, queue, logging.Discard())
+
+	mockUser := &model.User{ID: "1", Name: "Ana", Answers: []model.Answer{{QuestionID: "1", Option: model.Option{ID: "A", IsCorrect: true}}}}
+	mockUserRepo.EXPECT().GetUser(gomock.Any(), "1").Return(mockUser, nil)
+	mockQuestionRepo.EXPECT().GetAllQuestions(gomock.Any()).Return(model.QuestionMap{"1": {Label: "Question 1"}}, nil)
+	mockUserRepo.EXPECT().UpdateUser(gomock.Any(), mockUser).Return(nil)
+	mockEventRepo.EXPECT().AppendEvent(gomock.Any(), gomock.Any()).Return(&model.Event{}, nil)
+
+	rr := setupRouterAndRequest(t, userService.PostAnswers, "POST", "/users/{user}/finish", "/users/1/finish", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code, "a webhook that can't be queued doesn't fail the quiz")
+	require.Len(t, queue.queued, 1)
+	assert.Equal(t, webhook.EventQuizFinished, queue.queued[0].Event)
+	assert.Equal(t, FinishedWebhook{UserID: "1", Name: "Ana", Score: 1, FinishedAt: *mockUser.FinishedAt}, queue.queued[0].Data)
+}
@@ -0,0 +1,264 @@
+// Package webhook notifies the URLs subscribed to the events of the quiz.
+// Deliveries are queued in a WebhookRepository before being sent, and are
+// retried with an exponential backoff until the webhook accepts them.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// The events webhooks can subscribe to.
+const (
+	EventQuizFinished = "quiz.finished"
+)
+
+var Events = []string{EventQuizFinished}
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	MaxAttempts = 8
+	// firstRetry doubles after every failed attempt, up to maxRetry, so the
+	// attempts of a delivery span about an hour.
+	firstRetry = 30 * time.Second
+	maxRetry   = 30 * time.Minute
+	// sendTimeout bounds how long a webhook can take to answer.
+	sendTimeout = 10 * time.Second
+	// responseRead is how much of a failed response is kept as its error.
+	responseRead = 256
+)
+
+// Payload is the JSON body posted to the webhooks.
+type Payload struct {
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Dispatcher queues the events for the webhooks subscribed to them and sends
+// the deliveries that are due, one after the other, in the background.
+type Dispatcher struct {
+	repo    model.WebhookRepository
+	client  *http.Client
+	logger  *slog.Logger
+	now     func() time.Time
+	backoff func(attempt int) time.Duration
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewDispatcher starts sending the deliveries queued in repo, those left
+// pending by a previous run included, until it is closed. Deliveries to
+// hosts that aren't allowed fail to connect.
+func NewDispatcher(repo model.WebhookRepository, hosts Hosts, logger *slog.Logger) *Dispatcher {
+	d := newDispatcher(repo, hosts, logger)
+	go d.run()
+	return d
+}
+
+func newDispatcher(repo model.WebhookRepository, hosts Hosts, logger *slog.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		repo:    repo,
+		client:  hosts.client(),
+		logger:  logger,
+		now:     time.Now,
+		backoff: Backoff,
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+// Backoff is how long to wait after the given failed attempt.
+func Backoff(attempt int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempt && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetry)
+}
+
+// Enqueue queues the event for every webhook subscribed to it. The event is
+// sent soon after, Enqueue doesn't wait for it.
+func (d *Dispatcher) Enqueue(ctx context.Context, event string, data any) error {
+	webhooks, err := d.repo.GetWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	var subscribed []model.Webhook
+	for _, webhook := range webhooks {
+		if slices.Contains(webhook.Events, event) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	now := d.now().UTC()
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %v", event, err)
+	}
+	payload, err := json.Marshal(Payload{Event: event, CreatedAt: now, Data: encoded})
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %v", event, err)
+	}
+	deliveries := make([]model.Delivery, len(subscribed))
+	for i, webhook := range subscribed {
+		deliveries[i] = model.Delivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			CreatedAt:     now,
+			NextAttemptAt: &now,
+		}
+	}
+	if _, err := d.repo.AddDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops sending deliveries. One being sent is left pending, to be sent
+// again by the next dispatcher.
+func (d *Dispatcher) Close() error {
+	d.cancel()
+	<-d.stopped
+	return nil
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.stopped)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+
+		next := d.deliverDue()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(next.Sub(d.now()))
+		}
+	}
+}
+
+// deliverDue sends the pending deliveries that are due, oldest first, and
+// gives when the next one is due, zero when none is pending.
+func (d *Dispatcher) deliverDue() time.Time {
+	deliveries, err := d.repo.GetDeliveries(d.ctx)
+	if err != nil {
+		d.logger.Error("getting webhook deliveries", "error", err)
+		return d.now().Add(firstRetry)
+	}
+	webhooks, err := d.repo.GetWebhooks(d.ctx)
+	if err != nil {
+		d.logger.Error("getting webhooks", "error", err)
+		return d.now().Add(firstRetry)
+	}
+
+	var next time.Time
+	for i := len(deliveries) - 1; i >= 0 && d.ctx.Err() == nil; i-- {
+		delivery := deliveries[i]
+		if delivery.Status != model.DeliveryPending {
+			continue
+		}
+		if delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.After(d.now()) {
+			delivery = d.attempt(delivery, webhooks)
+		}
+		if delivery.Status == model.DeliveryPending && (next.IsZero() || delivery.NextAttemptAt.Before(next)) {
+			next = *delivery.NextAttemptAt
+		}
+	}
+	return next
+}
+
+// attempt sends a delivery and records how it went.
+func (d *Dispatcher) attempt(delivery model.Delivery, webhooks []model.Webhook) model.Delivery {
+	i := slices.IndexFunc(webhooks, func(webhook model.Webhook) bool { return webhook.ID == delivery.WebhookID })
+	now := d.now().UTC()
+	if i < 0 {
+		delivery.Status = model.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = "the webhook was deleted"
+	} else {
+		status, err := d.send(webhooks[i], delivery, now)
+		if d.ctx.Err() != nil {
+			return delivery
+		}
+		delivery.Attempts++
+		delivery.LastAttemptAt = &now
+		delivery.ResponseStatus = status
+		delivery.Error = ""
+		switch {
+		case err == nil:
+			delivery.Status = model.DeliveryDelivered
+			delivery.NextAttemptAt = nil
+		case delivery.Attempts >= MaxAttempts:
+			delivery.Status = model.DeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = err.Error()
+		default:
+			next := now.Add(d.backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+			delivery.Error = err.Error()
+		}
+		d.logger.Debug("webhook delivery attempted", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "status", delivery.Status, "attempts", delivery.Attempts, "error", err)
+	}
+	if err := d.repo.UpdateDelivery(d.ctx, delivery); err != nil {
+		d.logger.Error("saving webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+	return delivery
+}
+
+// send posts the payload of the delivery to the webhook, which must answer
+// with a 2xx status.
+func (d *Dispatcher) send(webhook model.Webhook, delivery model.Delivery, at time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "simple-quiz-webhooks")
+	req.Header.Set("X-Quiz-Event", delivery.Event)
+	req.Header.Set("X-Quiz-Delivery", delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, at, delivery.Payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseRead))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("the webhook answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,256 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRepository keeps webhooks and deliveries in memory.
+type memoryRepository struct {
+	mu         sync.Mutex
+	webhooks   []model.Webhook
+	deliveries []model.Delivery
+}
+
+func (mr *memoryRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	webhook.ID = fmt.Sprint(len(mr.webhooks) + 1)
+	mr.webhooks = append(mr.webhooks, webhook)
+	return &webhook, nil
+}
+
+func (mr *memoryRepository) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	return slices.Clone(mr.webhooks), nil
+}
+
+func (mr *memoryRepository) DeleteWebhook(ctx context.Context, id string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.webhooks = slices.DeleteFunc(mr.webhooks, func(webhook model.Webhook) bool { return webhook.ID == id })
+	return nil
+}
+
+func (mr *memoryRepository) AddDeliveries(ctx context.Context, deliveries []model.Delivery) ([]model.Delivery, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	for i := range deliveries {
+		deliveries[i].ID = fmt.Sprint(len(mr.deliveries) + 1)
+		mr.deliveries = append(mr.deliveries, deliveries[i])
+	}
+	return deliveries, nil
+}
+
+func (mr *memoryRepository) UpdateDelivery(ctx context.Context, delivery model.Delivery) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	for i := range mr.deliveries {
+		if mr.deliveries[i].ID == delivery.ID {
+			mr.deliveries[i] = delivery
+		}
+	}
+	return nil
+}
+
+func (mr *memoryRepository) GetDeliveries(ctx context.Context) ([]model.Delivery, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	deliveries := slices.Clone(mr.deliveries)
+	slices.Reverse(deliveries)
+	return deliveries, nil
+}
+
+func (mr *memoryRepository) delivery(id string) model.Delivery {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	return mr.deliveries[slices.IndexFunc(mr.deliveries, func(delivery model.Delivery) bool { return delivery.ID == id })]
+}
+
+// receiver is a webhook answering with the given statuses, then with 204.
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rc := &receiver{statuses: statuses}
+	rc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.requests = append(rc.requests, r)
+		rc.bodies = append(rc.bodies, body)
+		if len(rc.statuses) > 0 {
+			status := rc.statuses[0]
+			rc.statuses = rc.statuses[1:]
+			http.Error(w, "try later", status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(rc.Close)
+	return rc
+}
+
+func (rc *receiver) received() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+// receiverHosts allows the receivers, which listen on the loopback address.
+var receiverHosts = Hosts{Allowed: []string{"127.0.0.1"}}
+
+func startDispatcher(t *testing.T, repo model.WebhookRepository) *Dispatcher {
+	return startDispatcherTo(t, repo, receiverHosts)
+}
+
+func startDispatcherTo(t *testing.T, repo model.WebhookRepository, hosts Hosts) *Dispatcher {
+	d := newDispatcher(repo, hosts, logging.Discard())
+	d.backoff = func(attempt int) time.Duration { return time.Duration(attempt) * 10 * time.Millisecond }
+	go d.run()
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]any{"user_id": "1", "score": 0.8}
+
+	t.Run("Delivered Signed", func(t *testing.T) {
+		hr, chat := newReceiver(t), newReceiver(t)
+		repo := &memoryRepository{}
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "hr-secret"})
+		repo.CreateWebhook(ctx, model.Webhook{URL: chat.URL, Events: []string{"quiz.other"}, Secret: "chat-secret"})
+		d := startDispatcher(t, repo)
+
+		require.NoError(t, d.Enqueue(ctx, EventQuizFinished, data))
+		require.Eventually(t, func() bool { return repo.delivery("1").Status == model.DeliveryDelivered }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 0, chat.received(), "only the subscribed webhooks are notified")
+
+		req, body := hr.requests[0], hr.bodies[0]
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, EventQuizFinished, req.Header.Get("X-Quiz-Event"))
+		assert.Equal(t, "1", req.Header.Get("X-Quiz-Delivery"))
+		assert.NoError(t, Verify("hr-secret", req.Header.Get(SignatureHeader), body, time.Now(), time.Minute))
+		assert.ErrorIs(t, Verify("chat-secret", req.Header.Get(SignatureHeader), body, time.Now(), time.Minute), ErrBadSignature)
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, EventQuizFinished, payload.Event)
+		assert.JSONEq(t, `{"user_id":"1","score":0.8}`, string(payload.Data))
+
+		delivery := repo.delivery("1")
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+		assert.Nil(t, delivery.NextAttemptAt)
+	})
+
+	t.Run("Delivered After Retries", func(t *testing.T) {
+		hr := newReceiver(t, http.StatusServiceUnavailable, http.StatusInternalServerError)
+		repo := &memoryRepository{}
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "hr-secret"})
+		d := startDispatcher(t, repo)
+
+		require.NoError(t, d.Enqueue(ctx, EventQuizFinished, data))
+		require.Eventually(t, func() bool { return repo.delivery("1").Status == model.DeliveryDelivered }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 3, hr.received())
+		assert.Equal(t, hr.bodies[0], hr.bodies[2], "every attempt sends the same payload")
+		delivery := repo.delivery("1")
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Empty(t, delivery.Error)
+	})
+
+	t.Run("Failed After Max Attempts", func(t *testing.T) {
+		statuses := make([]int, MaxAttempts)
+		for i := range statuses {
+			statuses[i] = http.StatusInternalServerError
+		}
+		hr := newReceiver(t, statuses...)
+		repo := &memoryRepository{}
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "hr-secret"})
+		d := startDispatcher(t, repo)
+
+		require.NoError(t, d.Enqueue(ctx, EventQuizFinished, data))
+		require.Eventually(t, func() bool { return repo.delivery("1").Status == model.DeliveryFailed }, 2*time.Second, 5*time.Millisecond)
+		delivery := repo.delivery("1")
+		assert.Equal(t, MaxAttempts, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+		assert.Equal(t, "the webhook answered 500: try later", delivery.Error)
+		assert.Equal(t, MaxAttempts, hr.received())
+	})
+
+	t.Run("Pending Sent On Start", func(t *testing.T) {
+		hr := newReceiver(t)
+		repo := &memoryRepository{}
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "hr-secret"})
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "deleted"})
+		d := newDispatcher(repo, receiverHosts, logging.Discard())
+		require.NoError(t, d.Enqueue(ctx, EventQuizFinished, data))
+		repo.DeleteWebhook(ctx, "2")
+
+		startDispatcher(t, repo)
+		require.Eventually(t, func() bool { return repo.delivery("1").Status == model.DeliveryDelivered }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, model.DeliveryFailed, repo.delivery("2").Status)
+		assert.Equal(t, "the webhook was deleted", repo.delivery("2").Error)
+	})
+
+	t.Run("Private Host Not Connected To", func(t *testing.T) {
+		hr := newReceiver(t)
+		repo := &memoryRepository{}
+		repo.CreateWebhook(ctx, model.Webhook{URL: hr.URL, Events: []string{EventQuizFinished}, Secret: "hr-secret"})
+		d := startDispatcherTo(t, repo, Hosts{})
+		require.NoError(t, d.Enqueue(ctx, EventQuizFinished, data))
+
+		require.Eventually(t, func() bool { return repo.delivery("1").Attempts > 0 }, time.Second, 5*time.Millisecond)
+		assert.Contains(t, repo.delivery("1").Error, "address 127.0.0.1 is not public")
+		assert.Zero(t, hr.received())
+	})
+}
+
+func TestHostsCheck(t *testing.T) {
+	hosts := Hosts{Allowed: []string{"10.0.0.5", "hr.internal"}}
+	for _, host := range []string{"example.com", "93.184.215.14", "[2606:2800:21f:cb07:6820:80da:af6b:8b2c]", "10.0.0.5", "hr.internal"} {
+		assert.NoError(t, hosts.Check(host), host)
+	}
+	for _, host := range []string{"localhost", "api.localhost", "127.0.0.1", "[::1]", "10.0.0.6", "172.16.0.1", "192.168.1.1", "169.254.169.254", "[fe80::1]", "[fd00::1]", "0.0.0.0", "[::ffff:127.0.0.1]"} {
+		assert.Error(t, hosts.Check(host), host)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 16*time.Minute, Backoff(6))
+	assert.Equal(t, 30*time.Minute, Backoff(7))
+	assert.Equal(t, 30*time.Minute, Backoff(20))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"quiz.finished"}`)
+	now := time.Unix(1700000000, 0)
+	signature := Sign("secret", now, body)
+
+	assert.NoError(t, Verify("secret", signature, body, now.Add(time.Minute), 5*time.Minute))
+	assert.ErrorIs(t, Verify("secret", signature, []byte(`{"event":"other"}`), now, 5*time.Minute), ErrBadSignature)
+	assert.ErrorIs(t, Verify("secret", signature, body, now.Add(10*time.Minute), 5*time.Minute), ErrSignatureAge)
+	assert.ErrorIs(t, Verify("secret", "v1=abc", body, now, 5*time.Minute), ErrNoSignature)
+	assert.ErrorIs(t, Verify("secret", "", body, now, 5*time.Minute), ErrNoSignature)
+}
@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Hosts tells which hosts webhooks can be sent to. Hosts at loopback,
+// link-local, private or other addresses that aren't public are refused,
+// unless allowed, so webhooks can't be used to reach the network of the
+// server.
+type Hosts struct {
+	// Allowed are the host names or addresses notified even though they
+	// aren't public, such as tools on the internal network.
+	Allowed []string
+}
+
+// Check refuses a host that is localhost or an address that isn't public.
+// Host names are only resolved when they are notified.
+func (h Hosts) Check(host string) error {
+	if h.allowed(host) {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(host, "."))
+	if name == "localhost" || strings.HasSuffix(name, ".localhost") {
+		return fmt.Errorf("host %s is not public", host)
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && !public(ip) {
+		return fmt.Errorf("host %s is not public", host)
+	}
+	return nil
+}
+
+func (h Hosts) allowed(host string) bool {
+	return slices.ContainsFunc(h.Allowed, func(allowed string) bool {
+		return strings.EqualFold(strings.Trim(allowed, "[]"), strings.Trim(host, "[]"))
+	})
+}
+
+// client is an HTTP client only connecting to the hosts allowed, checking
+// the addresses the host names resolve to as they are dialed, redirects
+// included.
+func (h Hosts) client() *http.Client {
+	checked := &net.Dialer{
+		Timeout: sendTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !public(ip) {
+				return fmt.Errorf("address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	unchecked := &net.Dialer{Timeout: sendTimeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(address)
+			if err == nil && h.allowed(host) {
+				return unchecked.DialContext(ctx, network, address)
+			}
+			return checked.DialContext(ctx, network, address)
+		},
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   sendTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{Timeout: sendTimeout, Transport: transport}
+}
+
+// public reports whether the address can be reached from the internet.
+func public(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
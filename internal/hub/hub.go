@@ -0,0 +1,132 @@
+// Package hub fans events out to subscribers in memory. Every event gets an
+// increasing id and the latest ones are kept, so a subscriber coming back
+// after a disconnection can pick up where it left off.
+package hub
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Event is a published event, its data already encoded in JSON so it is
+// encoded once whatever the number of subscribers.
+type Event struct {
+	ID   uint64
+	Type string
+	Data json.RawMessage
+}
+
+// Hub is safe for concurrent use. Publishing never waits for subscribers:
+// a subscriber that lets size events pile up is dropped, and is expected to
+// subscribe again from the last event it got.
+type Hub struct {
+	mu          sync.Mutex
+	size        int
+	lastID      uint64
+	history     []Event
+	subscribers map[*Subscription]bool
+}
+
+// New keeps the last size events and lets size events wait for each
+// subscriber.
+func New(size int) *Hub {
+	return &Hub{size: size, subscribers: map[*Subscription]bool{}}
+}
+
+// Publish sends an event to every subscriber.
+func (h *Hub) Publish(eventType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	event := Event{ID: h.lastID, Type: eventType, Data: encoded}
+	h.history = append(h.history, event)
+	if len(h.history) > h.size {
+		h.history = h.history[len(h.history)-h.size:]
+	}
+	for sub := range h.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped = true
+			h.unsubscribe(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts receiving the events published after the one with
+// lastID, 0 to only get the events published from now on. When some of
+// those events are no longer kept, the subscription only gets the newer
+// ones and tells it missed some.
+func (h *Hub) Subscribe(lastID uint64) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub := &Subscription{hub: h, events: make(chan Event, h.size)}
+	h.subscribers[sub] = true
+	if lastID == 0 {
+		return sub
+	}
+
+	// The ids start over when the server restarts, so an id from the future
+	// means the events since are lost too.
+	oldest := h.lastID + 1
+	if len(h.history) > 0 {
+		oldest = h.history[0].ID
+	}
+	sub.missed = lastID > h.lastID || lastID+1 < oldest
+	for _, event := range h.history {
+		if event.ID > lastID {
+			sub.events <- event
+		}
+	}
+	return sub
+}
+
+// Subscribers is the number of current subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	if h.subscribers[sub] {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+type Subscription struct {
+	hub     *Hub
+	events  chan Event
+	missed  bool
+	dropped bool
+}
+
+// Events are closed when the subscription is closed or dropped.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Missed tells whether events published after the requested one were lost.
+func (s *Subscription) Missed() bool {
+	return s.missed
+}
+
+// Dropped tells whether the subscription was closed for being too slow.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.unsubscribe(s)
+}
@@ -0,0 +1,99 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain takes the events waiting for a subscription.
+func drain(sub *Subscription) []Event {
+	var events []Event
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	h := New(3)
+	first := h.Subscribe(0)
+	second := h.Subscribe(0)
+	assert.Equal(t, 2, h.Subscribers())
+
+	require.NoError(t, h.Publish("answered", map[string]int{"answered": 1}))
+	assert.Equal(t, []Event{{ID: 1, Type: "answered", Data: []byte(`{"answered":1}`)}}, drain(first))
+	assert.Len(t, drain(second), 1)
+
+	second.Close()
+	assert.Equal(t, 1, h.Subscribers())
+	_, open := <-second.Events()
+	assert.False(t, open)
+	second.Close()
+
+	assert.Error(t, h.Publish("answered", func() {}))
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	h := New(2)
+	slow := h.Subscribe(0)
+	fast := h.Subscribe(0)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, h.Publish("finished", i))
+		drain(fast)
+	}
+	assert.False(t, slow.Dropped())
+
+	// A third event doesn't fit the buffer of the slow subscriber, which is
+	// dropped without holding the others up.
+	require.NoError(t, h.Publish("finished", 2))
+	assert.True(t, slow.Dropped())
+	assert.Len(t, drain(slow), 2)
+	assert.Equal(t, []Event{{ID: 3, Type: "finished", Data: []byte("2")}}, drain(fast))
+	assert.Equal(t, 1, h.Subscribers())
+}
+
+func TestHubResume(t *testing.T) {
+	h := New(2)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, h.Publish("answered", i))
+	}
+
+	sub := h.Subscribe(2)
+	assert.False(t, sub.Missed())
+	assert.Equal(t, []uint64{3}, ids(drain(sub)))
+
+	sub = h.Subscribe(1)
+	assert.False(t, sub.Missed())
+	assert.Equal(t, []uint64{2, 3}, ids(drain(sub)))
+	sub = h.Subscribe(3)
+	assert.False(t, sub.Missed())
+	assert.Empty(t, drain(sub))
+
+	// The second event is no longer kept once the fourth is published.
+	require.NoError(t, h.Publish("answered", 4))
+	sub = h.Subscribe(1)
+	assert.True(t, sub.Missed())
+	assert.Equal(t, []uint64{3, 4}, ids(drain(sub)))
+
+	// Ids from before a restart are ahead of the hub.
+	sub = h.Subscribe(10)
+	assert.True(t, sub.Missed())
+	assert.Empty(t, drain(sub))
+}
+
+func ids(events []Event) []uint64 {
+	var ids []uint64
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
@@ -2,31 +2,44 @@ package api
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 
+	"github.com/MFCaballero/simple-quiz/internal/domain/backup"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
 	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 type App struct {
 	wait          *sync.WaitGroup
-	logger        *log.Logger
+	logger        *slog.Logger
 	services      usecase.Services
+	metrics       *metrics.Registry
+	limits        ratelimit.Limits
+	adminToken    string
+	port          int
 	errorChan     chan error
 	errorChanDone chan bool
 }
 
-func NewApp(logger *log.Logger, wg *sync.WaitGroup, services usecase.Services) App {
+func NewApp(logger *slog.Logger, wg *sync.WaitGroup, services usecase.Services, registry *metrics.Registry, limits ratelimit.Limits, adminToken string, port int) App {
 	return App{
 		wait:          wg,
 		logger:        logger,
 		services:      services,
+		metrics:       registry,
+		limits:        limits,
+		adminToken:    adminToken,
+		port:          port,
 		errorChan:     make(chan error),
 		errorChanDone: make(chan bool),
 	}
@@ -36,18 +49,25 @@ func (app *App) ListenForErrors() {
 	for {
 		select {
 		case err := <-app.errorChan:
-			app.logger.Printf("error: %v", err)
+			app.logger.Error("application error", "error", err)
 		case <-app.errorChanDone:
 			return
 		}
 	}
 }
 
-func (app *App) ListenForShutdown() {
+// ListenForShutdown shuts the application down on SIGINT or SIGTERM,
+// closing the repositories last so they are left consistent before exiting.
+func (app *App) ListenForShutdown(repositories ...io.Closer) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	app.shutdown()
+	for _, repository := range repositories {
+		if err := repository.Close(); err != nil {
+			app.logger.Error("closing repository", "error", err)
+		}
+	}
 	os.Exit(0)
 }
 
@@ -57,42 +77,395 @@ func (app *App) shutdown() {
 
 	app.errorChanDone <- true
 
-	app.logger.Println("closing channels and shutting down application...")
+	if err := app.services.Close(); err != nil {
+		app.logger.Error("closing services", "error", err)
+	}
+
+	app.logger.Info("closing channels and shutting down application")
 
 	close(app.errorChan)
 	close(app.errorChanDone)
 }
 
 func (app *App) Run() {
-	port := 8080 //this should be in config
-	addr := fmt.Sprintf(":%d", port)
+	addr := fmt.Sprintf(":%d", app.port)
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: app.routes(),
 	}
 
-	app.logger.Printf("starting web server on port %d", port)
+	app.logger.Info("starting web server", "port", app.port)
 	err := srv.ListenAndServe()
 	if err != nil {
-		log.Panic(err)
+		app.logger.Error("web server stopped", "error", err)
+		panic(err)
 	}
 }
 
 func (app *App) routes() http.Handler {
 	mux := chi.NewRouter()
+	mux.Use(middleware.RequestID)
+	mux.Use(app.logRequests)
+	mux.Use(newRequestMetrics(app.metrics).middleware)
 	mux.Use(middleware.Recoverer)
 
-	mux.Route("/questions", func(r chi.Router) {
-		r.Get("/", app.services.QuestionService.GetAllQuestions)
-		r.Get("/{question}", app.services.QuestionService.GetQuestion)
-	})
-	mux.Route("/users", func(r chi.Router) {
-		r.Post("/login", app.services.UserService.Login)
-		r.Get("/{user}/answered", app.services.UserService.GetAnswered)
-		r.Get("/{user}/score", app.services.UserService.GetScoreData)
-		r.Post("/{user}/answer", app.services.UserService.AnswerQuestion)
-		r.Post("/{user}/finish", app.services.UserService.PostAnswers)
+	endpoints := app.endpoints()
+	for _, e := range endpoints {
+		mux.With(e.Middlewares...).Method(e.Method, e.Path, e.Handler)
+	}
+	spec, err := openAPI(endpoints)
+	if err != nil {
+		panic(err)
+	}
+	mux.Get("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
 	})
 
 	return mux
 }
+
+// endpoints lists every route served, with what the OpenAPI document says
+// about them. A route is only served once it is described here. The /admin
+// routes require the admin token.
+func (app *App) endpoints() []endpoint {
+	endpoints := versioned(app.adminOnly(app.apiEndpoints()), true)
+	for _, group := range [][]endpoint{app.realtimeEndpoints(), app.webhookEndpoints(), app.userListEndpoints()} {
+		endpoints = append(endpoints, versioned(app.adminOnly(group), false)...)
+	}
+	return append(endpoints, app.operationsEndpoints()...)
+}
+
+// apiEndpoints are the routes of the quiz served before the API was
+// versioned, as served by /v1. They are also served by /v2 and, deprecated,
+// without a version.
+func (app *App) apiEndpoints() []endpoint {
+	services := app.services
+	notFound := errorResponse(http.StatusNotFound, "The user does not exist")
+	serverError := errorResponse(http.StatusInternalServerError, "The data could not be read or written")
+	dryRun := queryParam{Name: "dry_run", Type: "boolean", Description: "Report what would change without changing anything"}
+	badList := errorResponse(http.StatusBadRequest, "A query parameter or the cursor is invalid")
+	limited := errorResponse(http.StatusTooManyRequests, "Too many requests, the Retry-After header tells how many seconds to wait")
+
+	return []endpoint{
+		{
+			Method: http.MethodGet, Path: "/questions", Handler: http.HandlerFunc(services.QuestionService.GetAllQuestions),
+			Tag: "questions", Summary: "List the questions, by id, without their answers",
+			Responses: []response{{Status: http.StatusOK, Description: "The questions", Body: jsonBody(map[string]usecase.QuestionDTO{})}, serverError},
+			V2: &endpoint{
+				Handler: http.HandlerFunc(services.QuestionService.ListQuestions),
+				Tag:     "questions", Summary: "List a page of the questions without their answers",
+				Query: listQuery(model.QuestionSortKeys.Fields(), "position", queryParam{Name: "label", Description: "Only list the questions whose label contains this text"}),
+				Responses: []response{
+					{Status: http.StatusOK, Description: "A page of the questions", Body: jsonBody(usecase.QuestionPage{})},
+					badList,
+					serverError,
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/questions/{question}", Handler: http.HandlerFunc(services.QuestionService.GetQuestion),
+			Tag: "questions", Summary: "Get a question without its answer",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The question", Body: jsonBody(usecase.QuestionDTO{})},
+				errorResponse(http.StatusNotFound, "The question does not exist"),
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/login", Handler: http.HandlerFunc(services.UserService.Login),
+			Tag: "quiz", Summary: "Start the quiz as a new quizzer",
+			Description: "Logins are rate limited by client address, which is locked out for a while after too many rejected logins.",
+			Request:     jsonBody(usecase.LoginRequest{}),
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "The quizzer was created", Body: jsonBody(usecase.LoginResponse{})},
+				errorResponse(http.StatusBadRequest, "The body is not a login request"),
+				limited,
+				serverError,
+			},
+			Middlewares: []func(http.Handler) http.Handler{app.lockOutLogins, app.limitByIP},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/{user}/answered", Handler: http.HandlerFunc(services.UserService.GetAnswered),
+			Tag: "quiz", Summary: "List the answers given so far, in question order",
+			Responses: []response{{Status: http.StatusOK, Description: "The answers", Body: jsonBody([]usecase.Answer{})}, notFound, serverError},
+			V2: &endpoint{
+				Handler: http.HandlerFunc(services.UserService.ListAnswered),
+				Tag:     "quiz", Summary: "List a page of the answers given so far, in question order",
+				Query: listQuery(usecase.AnswerSortKeys.Fields(), "position", queryParam{Name: "question", Description: "Only list the answers whose question contains this text"}),
+				Responses: []response{
+					{Status: http.StatusOK, Description: "A page of the answers", Body: jsonBody(usecase.AnswerPage{})},
+					badList,
+					notFound,
+					serverError,
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/{user}/score", Handler: http.HandlerFunc(services.UserService.GetScoreData),
+			Tag: "quiz", Summary: "Get the score of a finished quiz compared to the other quizzers",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The score", Body: jsonBody(usecase.ScoreData{})},
+				errorResponse(http.StatusForbidden, "The quizzer has not finished the quiz"),
+				notFound,
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/{user}/answer", Handler: http.HandlerFunc(services.UserService.AnswerQuestion),
+			Tag: "quiz", Summary: "Answer a question, replacing any previous answer to it",
+			Description: "Answers are rate limited by client address and by quizzer. Changing an answer can be limited or disabled by the server, answering again with the same option is always accepted. The response is the same whether the option is correct or not.",
+			Request:     jsonBody(usecase.AnswerRequest{}),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The answer was saved"},
+				errorResponse(http.StatusBadRequest, "The question or the option does not exist"),
+				errorResponse(http.StatusForbidden, "The quizzer has already finished the quiz, or can't change this answer anymore"),
+				notFound,
+				errorResponse(http.StatusConflict, "The quizzer kept being changed by other requests, the answer can be posted again"),
+				limited,
+				serverError,
+			},
+			Middlewares: []func(http.Handler) http.Handler{app.limitByIP, app.limitByUser},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/{user}/finish", Handler: http.HandlerFunc(services.UserService.PostAnswers),
+			Tag: "quiz", Summary: "Finish the quiz, after which answers can't be changed",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The quiz was finished", Body: textBody()},
+				errorResponse(http.StatusForbidden, "Some questions are not answered yet, or the quiz was already finished"),
+				notFound,
+				errorResponse(http.StatusConflict, "The quizzer kept being changed by other requests, the request can be sent again"),
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/leaderboard", Handler: http.HandlerFunc(services.UserService.GetLeaderboard),
+			Tag: "quiz", Summary: "Rank the quizzers that finished by score",
+			Query: []queryParam{
+				{Name: "limit", Type: "integer", Description: "Number of positions to return, from 1 to 100, 10 by default"},
+				{Name: "offset", Type: "integer", Description: "Number of positions to skip"},
+				{Name: "user", Description: "Also return the position of this quizzer"},
+				{Name: "from", Description: "Only rank quizzers that finished at or after this RFC3339 time"},
+				{Name: "to", Description: "Only rank quizzers that finished at or before this RFC3339 time"},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "A page of the ranking", Body: jsonBody(usecase.LeaderboardPage{})},
+				errorResponse(http.StatusBadRequest, "A query parameter is invalid"),
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/stats", Handler: http.HandlerFunc(services.AdminService.GetQuestionStats),
+			Tag: "admin", Summary: "Analyze how each question was answered by the quizzers that finished",
+			Responses: []response{{Status: http.StatusOK, Description: "The item analysis", Body: jsonBody(usecase.ItemAnalysis{})}, serverError},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/results", Handler: http.HandlerFunc(services.AdminService.ExportResults),
+			Tag: "admin", Summary: "Export one row per quizzer",
+			Query: []queryParam{{Name: "format", Enum: []string{"csv", "jsonl"}, Description: "csv by default"}},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The results, as CSV or as JSON lines of result rows", Body: &body{ContentType: "text/csv"}},
+				errorResponse(http.StatusBadRequest, "The format is not supported"),
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/questions/import", Handler: http.HandlerFunc(services.AdminService.ImportQuestions),
+			Tag: "admin", Summary: "Import questions from a CSV, YAML or GIFT file",
+			Query: []queryParam{
+				{Name: "format", Enum: []string{"csv", "yaml", "gift"}},
+				{Name: "replace", Type: "boolean", Description: "Replace the questions instead of adding to them"},
+				dryRun,
+			},
+			Request: &body{ContentType: "application/octet-stream"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The questions that would be imported, on a dry run", Body: jsonBody(usecase.ImportReport{})},
+				{Status: http.StatusCreated, Description: "The questions were imported", Body: jsonBody(usecase.ImportReport{})},
+				errorResponse(http.StatusBadRequest, "The format is not supported or the file can't be read"),
+				{Status: http.StatusUnprocessableEntity, Description: "The file or the resulting questions have errors", Body: jsonBody(usecase.ImportReport{})},
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/users/{user}/timeline", Handler: http.HandlerFunc(services.AdminService.GetUserTimeline),
+			Tag: "admin", Summary: "Replay the events of a quizzer",
+			Responses: []response{{Status: http.StatusOK, Description: "The timeline", Body: jsonBody(usecase.Timeline{})}, notFound, serverError},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/backup", Handler: http.HandlerFunc(services.AdminService.Backup),
+			Tag: "admin", Summary: "Download a backup of all the data",
+			Responses: []response{{Status: http.StatusOK, Description: "A gzipped tar archive", Body: &body{ContentType: "application/gzip"}}, serverError},
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/restore", Handler: http.HandlerFunc(services.AdminService.Restore),
+			Tag: "admin", Summary: "Replace all the data with a backup",
+			Query:   []queryParam{dryRun},
+			Request: &body{ContentType: "application/gzip"},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "What was, or would be, changed", Body: jsonBody(backup.Report{})},
+				errorResponse(http.StatusBadRequest, "The archive can't be read"),
+				{Status: http.StatusUnprocessableEntity, Description: "The backup has errors", Body: jsonBody(backup.Report{})},
+				serverError,
+			},
+		},
+	}
+}
+
+// realtimeEndpoints are the routes keeping the connection open to push
+// what happens as it happens. The messages of the live quiz rooms are
+// described in the room package.
+func (app *App) realtimeEndpoints() []endpoint {
+	services := app.services
+	upgraded := response{Status: http.StatusSwitchingProtocols, Description: "The connection was upgraded to a WebSocket"}
+	return []endpoint{
+		{
+			Method: http.MethodGet, Path: "/rooms/host", Handler: http.HandlerFunc(services.RoomService.HostRoom),
+			Tag: "rooms", Summary: "Open a live quiz room and host it over a WebSocket",
+			Description: "The first message gives the code players join with. The host sends next to ask each question and to reveal its results early.",
+			Query:       []queryParam{{Name: "seconds", Type: "integer", Description: "Time to answer each question, from 5 to 120, 20 by default"}},
+			Responses: []response{
+				upgraded,
+				errorResponse(http.StatusBadRequest, "The seconds are invalid"),
+				errorResponse(http.StatusConflict, "There are no questions"),
+				errorResponse(http.StatusInternalServerError, "The questions could not be read"),
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/rooms/{code}/join", Handler: http.HandlerFunc(services.RoomService.JoinRoom),
+			Tag: "rooms", Summary: "Join a live quiz room and play over a WebSocket",
+			Description: "Players send answer with an option id to answer the question asked. The faster a right answer, the more points it scores.",
+			Query:       []queryParam{{Name: "name", Description: "The name shown to the room, unique in it"}},
+			Responses: []response{
+				upgraded,
+				errorResponse(http.StatusBadRequest, "The name is missing or too long"),
+				errorResponse(http.StatusNotFound, "The room does not exist"),
+				errorResponse(http.StatusConflict, "The name is taken, the room is full or the quiz has started"),
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/live", Handler: http.HandlerFunc(services.LiveService.Stream),
+			Tag: "admin", Summary: "Follow the answers, finishes and ranking moves as Server-Sent Events",
+			Description: "Events are answered, finished and leaderboard, with JSON data. A reset event tells some events were lost while reconnecting.",
+			Query:       []queryParam{{Name: "last_event_id", Type: "integer", Description: "Resume after this event, as the Last-Event-ID header does"}},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The stream of events", Body: &body{ContentType: "text/event-stream"}},
+				errorResponse(http.StatusBadRequest, "The last event id is not a number"),
+			},
+		},
+	}
+}
+
+// webhookEndpoints manage the URLs notified of the events of the quiz and
+// report how notifying them went.
+func (app *App) webhookEndpoints() []endpoint {
+	services := app.services
+	serverError := errorResponse(http.StatusInternalServerError, "The webhooks could not be read or written")
+	return []endpoint{
+		{
+			Method: http.MethodPost, Path: "/admin/webhooks", Handler: http.HandlerFunc(services.WebhookService.CreateWebhook),
+			Tag: "admin", Summary: "Subscribe a URL to events of the quiz",
+			Description: "Every event is posted as JSON, signed with the secret in the X-Quiz-Signature header. The secret is only returned here.",
+			Request:     jsonBody(usecase.WebhookRequest{}),
+			Responses: []response{
+				{Status: http.StatusCreated, Description: "The webhook was created", Body: jsonBody(usecase.WebhookDTO{})},
+				errorResponse(http.StatusBadRequest, "The URL or the events are invalid, or the host of the URL is not public"),
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/webhooks", Handler: http.HandlerFunc(services.WebhookService.GetWebhooks),
+			Tag: "admin", Summary: "List the webhooks, without their secrets",
+			Responses: []response{{Status: http.StatusOK, Description: "The webhooks", Body: jsonBody([]usecase.WebhookDTO{})}, serverError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/webhooks/{webhook}", Handler: http.HandlerFunc(services.WebhookService.DeleteWebhook),
+			Tag: "admin", Summary: "Remove a webhook, failing its pending deliveries",
+			Responses: []response{
+				{Status: http.StatusNoContent, Description: "The webhook was removed"},
+				errorResponse(http.StatusNotFound, "The webhook does not exist"),
+				serverError,
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/webhooks/deliveries", Handler: http.HandlerFunc(services.WebhookService.GetDeliveries),
+			Tag: "admin", Summary: "List the deliveries to the webhooks, newest first",
+			Query: []queryParam{
+				{Name: "webhook", Description: "Only list the deliveries to this webhook"},
+				{Name: "status", Enum: []string{"pending", "delivered", "failed"}},
+				{Name: "limit", Type: "integer", Description: "Number of deliveries to return, from 1 to 1000, 50 by default"},
+			},
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The deliveries", Body: jsonBody([]model.Delivery{})},
+				errorResponse(http.StatusBadRequest, "A query parameter is invalid"),
+				serverError,
+			},
+		},
+	}
+}
+
+// userListEndpoints are the routes listing the quizzers to administrators.
+func (app *App) userListEndpoints() []endpoint {
+	services := app.services
+	return []endpoint{
+		{
+			Method: http.MethodGet, Path: "/admin/users", Handler: http.HandlerFunc(services.AdminService.ListUsers),
+			Tag: "admin", Summary: "List a page of the quizzers with their results",
+			Query: listQuery(model.UserSortKeys.Fields(), "id",
+				queryParam{Name: "name", Description: "Only list the quizzers whose name contains this text"},
+				queryParam{Name: "finished", Type: "boolean", Description: "Only list the quizzers that finished the quiz, or those that didn't"},
+			),
+			Responses: []response{
+				{Status: http.StatusOK, Description: "A page of the quizzers", Body: jsonBody(usecase.UserPage{})},
+				errorResponse(http.StatusBadRequest, "A query parameter or the cursor is invalid"),
+				errorResponse(http.StatusInternalServerError, "The users could not be read"),
+			},
+		},
+	}
+}
+
+// listQuery is the query of a listing sorted by the given fields, or by
+// defaultSort when not sorted, followed by its filters.
+func listQuery(sortFields []string, defaultSort string, filters ...queryParam) []queryParam {
+	sorts := []string{}
+	for _, field := range sortFields {
+		sorts = append(sorts, field, "-"+field)
+	}
+	return append([]queryParam{
+		{Name: "limit", Type: "integer", Description: "Number of items to return, from 1 to 1000, 50 by default"},
+		{Name: "cursor", Description: "The next_cursor of the previous page, to get the next one"},
+		{Name: "sort", Enum: sorts, Description: "Field to sort by, then by id, prefixed with - for descending order. By " + defaultSort + " when not set"},
+	}, filters...)
+}
+
+// operationsEndpoints are the routes used to operate the server. Probes and
+// scrapers are configured once, so these are not versioned.
+func (app *App) operationsEndpoints() []endpoint {
+	services := app.services
+	return []endpoint{
+		{
+			Method: http.MethodGet, Path: "/metrics", Handler: app.metrics,
+			Tag: "operations", Summary: "Metrics in the Prometheus text format",
+			Responses: []response{{Status: http.StatusOK, Description: "The metrics", Body: textBody()}},
+		},
+		{
+			Method: http.MethodGet, Path: "/healthz", Handler: http.HandlerFunc(services.HealthService.Healthz),
+			Tag: "operations", Summary: "Liveness probe",
+			Responses: []response{{Status: http.StatusOK, Description: "The server is running", Body: textBody()}},
+		},
+		{
+			Method: http.MethodGet, Path: "/readyz", Handler: http.HandlerFunc(services.HealthService.Readyz),
+			Tag: "operations", Summary: "Readiness probe, checking the data can be read",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "Every check passed", Body: jsonBody(usecase.Readiness{})},
+				{Status: http.StatusServiceUnavailable, Description: "Some checks failed", Body: jsonBody(usecase.Readiness{})},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/version", Handler: http.HandlerFunc(services.HealthService.Version),
+			Tag: "operations", Summary: "The build of the server",
+			Responses: []response{
+				{Status: http.StatusOK, Description: "The build information", Body: jsonBody(usecase.Version{})},
+				errorResponse(http.StatusNotFound, "The binary has no build information"),
+			},
+		},
+	}
+}
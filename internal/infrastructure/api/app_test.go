@@ -0,0 +1,463 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/repository"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConcurrentAnswers answers every question of the quiz several times at
+// once for the same user, as terminals sharing a session would, and checks
+// that no acknowledged answer is lost.
+func TestConcurrentAnswers(t *testing.T) {
+	const (
+		questionCount = 30
+		rounds        = 3
+	)
+	logger := logging.Discard()
+
+	storages := map[string]func(t *testing.T, dataDir string) model.UserRepository{
+		"JSON": func(t *testing.T, dataDir string) model.UserRepository {
+			return repository.NewUserRepository(logger, dataDir)
+		},
+		"Memory": func(t *testing.T, dataDir string) model.UserRepository {
+			users, err := repository.NewMemoryUserRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			t.Cleanup(func() { users.Close() })
+			return users
+		},
+	}
+	for name, openUsers := range storages {
+		t.Run(name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			writeQuestions(t, dataDir, questionCount)
+			users := openUsers(t, dataDir)
+			server := newTestServer(t, logger, dataDir, users, metrics.NewRegistry())
+			userID := login(t, server.URL, "Ana")
+
+			var (
+				wg           sync.WaitGroup
+				mu           sync.Mutex
+				acknowledged = map[string]bool{}
+				updates      int
+				start        = make(chan struct{})
+			)
+			for round := 0; round < rounds; round++ {
+				for i := 1; i <= questionCount; i++ {
+					wg.Add(1)
+					go func(questionID, optionID string) {
+						defer wg.Done()
+						<-start
+						body := fmt.Sprintf(`{"question_id": %q, "option_id": %q}`, questionID, optionID)
+						resp, err := http.Post(fmt.Sprintf("%s/users/%s/answer", server.URL, userID), "application/json", bytes.NewBufferString(body))
+						if !assert.NoError(t, err) {
+							return
+						}
+						defer resp.Body.Close()
+						assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, resp.StatusCode)
+						if resp.StatusCode == http.StatusOK {
+							mu.Lock()
+							acknowledged[questionID] = true
+							updates++
+							mu.Unlock()
+						}
+					}(fmt.Sprint(i), []string{"A", "B"}[round%2])
+				}
+			}
+			close(start)
+			wg.Wait()
+
+			user, err := users.GetUser(context.Background(), userID)
+			require.NoError(t, err)
+			stored := map[string]bool{}
+			for _, answer := range user.Answers {
+				stored[answer.QuestionID] = true
+			}
+			assert.Equal(t, acknowledged, stored)
+			assert.Equal(t, updates, user.Version, "every acknowledged answer must be one update of the user")
+			assert.NotEmpty(t, acknowledged)
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 2)
+	registry := metrics.NewRegistry()
+	instrumentation := repository.NewInstrumentation(registry)
+	server := newTestServer(t, logger, dataDir, instrumentation.Users(repository.NewUserRepository(logger, dataDir)), registry)
+
+	userID := login(t, server.URL, "Ana")
+	login(t, server.URL, "Bob")
+	for _, body := range []string{`{"question_id": "1", "option_id": "A"}`, `{"question_id": "2", "option_id": "B"}`} {
+		resp, err := http.Post(fmt.Sprintf("%s/users/%s/answer", server.URL, userID), "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	resp, err := http.Post(fmt.Sprintf("%s/users/%s/finish", server.URL, userID), "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/users/unknown/answered")
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/nowhere")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	exposition := string(content)
+
+	for _, line := range []string{
+		`quiz_http_requests_total{method="POST",route="/users/login",status="201"} 2`,
+		`quiz_http_requests_total{method="POST",route="/users/{user}/answer",status="200"} 2`,
+		`quiz_http_requests_total{method="POST",route="/users/{user}/finish",status="200"} 1`,
+		`quiz_http_requests_total{method="GET",route="/users/{user}/answered",status="404"} 1`,
+		`quiz_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`quiz_http_request_duration_seconds_count{method="POST",route="/users/{user}/answer"} 2`,
+		`quiz_repository_operation_duration_seconds_count{repository="users",operation="update_user"} 3`,
+		"quiz_active_users 1",
+		"quiz_answers_submitted_total 2",
+		"quiz_quizzes_finished_total 1",
+		`quiz_scores_bucket{le="0.4"} 0`,
+		`quiz_scores_bucket{le="0.5"} 1`,
+		"quiz_scores_sum 0.5",
+	} {
+		assert.Contains(t, exposition, line+"\n")
+	}
+
+	// Quizzers that log in are counted without reading the users again.
+	readAllUsers := regexp.MustCompile(`quiz_repository_operation_duration_seconds_count\{repository="users",operation="get_all_users"\} \d+`)
+	scraped := readAllUsers.FindString(exposition)
+	require.NotEmpty(t, scraped)
+	login(t, server.URL, "Cleo")
+	resp, err = http.Get(server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	content, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "quiz_active_users 2\n")
+	assert.Equal(t, scraped, readAllUsers.FindString(string(content)))
+}
+
+// writeQuestions writes a questions file with count yes or no questions,
+// the right answer always being A.
+func writeQuestions(t *testing.T, dataDir string, count int) {
+	questions := model.QuestionMap{}
+	for i := 1; i <= count; i++ {
+		questions[fmt.Sprint(i)] = model.Question{
+			Label:   fmt.Sprintf("Question %d?", i),
+			Options: []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}},
+		}
+	}
+	content, err := json.Marshal(questions)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "questions.json"), content, 0644))
+}
+
+// newTestServer serves the quiz from dataDir, storing users in users.
+func newTestServer(t *testing.T, logger *slog.Logger, dataDir string, users model.UserRepository, registry *metrics.Registry) *httptest.Server {
+	return newLimitedTestServer(t, logger, dataDir, users, registry, ratelimit.Limits{})
+}
+
+// newLimitedTestServer is newTestServer applying limits to the clients.
+func newLimitedTestServer(t *testing.T, logger *slog.Logger, dataDir string, users model.UserRepository, registry *metrics.Registry, limits ratelimit.Limits) *httptest.Server {
+	questionRepo, err := repository.NewMemoryQuestionRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { questionRepo.Close() })
+	services := usecase.LoadServices(users, questionRepo, repository.NewEventRepository(logger, dataDir), nil, repository.NewWebhookRepository(logger, dataDir), webhook.Hosts{Allowed: []string{"127.0.0.1"}}, registry, logger)
+	t.Cleanup(func() { services.Close() })
+	app := NewApp(logger, &sync.WaitGroup{}, services, registry, limits, testAdminToken, 0)
+	server := httptest.NewServer(app.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testAdminToken is the admin token of the test servers.
+const testAdminToken = "admin-token"
+
+// asAdmin sends a request to an /admin route with the admin token.
+func asAdmin(t *testing.T, method, url string, body io.Reader) *http.Response {
+	req, err := http.NewRequest(method, url, body)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func login(t *testing.T, serverURL, name string) string {
+	resp, err := http.Post(serverURL+"/users/login", "application/json", bytes.NewBufferString(fmt.Sprintf(`{"name": %q}`, name)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var login struct {
+		UserID string `json:"user_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&login))
+	return login.UserID
+}
+
+// TestPagination walks through the pages of the /v2 listings, on both user
+// storages, and checks /v1 keeps answering with whole lists.
+func TestPagination(t *testing.T) {
+	logger := logging.Discard()
+	storages := map[string]func(t *testing.T, dataDir string) model.UserRepository{
+		"JSON": func(t *testing.T, dataDir string) model.UserRepository {
+			return repository.NewUserRepository(logger, dataDir)
+		},
+		"Memory": func(t *testing.T, dataDir string) model.UserRepository {
+			users, err := repository.NewMemoryUserRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			t.Cleanup(func() { users.Close() })
+			return users
+		},
+	}
+	for name, openUsers := range storages {
+		t.Run(name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			writeQuestions(t, dataDir, 11)
+			server := newTestServer(t, logger, dataDir, openUsers(t, dataDir), metrics.NewRegistry())
+			for _, name := range []string{"Ana", "Bruno", "Anabel"} {
+				login(t, server.URL, name)
+			}
+
+			// walk gets every page of a listing, giving the ids listed.
+			walk := func(path, list string) []string {
+				var ids []string
+				cursor := ""
+				for pages := 0; pages < 10; pages++ {
+					resp := asAdmin(t, http.MethodGet, server.URL+path+"&cursor="+cursor, nil)
+					var page map[string]json.RawMessage
+					require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+					resp.Body.Close()
+					require.Equal(t, http.StatusOK, resp.StatusCode)
+
+					var items []struct {
+						ID string `json:"id"`
+					}
+					require.NoError(t, json.Unmarshal(page[list], &items))
+					for _, item := range items {
+						ids = append(ids, item.ID)
+					}
+					if page["next_cursor"] == nil {
+						return ids
+					}
+					require.NoError(t, json.Unmarshal(page["next_cursor"], &cursor))
+				}
+				t.Fatalf("%s has too many pages", path)
+				return nil
+			}
+
+			assert.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}, walk("/v2/questions?limit=4", "questions"))
+			assert.Equal(t, []string{"1", "10", "11"}, walk("/v2/questions?limit=2&label=1", "questions"))
+			assert.Equal(t, []string{"3", "1"}, walk("/v1/admin/users?limit=1&sort=-name&name=an", "users"))
+
+			resp, err := http.Get(server.URL + "/v1/questions")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var questions map[string]usecase.QuestionDTO
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&questions))
+			assert.Len(t, questions, 11)
+		})
+	}
+}
+
+// TestWebhooks subscribes a receiver to finished quizzes and checks it gets
+// a signed payload, and that its failure shows in the delivery log.
+func TestWebhooks(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 1)
+	server := newTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry())
+
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	resp := asAdmin(t, http.MethodPost, server.URL+"/v1/admin/webhooks", bytes.NewBufferString(fmt.Sprintf(`{"url": %q, "events": ["quiz.finished"], "secret": "s3cret"}`, receiver.URL)))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	userID := login(t, server.URL, "Ana")
+	resp, err := http.Post(fmt.Sprintf("%s/users/%s/answer", server.URL, userID), "application/json", bytes.NewBufferString(`{"question_id": "1", "option_id": "A"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Post(fmt.Sprintf("%s/users/%s/finish", server.URL, userID), "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Post(fmt.Sprintf("%s/users/%s/finish", server.URL, userID), "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode, "finishing again notifies nobody")
+
+	req, body := <-received, <-bodies
+	assert.Equal(t, "quiz.finished", req.Header.Get("X-Quiz-Event"))
+	assert.NoError(t, webhook.Verify("s3cret", req.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute))
+	var payload struct {
+		Event string                  `json:"event"`
+		Data  usecase.FinishedWebhook `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "quiz.finished", payload.Event)
+	assert.Equal(t, userID, payload.Data.UserID)
+	assert.Equal(t, float32(1), payload.Data.Score)
+
+	var deliveries []model.Delivery
+	require.Eventually(t, func() bool {
+		resp := asAdmin(t, http.MethodGet, server.URL+"/v1/admin/webhooks/deliveries", nil)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&deliveries))
+		return len(deliveries) == 1 && deliveries[0].Attempts == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, model.DeliveryPending, deliveries[0].Status, "the delivery is retried later")
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].ResponseStatus)
+	assert.Equal(t, "the webhook answered 503: busy", deliveries[0].Error)
+	assert.NotNil(t, deliveries[0].NextAttemptAt)
+}
+
+// TestRateLimits checks the limits of the logins and answers. The rates are
+// so low that no call is allowed again during the test.
+func TestRateLimits(t *testing.T) {
+	logger := logging.Discard()
+	newServer := func(t *testing.T, limits ratelimit.Limits) string {
+		dataDir := t.TempDir()
+		writeQuestions(t, dataDir, 2)
+		return newLimitedTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry(), limits).URL
+	}
+	post := func(t *testing.T, url, body string) *http.Response {
+		resp, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("By Client Address", func(t *testing.T) {
+		serverURL := newServer(t, ratelimit.Limits{IP: ratelimit.NewLimiter(0.001, 2)})
+		userID := login(t, serverURL, "Ana")
+		assert.Equal(t, http.StatusOK, post(t, serverURL+"/v1/users/"+userID+"/answer", `{"question_id": "1", "option_id": "A"}`).StatusCode)
+
+		resp := post(t, serverURL+"/v2/users/login", `{"name": "Bruno"}`)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "logins and answers share the limit")
+		assert.Equal(t, "1000", resp.Header.Get("Retry-After"))
+		var envelope ErrorEnvelope
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+		assert.Equal(t, ErrorBody{Status: http.StatusTooManyRequests, Code: "too_many_requests", Message: "Too many requests, try again in 1000 seconds"}, envelope.Error)
+
+		resp, err := http.Get(serverURL + "/v1/questions")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "reading the questions is not limited")
+	})
+
+	t.Run("By Quizzer", func(t *testing.T) {
+		serverURL := newServer(t, ratelimit.Limits{User: ratelimit.NewLimiter(0.001, 1)})
+		ana, bruno := login(t, serverURL, "Ana"), login(t, serverURL, "Bruno")
+		answer := `{"question_id": "1", "option_id": "B"}`
+
+		assert.Equal(t, http.StatusOK, post(t, serverURL+"/v1/users/"+ana+"/answer", answer).StatusCode)
+		assert.Equal(t, http.StatusTooManyRequests, post(t, serverURL+"/v1/users/"+ana+"/answer", answer).StatusCode)
+		assert.Equal(t, http.StatusOK, post(t, serverURL+"/v1/users/"+bruno+"/answer", answer).StatusCode)
+	})
+
+	t.Run("Login Lockout", func(t *testing.T) {
+		serverURL := newServer(t, ratelimit.Limits{Login: ratelimit.NewLockout(3, time.Minute)})
+
+		assert.Equal(t, http.StatusBadRequest, post(t, serverURL+"/v1/users/login", "not json").StatusCode)
+		assert.Equal(t, http.StatusCreated, post(t, serverURL+"/v1/users/login", `{"name": "Ana"}`).StatusCode)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusBadRequest, post(t, serverURL+"/v1/users/login", "not json").StatusCode, "a login forgets the failures before it")
+		}
+		resp := post(t, serverURL+"/v1/users/login", `{"name": "Bruno"}`)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+	})
+}
+
+// TestAdminAuth checks every /admin route rejects the requests without the
+// admin token, before doing anything.
+func TestAdminAuth(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 1)
+	server := newTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry())
+	app := NewApp(logger, &sync.WaitGroup{}, usecase.Services{}, metrics.NewRegistry(), ratelimit.Limits{}, testAdminToken, 0)
+
+	var admin int
+	for _, e := range app.endpoints() {
+		if !strings.Contains(e.Path, "/admin/") {
+			continue
+		}
+		admin++
+		assert.True(t, e.Admin, "%s %s", e.Method, e.Path)
+		path := pathParamPattern.ReplaceAllString(e.Path, "1")
+		for name, authorization := range map[string]string{"No Token": "", "Wrong Token": "Bearer wrong", "Not Bearer": "Basic " + testAdminToken} {
+			t.Run(fmt.Sprintf("%s %s %s", e.Method, e.Path, name), func(t *testing.T) {
+				req, err := http.NewRequest(e.Method, server.URL+path, strings.NewReader("{}"))
+				require.NoError(t, err)
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				defer resp.Body.Close()
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+				assert.Equal(t, `Bearer realm="admin"`, resp.Header.Get("WWW-Authenticate"))
+				if strings.HasPrefix(e.Path, "/v2/") {
+					var envelope ErrorEnvelope
+					require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+					assert.Equal(t, ErrorBody{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "Unauthorized"}, envelope.Error)
+				}
+			})
+		}
+	}
+	assert.NotZero(t, admin)
+
+	for _, path := range []string{"/v1/admin/stats", "/v2/admin/stats", "/admin/stats"} {
+		resp := asAdmin(t, http.MethodGet, server.URL+path, nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+
+	t.Run("No Admin Token Configured", func(t *testing.T) {
+		unconfigured := NewApp(logger, &sync.WaitGroup{}, usecase.Services{}, metrics.NewRegistry(), ratelimit.Limits{}, "", 0)
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/stats", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		unconfigured.routes().ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
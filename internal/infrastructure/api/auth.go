@@ -0,0 +1,44 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// adminOnly puts the /admin routes behind requireAdmin.
+func (app *App) adminOnly(endpoints []endpoint) []endpoint {
+	for i, e := range endpoints {
+		if !strings.HasPrefix(e.Path, "/admin/") {
+			continue
+		}
+		endpoints[i] = app.withAdmin(e)
+		if e.V2 != nil {
+			v2 := app.withAdmin(*e.V2)
+			endpoints[i].V2 = &v2
+		}
+	}
+	return endpoints
+}
+
+func (app *App) withAdmin(e endpoint) endpoint {
+	e.Admin = true
+	e.Middlewares = append([]func(http.Handler) http.Handler{app.requireAdmin}, e.Middlewares...)
+	e.Responses = append(e.Responses, errorResponse(http.StatusUnauthorized, "The admin token is missing or wrong"))
+	return e
+}
+
+// requireAdmin rejects the requests that don't carry the admin token as a
+// bearer token. Without an admin token configured, every request is
+// rejected.
+func (app *App) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || app.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(app.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// requestMetrics counts the requests served and how long they took, by chi
+// route pattern so the user ids in the paths don't create a series each.
+type requestMetrics struct {
+	requests  *metrics.CounterVec
+	durations *metrics.HistogramVec
+}
+
+func newRequestMetrics(registry *metrics.Registry) *requestMetrics {
+	return &requestMetrics{
+		requests:  registry.NewCounterVec("quiz_http_requests_total", "HTTP requests served.", "method", "route", "status"),
+		durations: registry.NewHistogramVec("quiz_http_request_duration_seconds", "Time taken to serve HTTP requests.", metrics.DurationBuckets, "method", "route"),
+	}
+}
+
+func (rm *requestMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		// The pattern is only known once chi has routed the request.
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		rm.requests.With(r.Method, route, strconv.Itoa(status)).Inc()
+		rm.durations.With(r.Method, route).ObserveSince(start)
+	})
+}
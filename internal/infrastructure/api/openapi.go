@@ -0,0 +1,318 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// endpoint is a route of the server together with what the OpenAPI document
+// says about it. Bodies are described by values of their Go type, nil when
+// there is no JSON body.
+type endpoint struct {
+	Method      string
+	Path        string
+	Handler     http.Handler
+	Tag         string
+	Summary     string
+	Query       []queryParam
+	Request     *body
+	Responses   []response
+	Description string
+	Deprecated  bool
+	// Admin tells the route requires the admin token.
+	Admin       bool
+	Middlewares []func(http.Handler) http.Handler
+	// V2 is served by /v2 instead of the endpoint, for the routes that
+	// answer differently there. It has the method and path of the endpoint.
+	V2 *endpoint
+}
+
+type queryParam struct {
+	Name        string
+	Description string
+	Type        string
+	Enum        []string
+}
+
+type body struct {
+	ContentType string
+	Value       any
+}
+
+type response struct {
+	Status      int
+	Description string
+	Body        *body
+}
+
+func jsonBody(v any) *body {
+	return &body{ContentType: "application/json", Value: v}
+}
+
+func textBody() *body {
+	return &body{ContentType: "text/plain"}
+}
+
+// errorResponse is a response carrying the plain text message written by
+// http.Error.
+func errorResponse(status int, description string) response {
+	return response{Status: status, Description: description, Body: textBody()}
+}
+
+// The OpenAPI document types only have the fields this server uses.
+
+type openAPIDocument struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       openAPIInfo                     `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components openAPIComponents               `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes,omitempty"`
+}
+
+type securityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// adminToken is the security scheme of the routes requiring the admin token.
+const adminToken = "adminToken"
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]respDoc    `json:"responses"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type respDoc struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// openAPI describes the endpoints in an OpenAPI 3 document. The schemas of
+// the bodies are derived from their Go types and json tags, so the document
+// follows the DTOs as they change.
+func openAPI(endpoints []endpoint) ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "Simple Quiz API", Version: "1.0.0"},
+		Paths:   map[string]map[string]operation{},
+		Components: openAPIComponents{
+			Schemas:         map[string]*schema{},
+			SecuritySchemes: map[string]securityScheme{adminToken: {Type: "http", Scheme: "bearer"}},
+		},
+	}
+	schemas := &schemaBuilder{components: doc.Components.Schemas, names: map[reflect.Type]string{}}
+
+	for _, e := range endpoints {
+		op := operation{
+			Summary:     e.Summary,
+			Description: e.Description,
+			OperationID: operationID(e.Method, e.Path),
+			Responses:   map[string]respDoc{},
+			Deprecated:  e.Deprecated,
+		}
+		if e.Tag != "" {
+			op.Tags = []string{e.Tag}
+		}
+		if e.Admin {
+			op.Security = []map[string][]string{{adminToken: {}}}
+		}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(e.Path, -1) {
+			op.Parameters = append(op.Parameters, parameter{Name: match[1], In: "path", Required: true, Schema: &schema{Type: "string"}})
+		}
+		for _, q := range e.Query {
+			typ := q.Type
+			if typ == "" {
+				typ = "string"
+			}
+			op.Parameters = append(op.Parameters, parameter{Name: q.Name, In: "query", Description: q.Description, Schema: &schema{Type: typ, Enum: q.Enum}})
+		}
+		if e.Request != nil {
+			op.RequestBody = &requestBody{Required: true, Content: schemas.content(e.Request)}
+		}
+		for _, r := range e.Responses {
+			resp := respDoc{Description: r.Description}
+			if r.Body != nil {
+				resp.Content = schemas.content(r.Body)
+			}
+			op.Responses[strconv.Itoa(r.Status)] = resp
+		}
+
+		if doc.Paths[e.Path] == nil {
+			doc.Paths[e.Path] = map[string]operation{}
+		}
+		method := strings.ToLower(e.Method)
+		if _, exists := doc.Paths[e.Path][method]; exists {
+			return nil, fmt.Errorf("%s %s is documented twice", e.Method, e.Path)
+		}
+		doc.Paths[e.Path][method] = op
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// operationID names an operation after its method and path, such as
+// postUsersUserAnswer for POST /users/{user}/answer.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// schemaBuilder turns Go types into schemas, adding named struct types to
+// the components so they are described once.
+type schemaBuilder struct {
+	components map[string]*schema
+	names      map[reflect.Type]string
+}
+
+func (sb *schemaBuilder) content(b *body) map[string]mediaType {
+	var s *schema
+	switch {
+	case b.Value != nil:
+		s = sb.schemaOf(reflect.TypeOf(b.Value))
+	case strings.HasPrefix(b.ContentType, "text/"):
+		s = &schema{Type: "string"}
+	default:
+		s = &schema{Type: "string", Format: "binary"}
+	}
+	return map[string]mediaType{b.ContentType: {Schema: s}}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (sb *schemaBuilder) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		// Raw JSON can be any value, as interfaces.
+		return &schema{}
+	case t.Kind() == reflect.Struct:
+		return sb.structRef(t)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: sb.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: sb.schemaOf(t.Elem())}
+	}
+	// Interfaces can hold anything.
+	return &schema{}
+}
+
+// structRef describes a struct type in the components and refers to it.
+// Types from different packages sharing a name are told apart by prefixing
+// the package name to the one seen last.
+func (sb *schemaBuilder) structRef(t reflect.Type) *schema {
+	name, seen := sb.names[t]
+	if !seen {
+		name = t.Name()
+		if _, taken := sb.components[name]; taken {
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		sb.names[t] = name
+		// Reserve the name before describing the fields, for types that
+		// refer to themselves.
+		sb.components[name] = &schema{}
+		*sb.components[name] = *sb.structSchema(t)
+	}
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+func (sb *schemaBuilder) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = sb.schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
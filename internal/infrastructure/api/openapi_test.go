@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/openapi.json with the generated document")
+
+// TestOpenAPI compares the document served with testdata/openapi.json, so a
+// change to the routes or to the DTOs shows up in the spec under review.
+// Run the test with -update to accept the change.
+func TestOpenAPI(t *testing.T) {
+	app := NewApp(logging.Discard(), &sync.WaitGroup{}, usecase.Services{}, metrics.NewRegistry(), ratelimit.Limits{}, "", 0)
+	router := app.routes()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	golden := filepath.Join("testdata", "openapi.json")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, rr.Body.Bytes(), 0644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), rr.Body.String(), "the OpenAPI document changed, run go test ./internal/infrastructure/api -run TestOpenAPI -update and review testdata/openapi.json")
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	t.Run("Every Route Is Documented", func(t *testing.T) {
+		require.NoError(t, chi.Walk(router.(chi.Routes), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+			if route == "/openapi.json" {
+				return nil
+			}
+			assert.Contains(t, doc.Paths[route], strings.ToLower(method), "%s %s is not in the OpenAPI document", method, route)
+			return nil
+		}))
+	})
+
+	t.Run("Every Reference Resolves", func(t *testing.T) {
+		for _, match := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(rr.Body.String(), -1) {
+			assert.Contains(t, doc.Components.Schemas, match[1])
+		}
+		for _, name := range []string{"LoginRequest", "LoginResponse", "AnswerRequest", "ScoreData", "QuestionDTO", "Answer"} {
+			assert.Contains(t, doc.Components.Schemas, name)
+		}
+	})
+}
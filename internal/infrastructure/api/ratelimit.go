@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// limitByIP rejects the requests of a client address that used up its calls.
+func (app *App) limitByIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowed, wait := app.limits.IP.Allow(clientIP(r)); !allowed {
+			tooManyRequests(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitByUser rejects the requests of a quizzer that used up their calls,
+// whichever address they come from.
+func (app *App) limitByUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowed, wait := app.limits.User.Allow(chi.URLParam(r, "user")); !allowed {
+			tooManyRequests(w, wait)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lockOutLogins rejects the logins of a client address while it is locked
+// out. Logins rejected as a client error, rate limiting included, count as
+// failures towards a lockout.
+func (app *App) lockOutLogins(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if wait := app.limits.Login.Locked(ip); wait > 0 {
+			tooManyRequests(w, wait)
+			return
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		switch status := ww.Status(); {
+		case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+			if app.limits.Login.Fail(ip) {
+				app.logger.WarnContext(r.Context(), "logins locked out", "ip", ip, "status", status)
+			}
+		case status < http.StatusBadRequest:
+			app.limits.Login.Succeed(ip)
+		}
+	})
+}
+
+// tooManyRequests tells the client how long to wait before trying again.
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	seconds := ratelimit.RetryAfter(wait)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, fmt.Sprintf("Too many requests, try again in %d seconds", seconds), http.StatusTooManyRequests)
+}
+
+// clientIP is the address the request comes from, without its port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
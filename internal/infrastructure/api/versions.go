@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The routes outside of /v1 and /v2 are the ones served before the API was
+// versioned. They keep answering like /v1 until they are removed.
+var (
+	unversionedDeprecation = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	unversionedSunset      = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+)
+
+// versioned returns the /v1 and /v2 copies of the endpoints and, when
+// aliased, their deprecated aliases without a version.
+func versioned(endpoints []endpoint, aliased bool) []endpoint {
+	var all []endpoint
+	for _, e := range endpoints {
+		v1 := e
+		v1.Path = "/v1" + e.Path
+
+		v2 := e
+		if e.V2 != nil {
+			v2 = *e.V2
+			v2.Method = e.Method
+		}
+		v2.Path = "/v2" + e.Path
+		v2.Middlewares = append([]func(http.Handler) http.Handler{errorEnvelopes}, v2.Middlewares...)
+		responses := v2.Responses
+		v2.Responses = nil
+		for _, r := range responses {
+			if r.Status >= http.StatusBadRequest && r.Body != nil && r.Body.ContentType == "text/plain" {
+				r.Body = jsonBody(ErrorEnvelope{})
+			}
+			v2.Responses = append(v2.Responses, r)
+		}
+
+		all = append(all, v1, v2)
+		if !aliased {
+			continue
+		}
+		alias := e
+		alias.Deprecated = true
+		alias.Description = strings.TrimSpace(fmt.Sprintf("Deprecated in favour of %s, removed after %s. %s", v1.Path, unversionedSunset.Format(time.DateOnly), e.Description))
+		alias.Middlewares = append([]func(http.Handler) http.Handler{deprecated}, e.Middlewares...)
+
+		all = append(all, alias)
+	}
+	return all
+}
+
+// deprecated marks the responses of the unversioned routes as deprecated,
+// pointing to the same route under /v1.
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", unversionedDeprecation.Unix()))
+		w.Header().Set("Sunset", unversionedSunset.Format(http.TimeFormat))
+		w.Header().Set("Link", fmt.Sprintf("</v1%s>; rel=\"successor-version\"", r.URL.Path))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ErrorEnvelope is the body of the errors of /v2.
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorCode turns a status into a code clients can switch on, such as
+// not_found.
+func errorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
+
+// errorEnvelopes rewrites the plain text errors written with http.Error as
+// an ErrorEnvelope, leaving every other response untouched.
+func errorEnvelopes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		ew.finish()
+	})
+}
+
+type envelopeWriter struct {
+	http.ResponseWriter
+	status  int
+	message bytes.Buffer
+}
+
+func (ew *envelopeWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest && strings.HasPrefix(ew.Header().Get("Content-Type"), "text/plain") {
+		ew.status = status
+		return
+	}
+	ew.ResponseWriter.WriteHeader(status)
+}
+
+func (ew *envelopeWriter) Write(b []byte) (int, error) {
+	if ew.status != 0 {
+		return ew.message.Write(b)
+	}
+	return ew.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming responses through.
+func (ew *envelopeWriter) Flush() {
+	if flusher, ok := ew.ResponseWriter.(http.Flusher); ok && ew.status == 0 {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSockets through.
+func (ew *envelopeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(ew.ResponseWriter).Hijack()
+}
+
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+func (ew *envelopeWriter) finish() {
+	if ew.status == 0 {
+		return
+	}
+	ew.Header().Del("X-Content-Type-Options")
+	ew.Header().Set("Content-Type", "application/json")
+	ew.ResponseWriter.WriteHeader(ew.status)
+	json.NewEncoder(ew.ResponseWriter).Encode(ErrorEnvelope{Error: ErrorBody{
+		Status:  ew.status,
+		Code:    errorCode(ew.status),
+		Message: strings.TrimSpace(ew.message.String()),
+	}})
+}
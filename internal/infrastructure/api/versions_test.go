@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/room"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/repository"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersions(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 1)
+	server := newTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry())
+	userID := login(t, server.URL, "Ana")
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(content)
+	}
+
+	for version, expected := range map[string]string{"/v1": "[]", "/v2": `{"answers": []}`} {
+		resp, content := get(version + "/users/" + userID + "/answered")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, version)
+		assert.JSONEq(t, expected, content, version)
+		assert.Empty(t, resp.Header.Get("Deprecation"), version)
+	}
+
+	resp, content := get("/v1/users/unknown/answered")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	assert.Equal(t, "An error occured getting user's answers\n", content)
+
+	resp, content = get("/v2/users/unknown/answered")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var envelope ErrorEnvelope
+	require.NoError(t, json.Unmarshal([]byte(content), &envelope), content)
+	assert.Equal(t, ErrorBody{Status: http.StatusNotFound, Code: "not_found", Message: "An error occured getting user's answers"}, envelope.Error)
+
+	resp, content = get("/users/unknown/answered")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "An error occured getting user's answers\n", content)
+	assert.Equal(t, "@1792281600", resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Sun, 18 Apr 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</v1/users/unknown/answered>; rel="successor-version"`, resp.Header.Get("Link"))
+
+	resp, _ = get("/v1/healthz")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+}
+
+// TestVersionsWebSocket checks the rooms are upgraded to WebSockets through
+// every middleware.
+func TestVersionsWebSocket(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 1)
+	server := newTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry())
+
+	for _, version := range []string{"/v1", "/v2"} {
+		conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+version+"/rooms/host", nil)
+		require.NoError(t, err, version)
+		resp.Body.Close()
+		var message room.Message
+		require.NoError(t, conn.ReadJSON(&message), version)
+		assert.Equal(t, room.MessageRoom, message.Type, version)
+		conn.Close()
+	}
+
+	resp, err := http.Get(server.URL + "/rooms/host")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// TestVersionsEventStream checks the live events are flushed through every
+// middleware.
+func TestVersionsEventStream(t *testing.T) {
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 1)
+	server := newTestServer(t, logger, dataDir, repository.NewUserRepository(logger, dataDir), metrics.NewRegistry())
+
+	resp := asAdmin(t, http.MethodGet, server.URL+"/v2/admin/live", nil)
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+	line, err := stream.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "retry: 3000\n", line)
+
+	login(t, server.URL, "Ana")
+	userID := login(t, server.URL, "Bob")
+	answer, err := http.Post(server.URL+"/v2/users/"+userID+"/answer", "application/json", strings.NewReader(`{"question_id": "1", "option_id": "A"}`))
+	require.NoError(t, err)
+	answer.Body.Close()
+	for _, want := range []string{"\n", "id: 1\n", "event: answered\n"} {
+		line, err = stream.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, want, line)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "not_found", errorCode(http.StatusNotFound))
+	assert.Equal(t, "request_entity_too_large", errorCode(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, "non_authoritative_information", errorCode(http.StatusNonAuthoritativeInfo))
+	assert.Equal(t, "error", errorCode(599))
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// The stores below are implemented by the repositories of this package.
+// lock holds off every other read and write until the returned func is
+// called, and the other methods must only be called while locked.
+type userStore interface {
+	lock() (unlock func())
+	dumpUsers() (model.UserMap, error)
+	restoreUsers(users model.UserMap) error
+}
+
+type questionStore interface {
+	lock() (unlock func())
+	dumpQuestions() (model.QuestionMap, model.QuestionRevisions, error)
+	restoreQuestions(questions model.QuestionMap, revisions model.QuestionRevisions) error
+}
+
+type eventStore interface {
+	lock() (unlock func())
+	dumpEvents() ([]model.Event, error)
+	restoreEvents(events []model.Event) error
+}
+
+// BackupRepository copies or replaces the data of the other repositories
+// while holding all their locks, so a backup never mixes data from before
+// and after a change.
+type BackupRepository struct {
+	logger    *slog.Logger
+	users     userStore
+	questions questionStore
+	events    eventStore
+}
+
+func NewBackupRepository(logger *slog.Logger, users model.UserRepository, questions model.QuestionRepository, events model.EventRepository) (model.BackupRepository, error) {
+	userStore, ok := users.(userStore)
+	if !ok {
+		return nil, fmt.Errorf("users stored in %T can't be backed up", users)
+	}
+	questionStore, ok := questions.(questionStore)
+	if !ok {
+		return nil, fmt.Errorf("questions stored in %T can't be backed up", questions)
+	}
+	eventStore, ok := events.(eventStore)
+	if !ok {
+		return nil, fmt.Errorf("events stored in %T can't be backed up", events)
+	}
+	return &BackupRepository{
+		logger:    logger,
+		users:     userStore,
+		questions: questionStore,
+		events:    eventStore,
+	}, nil
+}
+
+func (br *BackupRepository) Backup(ctx context.Context) (*model.Backup, error) {
+	defer br.lock()()
+
+	backup := &model.Backup{
+		SchemaVersion: model.SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	var err error
+	if backup.Users, err = br.users.dumpUsers(); err != nil {
+		br.logger.ErrorContext(ctx, "backing up users", "error", err)
+		return nil, err
+	}
+	if backup.Questions, backup.QuestionRevisions, err = br.questions.dumpQuestions(); err != nil {
+		br.logger.ErrorContext(ctx, "backing up questions", "error", err)
+		return nil, err
+	}
+	if backup.Events, err = br.events.dumpEvents(); err != nil {
+		br.logger.ErrorContext(ctx, "backing up events", "error", err)
+		return nil, err
+	}
+	return backup, nil
+}
+
+// Migrate upgrades a backup taken with an older schema version, so it can be
+// validated and restored.
+func (br *BackupRepository) Migrate(ctx context.Context, backup *model.Backup) error {
+	if err := migrate(br.logger, backup); err != nil {
+		br.logger.ErrorContext(ctx, "migrating backup", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Restore replaces the data with the backup, which must have been migrated
+// to the current schema version first.
+func (br *BackupRepository) Restore(ctx context.Context, backup model.Backup) error {
+	if backup.SchemaVersion != model.SchemaVersion {
+		err := fmt.Errorf("backup has schema version %d, it must be migrated to %d first", backup.SchemaVersion, model.SchemaVersion)
+		br.logger.ErrorContext(ctx, "restoring backup", "error", err)
+		return err
+	}
+
+	defer br.lock()()
+
+	if err := br.users.restoreUsers(backup.Users); err != nil {
+		br.logger.ErrorContext(ctx, "restoring users", "error", err)
+		return err
+	}
+	if err := br.questions.restoreQuestions(backup.Questions, backup.QuestionRevisions); err != nil {
+		br.logger.ErrorContext(ctx, "restoring questions", "error", err)
+		return err
+	}
+	if err := br.events.restoreEvents(backup.Events); err != nil {
+		br.logger.ErrorContext(ctx, "restoring events", "error", err)
+		return err
+	}
+	return nil
+}
+
+// lock locks every repository, always in the same order.
+func (br *BackupRepository) lock() (unlock func()) {
+	unlockUsers := br.users.lock()
+	unlockQuestions := br.questions.lock()
+	unlockEvents := br.events.lock()
+	return func() {
+		unlockEvents()
+		unlockQuestions()
+		unlockUsers()
+	}
+}
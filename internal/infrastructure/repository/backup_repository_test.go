@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRepository(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.Discard()
+	options := []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+
+	storages := map[string]func(t *testing.T, dataDir string) (model.UserRepository, model.QuestionRepository){
+		"JSON": func(t *testing.T, dataDir string) (model.UserRepository, model.QuestionRepository) {
+			return NewUserRepository(logger, dataDir), NewQuestionRepository(logger, dataDir)
+		},
+		"Memory": func(t *testing.T, dataDir string) (model.UserRepository, model.QuestionRepository) {
+			users, err := NewMemoryUserRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			t.Cleanup(func() { users.Close() })
+			questions, err := NewMemoryQuestionRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			t.Cleanup(func() { questions.Close() })
+			return users, questions
+		},
+	}
+	for name, open := range storages {
+		t.Run(name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			require.NoError(t, writeFileAtomic(filepath.Join(dataDir, "questions.json"), model.QuestionMap{
+				"1": {Label: "First?", Options: options},
+			}))
+			users, questions := open(t, dataDir)
+			events := NewEventRepository(logger, dataDir)
+			backups, err := NewBackupRepository(logger, users, questions, events)
+			require.NoError(t, err)
+
+			ana, err := users.CreateUser(ctx, model.User{Name: "Ana"})
+			require.NoError(t, err)
+			_, err = events.AppendEvent(ctx, model.Event{UserID: ana.ID, Type: model.EventStarted, At: time.Now().UTC(), Name: "Ana"})
+			require.NoError(t, err)
+
+			backup, err := backups.Backup(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, model.SchemaVersion, backup.SchemaVersion)
+			assert.Equal(t, model.UserMap{"1": {ID: "1", Name: "Ana"}}, backup.Users)
+			assert.Equal(t, model.QuestionMap{"1": {Label: "First?", Options: options, Revision: 1}}, backup.Questions)
+			assert.Len(t, backup.Events, 1)
+
+			// Change everything after the backup was taken.
+			_, err = users.CreateUser(ctx, model.User{Name: "Bob"})
+			require.NoError(t, err)
+			require.NoError(t, questions.SaveQuestions(ctx, model.QuestionMap{
+				"1": {Label: "First, edited?", Options: options},
+				"2": {Label: "Second?", Options: options},
+			}))
+			_, err = events.AppendEvent(ctx, model.Event{UserID: "2", Type: model.EventStarted, At: time.Now().UTC(), Name: "Bob"})
+			require.NoError(t, err)
+
+			require.NoError(t, backups.Restore(ctx, *backup))
+
+			restoredUsers, err := users.GetAllUsers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, backup.Users, restoredUsers)
+			restoredQuestions, err := questions.GetAllQuestions(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, "First?", restoredQuestions["1"].Label)
+			assert.NotContains(t, restoredQuestions, "2")
+			restoredEvents, err := events.GetUserEvents(ctx, "2")
+			require.NoError(t, err)
+			assert.Empty(t, restoredEvents)
+
+			event, err := events.AppendEvent(ctx, model.Event{UserID: "2", Type: model.EventStarted, At: time.Now().UTC(), Name: "Cai"})
+			require.NoError(t, err)
+			assert.Equal(t, 2, event.Sequence, "sequence continues from the restored events")
+
+			// The restore survives reopening the repositories.
+			users, questions = open(t, dataDir)
+			reopenedUsers, err := users.GetAllUsers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, backup.Users, reopenedUsers)
+			reopenedQuestions, err := questions.GetAllQuestions(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, restoredQuestions, reopenedQuestions)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// EventRepository stores events as JSON lines in a file that is only ever
+// appended to.
+type EventRepository struct {
+	mu       *sync.RWMutex
+	logger   *slog.Logger
+	dataPath string
+	sequence int
+}
+
+func NewEventRepository(logger *slog.Logger, dataDir string) model.EventRepository {
+	return &EventRepository{
+		mu:       &sync.RWMutex{},
+		logger:   logger,
+		dataPath: filepath.Join(dataDir, "events.jsonl"),
+		sequence: -1,
+	}
+}
+
+func (er *EventRepository) AppendEvent(ctx context.Context, event model.Event) (*model.Event, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if er.sequence < 0 {
+		events, err := er.readEventsFromFile()
+		if err != nil {
+			er.logger.ErrorContext(ctx, "appending event", "error", err)
+			return nil, err
+		}
+		er.sequence = 0
+		if len(events) > 0 {
+			er.sequence = events[len(events)-1].Sequence
+		}
+	}
+
+	event.Sequence = er.sequence + 1
+	line, err := json.Marshal(event)
+	if err != nil {
+		err = fmt.Errorf("encoding event: %v", err)
+		er.logger.ErrorContext(ctx, "appending event", "error", err)
+		return nil, err
+	}
+	file, err := os.OpenFile(er.dataPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		err = fmt.Errorf("opening events file: %v", err)
+		er.logger.ErrorContext(ctx, "appending event", "error", err)
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		err = fmt.Errorf("writing event: %v", err)
+		er.logger.ErrorContext(ctx, "appending event", "error", err)
+		return nil, err
+	}
+	er.sequence = event.Sequence
+
+	return &event, nil
+}
+
+func (er *EventRepository) GetUserEvents(ctx context.Context, userID string) ([]model.Event, error) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+
+	events, err := er.readEventsFromFile()
+	if err != nil {
+		er.logger.ErrorContext(ctx, "getting user events", "error", err)
+		return nil, err
+	}
+
+	userEvents := []model.Event{}
+	for _, event := range events {
+		if event.UserID == userID {
+			userEvents = append(userEvents, event)
+		}
+	}
+	return userEvents, nil
+}
+
+func (er *EventRepository) readEventsFromFile() ([]model.Event, error) {
+	return readEventsFile(er.dataPath)
+}
+
+func (er *EventRepository) lock() func() {
+	er.mu.Lock()
+	return er.mu.Unlock
+}
+
+func (er *EventRepository) dumpEvents() ([]model.Event, error) {
+	return er.readEventsFromFile()
+}
+
+func (er *EventRepository) restoreEvents(events []model.Event) error {
+	if err := writeEventsFile(er.dataPath, events); err != nil {
+		return err
+	}
+	er.sequence = 0
+	if len(events) > 0 {
+		er.sequence = events[len(events)-1].Sequence
+	}
+	return nil
+}
+
+func readEventsFile(path string) ([]model.Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading events from file: %v", err)
+	}
+	defer file.Close()
+
+	var events []model.Event
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		var event model.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("decoding event on line %d: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading events from file: %v", err)
+	}
+	return events, nil
+}
+
+// writeEventsFile replaces the events file at path with the given events.
+func writeEventsFile(path string, events []model.Event) error {
+	content := &bytes.Buffer{}
+	encoder := json.NewEncoder(content)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return fmt.Errorf("encoding event: %v", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing events file: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing events file: %v", err)
+	}
+	return nil
+}
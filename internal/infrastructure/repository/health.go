@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// The repositories check that the files they need can be read and that the
+// directory holding them still exists, so new files can be written.
+
+func (ur *UserRepository) CheckHealth(ctx context.Context) error {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+	if err := checkDataDir(ur.dataPath); err != nil {
+		return err
+	}
+	_, err := ur.readUsersFromFile()
+	return err
+}
+
+func (ur *MemoryUserRepository) CheckHealth(ctx context.Context) error {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+	if err := checkDataDir(ur.snapshotPath); err != nil {
+		return err
+	}
+	if _, err := ur.logFile.Stat(); err != nil {
+		return fmt.Errorf("users log: %v", err)
+	}
+	return nil
+}
+
+func (qr *QuestionRepository) CheckHealth(ctx context.Context) error {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+	return checkQuestionsFile(qr.dataPath)
+}
+
+// CheckHealth fails when questions.json is invalid, even though the
+// questions read before it was broken are still served.
+func (qr *MemoryQuestionRepository) CheckHealth(ctx context.Context) error {
+	return checkQuestionsFile(qr.dataPath)
+}
+
+func (er *EventRepository) CheckHealth(ctx context.Context) error {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+	if err := checkDataDir(er.dataPath); err != nil {
+		return err
+	}
+	file, err := os.Open(er.dataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("opening events file: %v", err)
+	}
+	return file.Close()
+}
+
+func checkDataDir(path string) error {
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("data directory: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("data directory: %s is not a directory", dir)
+	}
+	return nil
+}
+
+func checkQuestionsFile(path string) error {
+	questions, err := readQuestionsFile(path)
+	if err != nil {
+		return err
+	}
+	if errs := model.ValidateQuestions(questions).Errors(); len(errs) > 0 {
+		return fmt.Errorf("%s has %d errors, the first one: %s", filepath.Base(path), len(errs), errs[0])
+	}
+	return nil
+}
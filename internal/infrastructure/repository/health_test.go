@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	questionsPath := filepath.Join(dataDir, "questions.json")
+	require.NoError(t, os.WriteFile(questionsPath, []byte(`{"1": {"label": "Question 1", "options": [{"id": "A", "label": "Yes", "is_correct": true}, {"id": "B", "label": "No"}]}}`), 0644))
+
+	memoryUsers, err := NewMemoryUserRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+	defer memoryUsers.Close()
+	memoryQuestions, err := NewMemoryQuestionRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+	defer memoryQuestions.Close()
+	repos := map[string]model.HealthChecker{
+		"UserRepository":           NewUserRepository(logger, dataDir).(model.HealthChecker),
+		"MemoryUserRepository":     memoryUsers,
+		"QuestionRepository":       NewQuestionRepository(logger, dataDir).(model.HealthChecker),
+		"MemoryQuestionRepository": memoryQuestions,
+		"EventRepository":          NewEventRepository(logger, dataDir).(model.HealthChecker),
+	}
+	for name, repo := range repos {
+		assert.NoError(t, repo.CheckHealth(ctx), name)
+	}
+
+	t.Run("Invalid Questions File", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(questionsPath, []byte(`{"1": {"label": "Question 1", "options": [{"id": "A", "label": "Yes"}]}}`), 0644))
+
+		assert.ErrorContains(t, repos["QuestionRepository"].CheckHealth(ctx), "questions.json has 2 errors")
+		assert.ErrorContains(t, repos["MemoryQuestionRepository"].CheckHealth(ctx), "questions.json has 2 errors")
+	})
+
+	t.Run("Data Directory Removed", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(dataDir))
+
+		for name, repo := range repos {
+			assert.Error(t, repo.CheckHealth(ctx), name)
+		}
+	})
+}
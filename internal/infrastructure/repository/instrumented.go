@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+)
+
+// Instrumentation wraps repositories so the duration of each of their
+// operations is recorded. The wrappers pass health checks through, but hide
+// the stores used for backups, so the backup repository must be given the
+// repositories themselves.
+type Instrumentation struct {
+	durations *metrics.HistogramVec
+}
+
+func NewInstrumentation(registry *metrics.Registry) *Instrumentation {
+	return &Instrumentation{
+		durations: registry.NewHistogramVec("quiz_repository_operation_duration_seconds", "Duration of the repository operations, failed ones included.", metrics.DurationBuckets, "repository", "operation"),
+	}
+}
+
+func (in *Instrumentation) observe(repository, operation string, start time.Time) {
+	in.durations.With(repository, operation).ObserveSince(start)
+}
+
+func (in *Instrumentation) Users(repo model.UserRepository) model.UserRepository {
+	return &instrumentedUserRepository{repo: repo, in: in}
+}
+
+func (in *Instrumentation) Questions(repo model.QuestionRepository) model.QuestionRepository {
+	return &instrumentedQuestionRepository{repo: repo, in: in}
+}
+
+func (in *Instrumentation) Events(repo model.EventRepository) model.EventRepository {
+	return &instrumentedEventRepository{repo: repo, in: in}
+}
+
+func (in *Instrumentation) Webhooks(repo model.WebhookRepository) model.WebhookRepository {
+	return &instrumentedWebhookRepository{repo: repo, in: in}
+}
+
+func (in *Instrumentation) Backups(repo model.BackupRepository) model.BackupRepository {
+	return &instrumentedBackupRepository{repo: repo, in: in}
+}
+
+// checkHealth checks the health of the wrapped repository, when it can.
+func checkHealth(ctx context.Context, repo any) error {
+	if checker, ok := repo.(model.HealthChecker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
+
+type instrumentedUserRepository struct {
+	repo model.UserRepository
+	in   *Instrumentation
+}
+
+func (ir *instrumentedUserRepository) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, ir.repo)
+}
+
+func (ir *instrumentedUserRepository) CreateUser(ctx context.Context, user model.User) (*model.User, error) {
+	defer ir.in.observe("users", "create_user", time.Now())
+	return ir.repo.CreateUser(ctx, user)
+}
+
+func (ir *instrumentedUserRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	defer ir.in.observe("users", "update_user", time.Now())
+	return ir.repo.UpdateUser(ctx, user)
+}
+
+func (ir *instrumentedUserRepository) GetUser(ctx context.Context, id string) (*model.User, error) {
+	defer ir.in.observe("users", "get_user", time.Now())
+	return ir.repo.GetUser(ctx, id)
+}
+
+func (ir *instrumentedUserRepository) GetAllUsers(ctx context.Context) (model.UserMap, error) {
+	defer ir.in.observe("users", "get_all_users", time.Now())
+	return ir.repo.GetAllUsers(ctx)
+}
+
+func (ir *instrumentedUserRepository) ListUsers(ctx context.Context, query model.UserQuery) (*model.UserPage, error) {
+	defer ir.in.observe("users", "list_users", time.Now())
+	return ir.repo.ListUsers(ctx, query)
+}
+
+type instrumentedQuestionRepository struct {
+	repo model.QuestionRepository
+	in   *Instrumentation
+}
+
+func (ir *instrumentedQuestionRepository) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, ir.repo)
+}
+
+func (ir *instrumentedQuestionRepository) GetAllQuestions(ctx context.Context) (model.QuestionMap, error) {
+	defer ir.in.observe("questions", "get_all_questions", time.Now())
+	return ir.repo.GetAllQuestions(ctx)
+}
+
+func (ir *instrumentedQuestionRepository) ListQuestions(ctx context.Context, query model.QuestionQuery) (*model.QuestionPage, error) {
+	defer ir.in.observe("questions", "list_questions", time.Now())
+	return ir.repo.ListQuestions(ctx, query)
+}
+
+func (ir *instrumentedQuestionRepository) GetQuestion(ctx context.Context, id string) (*model.Question, error) {
+	defer ir.in.observe("questions", "get_question", time.Now())
+	return ir.repo.GetQuestion(ctx, id)
+}
+
+func (ir *instrumentedQuestionRepository) SaveQuestions(ctx context.Context, questions model.QuestionMap) error {
+	defer ir.in.observe("questions", "save_questions", time.Now())
+	return ir.repo.SaveQuestions(ctx, questions)
+}
+
+func (ir *instrumentedQuestionRepository) GetQuestionRevision(ctx context.Context, id string, revision int) (*model.Question, error) {
+	defer ir.in.observe("questions", "get_question_revision", time.Now())
+	return ir.repo.GetQuestionRevision(ctx, id, revision)
+}
+
+type instrumentedEventRepository struct {
+	repo model.EventRepository
+	in   *Instrumentation
+}
+
+func (ir *instrumentedEventRepository) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, ir.repo)
+}
+
+func (ir *instrumentedEventRepository) AppendEvent(ctx context.Context, event model.Event) (*model.Event, error) {
+	defer ir.in.observe("events", "append_event", time.Now())
+	return ir.repo.AppendEvent(ctx, event)
+}
+
+func (ir *instrumentedEventRepository) GetUserEvents(ctx context.Context, userID string) ([]model.Event, error) {
+	defer ir.in.observe("events", "get_user_events", time.Now())
+	return ir.repo.GetUserEvents(ctx, userID)
+}
+
+type instrumentedBackupRepository struct {
+	repo model.BackupRepository
+	in   *Instrumentation
+}
+
+func (ir *instrumentedBackupRepository) Backup(ctx context.Context) (*model.Backup, error) {
+	defer ir.in.observe("backups", "backup", time.Now())
+	return ir.repo.Backup(ctx)
+}
+
+func (ir *instrumentedBackupRepository) Migrate(ctx context.Context, backup *model.Backup) error {
+	defer ir.in.observe("backups", "migrate", time.Now())
+	return ir.repo.Migrate(ctx, backup)
+}
+
+func (ir *instrumentedBackupRepository) Restore(ctx context.Context, backup model.Backup) error {
+	defer ir.in.observe("backups", "restore", time.Now())
+	return ir.repo.Restore(ctx, backup)
+}
+
+type instrumentedWebhookRepository struct {
+	repo model.WebhookRepository
+	in   *Instrumentation
+}
+
+func (ir *instrumentedWebhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	defer ir.in.observe("webhooks", "create_webhook", time.Now())
+	return ir.repo.CreateWebhook(ctx, webhook)
+}
+
+func (ir *instrumentedWebhookRepository) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	defer ir.in.observe("webhooks", "get_webhooks", time.Now())
+	return ir.repo.GetWebhooks(ctx)
+}
+
+func (ir *instrumentedWebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	defer ir.in.observe("webhooks", "delete_webhook", time.Now())
+	return ir.repo.DeleteWebhook(ctx, id)
+}
+
+func (ir *instrumentedWebhookRepository) AddDeliveries(ctx context.Context, deliveries []model.Delivery) ([]model.Delivery, error) {
+	defer ir.in.observe("webhooks", "add_deliveries", time.Now())
+	return ir.repo.AddDeliveries(ctx, deliveries)
+}
+
+func (ir *instrumentedWebhookRepository) UpdateDelivery(ctx context.Context, delivery model.Delivery) error {
+	defer ir.in.observe("webhooks", "update_delivery", time.Now())
+	return ir.repo.UpdateDelivery(ctx, delivery)
+}
+
+func (ir *instrumentedWebhookRepository) GetDeliveries(ctx context.Context) ([]model.Delivery, error) {
+	defer ir.in.observe("webhooks", "get_deliveries", time.Now())
+	return ir.repo.GetDeliveries(ctx)
+}
@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// MemoryQuestionRepository reads the questions file once and serves every
+// request from memory. Saved questions are written to the file and replace
+// the in-memory set. When a poll interval is given the file is watched, so
+// edits made to it while the server runs are picked up without a restart.
+// Every version of a question is kept in question_revisions.json.
+type MemoryQuestionRepository struct {
+	mu            *sync.RWMutex
+	logger        *slog.Logger
+	dataPath      string
+	revisionsPath string
+	questions     model.QuestionMap
+	revisions     model.QuestionRevisions
+	file          fileVersion
+	done          chan struct{}
+}
+
+// fileVersion identifies the content of the questions file that was last
+// seen, valid or not, so an unchanged file is not read or reported again.
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+func NewMemoryQuestionRepository(logger *slog.Logger, dataDir string, pollInterval time.Duration) (*MemoryQuestionRepository, error) {
+	qr := &MemoryQuestionRepository{
+		mu:            &sync.RWMutex{},
+		logger:        logger,
+		dataPath:      filepath.Join(dataDir, "questions.json"),
+		revisionsPath: filepath.Join(dataDir, "question_revisions.json"),
+		done:          make(chan struct{}),
+	}
+	info, err := os.Stat(qr.dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %v", qr.dataPath, err)
+	}
+	content, err := os.ReadFile(qr.dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %v", qr.dataPath, err)
+	}
+	questions, err := decodeQuestions(content)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := readRevisionsFile(qr.revisionsPath)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err = recordRevisions(qr.revisionsPath, revisions, questions)
+	if err != nil {
+		return nil, err
+	}
+	qr.questions = questions
+	qr.revisions = revisions
+	qr.file = fileVersion{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(content)}
+	if pollInterval > 0 {
+		go qr.watch(pollInterval)
+	}
+	return qr, nil
+}
+
+func (qr *MemoryQuestionRepository) GetAllQuestions(ctx context.Context) (model.QuestionMap, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	questions := make(model.QuestionMap, len(qr.questions))
+	for id, question := range qr.questions {
+		questions[id] = question
+	}
+	return questions, nil
+}
+
+// ListQuestions pages through the questions without copying them all.
+func (qr *MemoryQuestionRepository) ListQuestions(ctx context.Context, query model.QuestionQuery) (*model.QuestionPage, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	return model.ListQuestions(qr.questions, query)
+}
+
+func (qr *MemoryQuestionRepository) GetQuestion(ctx context.Context, id string) (*model.Question, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	question, exists := qr.questions[id]
+	if !exists {
+		err := fmt.Errorf("question with id %s not found", id)
+		qr.logger.ErrorContext(ctx, "getting question", "error", err)
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (qr *MemoryQuestionRepository) GetQuestionRevision(ctx context.Context, id string, revision int) (*model.Question, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	question, exists := qr.revisions.Get(id, revision)
+	if !exists {
+		err := fmt.Errorf("question with id %s has no revision %d", id, revision)
+		qr.logger.ErrorContext(ctx, "getting question revision", "error", err)
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (qr *MemoryQuestionRepository) SaveQuestions(ctx context.Context, questions model.QuestionMap) error {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+
+	saved := make(model.QuestionMap, len(questions))
+	for id, question := range questions {
+		saved[id] = question
+	}
+	revisions, err := recordRevisions(qr.revisionsPath, qr.revisions, saved)
+	if err != nil {
+		qr.logger.ErrorContext(ctx, "saving questions", "error", err)
+		return err
+	}
+	if err := writeQuestionsFile(qr.dataPath, saved); err != nil {
+		qr.logger.ErrorContext(ctx, "saving questions", "error", err)
+		return err
+	}
+	qr.questions = saved
+	qr.revisions = revisions
+	qr.rememberFile()
+	return nil
+}
+
+// rememberFile records the questions file we just wrote, so the watcher
+// doesn't report our own write as an edit.
+func (qr *MemoryQuestionRepository) rememberFile() {
+	content, err := os.ReadFile(qr.dataPath)
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(qr.dataPath); err == nil {
+		qr.file = fileVersion{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(content)}
+	}
+}
+
+// Close stops watching the questions file.
+func (qr *MemoryQuestionRepository) Close() error {
+	close(qr.done)
+	return nil
+}
+
+func (qr *MemoryQuestionRepository) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			qr.reload()
+		case <-qr.done:
+			return
+		}
+	}
+}
+
+// reload swaps in the questions file when it changed since it was last seen.
+// A file that can't be decoded or has validation errors is reported and the
+// questions being served are kept.
+func (qr *MemoryQuestionRepository) reload() {
+	info, err := os.Stat(qr.dataPath)
+	if err != nil {
+		qr.logger.Error("watching questions", "error", err)
+		return
+	}
+
+	qr.mu.RLock()
+	seen := qr.file
+	qr.mu.RUnlock()
+	if info.ModTime().Equal(seen.modTime) && info.Size() == seen.size {
+		return
+	}
+
+	content, err := os.ReadFile(qr.dataPath)
+	if err != nil {
+		qr.logger.Error("watching questions", "error", err)
+		return
+	}
+	version := fileVersion{modTime: info.ModTime(), size: info.Size(), hash: sha256.Sum256(content)}
+
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+	if version.hash == qr.file.hash {
+		qr.file = version
+		return
+	}
+	qr.file = version
+
+	questions, err := decodeQuestions(content)
+	if err != nil {
+		qr.logger.Error("reloading questions, keeping the previous ones", "error", err)
+		return
+	}
+	if errs := model.ValidateQuestions(questions).Errors(); len(errs) > 0 {
+		for _, issue := range errs {
+			qr.logger.Error("invalid question", "question_id", issue.QuestionID, "rule", issue.Rule, "message", issue.Message)
+		}
+		qr.logger.Error("reloading questions, keeping the previous ones", "errors", len(errs))
+		return
+	}
+	revisions, err := recordRevisions(qr.revisionsPath, qr.revisions, questions)
+	if err != nil {
+		qr.logger.Error("reloading questions, keeping the previous ones", "error", err)
+		return
+	}
+
+	diff := model.DiffQuestions(qr.questions, questions)
+	qr.questions = questions
+	qr.revisions = revisions
+	qr.logger.Info("questions reloaded", "changes", diff.String())
+}
+
+func (qr *MemoryQuestionRepository) lock() func() {
+	qr.mu.Lock()
+	return qr.mu.Unlock
+}
+
+func (qr *MemoryQuestionRepository) dumpQuestions() (model.QuestionMap, model.QuestionRevisions, error) {
+	questions := make(model.QuestionMap, len(qr.questions))
+	for id, question := range qr.questions {
+		questions[id] = question
+	}
+	return questions, cloneRevisions(qr.revisions), nil
+}
+
+func (qr *MemoryQuestionRepository) restoreQuestions(questions model.QuestionMap, revisions model.QuestionRevisions) error {
+	restored := make(model.QuestionMap, len(questions))
+	for id, question := range questions {
+		restored[id] = question
+	}
+	restoredRevisions := cloneRevisions(revisions)
+	restoredRevisions.Record(restored)
+	if err := writeFileAtomic(qr.revisionsPath, restoredRevisions); err != nil {
+		return err
+	}
+	if err := writeQuestionsFile(qr.dataPath, restored); err != nil {
+		return err
+	}
+	qr.questions = restored
+	qr.revisions = restoredRevisions
+	qr.rememberFile()
+	return nil
+}
+
+func readQuestionsFile(path string) (model.QuestionMap, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %v", path, err)
+	}
+	return decodeQuestions(content)
+}
+
+// writeQuestionsFile writes the questions without their revisions, which are
+// assigned when the file is read.
+func writeQuestionsFile(path string, questions model.QuestionMap) error {
+	content := make(model.QuestionMap, len(questions))
+	for id, question := range questions {
+		question.Revision = 0
+		content[id] = question
+	}
+	return writeFileAtomic(path, content)
+}
+
+func readRevisionsFile(path string) (model.QuestionRevisions, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return model.QuestionRevisions{}, nil
+		}
+		return nil, fmt.Errorf("reading file %s: %v", path, err)
+	}
+	revisions := model.QuestionRevisions{}
+	if err := json.Unmarshal(content, &revisions); err != nil {
+		return nil, fmt.Errorf("decoding question revisions: %v", err)
+	}
+	return revisions, nil
+}
+
+// recordRevisions stamps the questions with their revisions and writes the
+// revisions file when new ones were recorded. The revisions are recorded on
+// a copy, returned to be swapped in once the questions are saved too.
+func recordRevisions(path string, revisions model.QuestionRevisions, questions model.QuestionMap) (model.QuestionRevisions, error) {
+	recorded := cloneRevisions(revisions)
+	if !recorded.Record(questions) {
+		return revisions, nil
+	}
+	if err := writeFileAtomic(path, recorded); err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+func cloneRevisions(revisions model.QuestionRevisions) model.QuestionRevisions {
+	cloned := make(model.QuestionRevisions, len(revisions))
+	for id, questionRevisions := range revisions {
+		cloned[id] = append([]model.Question(nil), questionRevisions...)
+	}
+	return cloned
+}
+
+func decodeQuestions(content []byte) (model.QuestionMap, error) {
+	questions := model.QuestionMap{}
+	if err := json.Unmarshal(content, &questions); err != nil {
+		return nil, fmt.Errorf("decoding json: %v", err)
+	}
+	return questions, nil
+}
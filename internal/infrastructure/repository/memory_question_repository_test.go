@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryQuestionRepositoryReload(t *testing.T) {
+	ctx := context.Background()
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(logs, nil))
+	dataDir := t.TempDir()
+	dataPath := filepath.Join(dataDir, "questions.json")
+
+	options := []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+	original := model.QuestionMap{
+		"1": {Label: "First?", Options: options},
+		"2": {Label: "Second?", Options: options},
+	}
+	require.NoError(t, writeFileAtomic(dataPath, original))
+
+	repo, err := NewMemoryQuestionRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	// edit rewrites the questions file with a later modification time, as
+	// two writes in quick succession may otherwise share one.
+	modTime := time.Now()
+	edit := func(content []byte) {
+		require.NoError(t, os.WriteFile(dataPath, content, 0644))
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(dataPath, modTime, modTime))
+		logs.Reset()
+		repo.reload()
+	}
+
+	t.Run("Unchanged File Is Not Reloaded", func(t *testing.T) {
+		repo.reload()
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("Valid Edit Is Swapped In", func(t *testing.T) {
+		edited := model.QuestionMap{
+			"1": {Label: "First, edited?", Options: options},
+			"2": {Label: "Second?", Options: options},
+			"3": {Label: "Third?", Options: options},
+		}
+		content, err := json.Marshal(edited)
+		require.NoError(t, err)
+		edit(content)
+
+		questions, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, model.QuestionMap{
+			"1": {Label: "First, edited?", Options: options, Revision: 2},
+			"2": {Label: "Second?", Options: options, Revision: 1},
+			"3": {Label: "Third?", Options: options, Revision: 1},
+		}, questions)
+		assert.Contains(t, logs.String(), `msg="questions reloaded" changes="added 3; changed 1"`)
+
+		previous, err := repo.GetQuestionRevision(ctx, "1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, model.Question{Label: "First?", Options: options, Revision: 1}, *previous)
+	})
+
+	t.Run("Invalid Edit Keeps Previous Questions", func(t *testing.T) {
+		before, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+
+		invalid := model.QuestionMap{"1": {Label: "First?", Options: []model.Option{{ID: "A", Label: "Yes"}, {ID: "B", Label: "No"}}}}
+		content, err := json.Marshal(invalid)
+		require.NoError(t, err)
+		edit(content)
+
+		questions, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before, questions)
+		assert.Contains(t, logs.String(), "correct-option-count")
+		assert.Contains(t, logs.String(), "keeping the previous ones")
+
+		// The same broken file is reported only once.
+		logs.Reset()
+		repo.reload()
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("Malformed Edit Keeps Previous Questions", func(t *testing.T) {
+		before, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+
+		edit([]byte(`{"1": {"label": "First?"`))
+
+		questions, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, before, questions)
+		assert.Contains(t, logs.String(), "decoding json")
+	})
+
+	t.Run("Saved Questions Are Not Reported As Edits", func(t *testing.T) {
+		require.NoError(t, repo.SaveQuestions(ctx, original))
+		logs.Reset()
+		repo.reload()
+		assert.Empty(t, logs.String())
+
+		questions, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, model.QuestionMap{
+			"1": {Label: "First?", Options: options, Revision: 3},
+			"2": {Label: "Second?", Options: options, Revision: 1},
+		}, questions)
+	})
+
+	t.Run("Revisions Survive A Restart", func(t *testing.T) {
+		content, err := os.ReadFile(dataPath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "revision")
+
+		reopened, err := NewMemoryQuestionRepository(logger, dataDir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		questions, err := reopened.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, questions["1"].Revision)
+		for revision, label := range map[int]string{1: "First?", 2: "First, edited?", 3: "First?"} {
+			question, err := reopened.GetQuestionRevision(ctx, "1", revision)
+			require.NoError(t, err)
+			assert.Equal(t, label, question.Label)
+		}
+		_, err = reopened.GetQuestionRevision(ctx, "1", 4)
+		assert.Error(t, err)
+	})
+
+	t.Run("Failed Save Records No Revision", func(t *testing.T) {
+		edited := model.QuestionMap{
+			"1": {Label: "First, saved?", Options: options},
+			"2": {Label: "Second?", Options: options},
+		}
+		// A directory in the way of the temporary questions file fails
+		// the save after the revisions file was written.
+		require.NoError(t, os.Mkdir(dataPath+".tmp", 0755))
+		assert.Error(t, repo.SaveQuestions(ctx, edited))
+		_, err := repo.GetQuestionRevision(ctx, "1", 4)
+		assert.Error(t, err)
+
+		require.NoError(t, os.Remove(dataPath+".tmp"))
+		require.NoError(t, repo.SaveQuestions(ctx, edited))
+		question, err := repo.GetQuestion(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 4, question.Revision)
+		_, err = repo.GetQuestionRevision(ctx, "1", 5)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// compactAfter is the number of logged changes that triggers a compaction
+// without waiting for the next tick.
+const compactAfter = 1000
+
+// MemoryUserRepository serves users from memory. Every change is appended to
+// a log next to the users snapshot before it is applied, and the log is
+// periodically folded into the snapshot. The snapshot has the same format
+// as the one used by UserRepository, so both can be used on the same data.
+type MemoryUserRepository struct {
+	mu           *sync.RWMutex
+	logger       *slog.Logger
+	users        model.UserMap
+	snapshotPath string
+	logPath      string
+	logFile      *os.File
+	pending      int
+	done         chan struct{}
+}
+
+func NewMemoryUserRepository(logger *slog.Logger, dataDir string, compactInterval time.Duration) (*MemoryUserRepository, error) {
+	ur := &MemoryUserRepository{
+		mu:           &sync.RWMutex{},
+		logger:       logger,
+		snapshotPath: filepath.Join(dataDir, "users.json"),
+		logPath:      filepath.Join(dataDir, "users.log"),
+		done:         make(chan struct{}),
+	}
+	if err := ur.load(); err != nil {
+		return nil, err
+	}
+	if err := ur.compact(); err != nil {
+		return nil, err
+	}
+	if compactInterval > 0 {
+		go ur.compactEvery(compactInterval)
+	}
+	return ur, nil
+}
+
+func (ur *MemoryUserRepository) CreateUser(ctx context.Context, user model.User) (*model.User, error) {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	user.ID = fmt.Sprint(len(ur.users) + 1)
+	if err := ur.apply(user); err != nil {
+		ur.logger.ErrorContext(ctx, "creating user", "error", err)
+		return nil, err
+	}
+	created := cloneUser(user)
+	return &created, nil
+}
+
+func (ur *MemoryUserRepository) UpdateUser(ctx context.Context, user *model.User) error {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+
+	if stored, exists := ur.users[user.ID]; exists && stored.Version != user.Version {
+		return &model.ConflictError{UserID: user.ID, Expected: user.Version, Actual: stored.Version}
+	}
+	updated := *user
+	updated.Version++
+	if err := ur.apply(updated); err != nil {
+		ur.logger.ErrorContext(ctx, "updating user", "error", err)
+		return err
+	}
+	user.Version = updated.Version
+	return nil
+}
+
+func (ur *MemoryUserRepository) GetUser(ctx context.Context, id string) (*model.User, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	user, exists := ur.users[id]
+	if !exists {
+		err := fmt.Errorf("user with id %s not found", id)
+		ur.logger.ErrorContext(ctx, "getting user", "error", err)
+		return nil, err
+	}
+	user = cloneUser(user)
+	return &user, nil
+}
+
+func (ur *MemoryUserRepository) GetAllUsers(ctx context.Context) (model.UserMap, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	users := make(model.UserMap, len(ur.users))
+	for id, user := range ur.users {
+		users[id] = cloneUser(user)
+	}
+	return users, nil
+}
+
+// ListUsers pages through the users without copying those left out of the
+// page.
+func (ur *MemoryUserRepository) ListUsers(ctx context.Context, query model.UserQuery) (*model.UserPage, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
+
+	page, err := model.ListUsers(ur.users, query)
+	if err != nil {
+		return nil, err
+	}
+	for i, user := range page.Users {
+		page.Users[i] = cloneUser(user)
+	}
+	return page, nil
+}
+
+// Close stops the periodic compaction and folds the log into the snapshot.
+func (ur *MemoryUserRepository) Close() error {
+	close(ur.done)
+
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+	if err := ur.compact(); err != nil {
+		return err
+	}
+	return ur.logFile.Close()
+}
+
+// apply logs the user and then stores it. It must be called with the write
+// lock held.
+func (ur *MemoryUserRepository) apply(user model.User) error {
+	line, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("encoding user: %v", err)
+	}
+	if _, err := ur.logFile.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("appending user to log: %v", err)
+	}
+	ur.users[user.ID] = cloneUser(user)
+	ur.pending++
+	if ur.pending >= compactAfter {
+		if err := ur.compact(); err != nil {
+			ur.logger.Error("compacting users log", "error", err)
+		}
+	}
+	return nil
+}
+
+// load reads the snapshot and replays the log written since it was taken.
+func (ur *MemoryUserRepository) load() error {
+	users, err := readUsersFile(ur.snapshotPath)
+	if err != nil {
+		return err
+	}
+	replayed, err := replayUsersLog(ur.logPath, users, ur.logger)
+	if err != nil {
+		return err
+	}
+	ur.users = users
+	ur.pending = replayed
+	return nil
+}
+
+// compact writes the users to a new snapshot and empties the log. It must be
+// called with the write lock held, or before the repository is shared.
+func (ur *MemoryUserRepository) compact() error {
+	if ur.logFile != nil && ur.pending == 0 {
+		return nil
+	}
+	if err := writeFileAtomic(ur.snapshotPath, ur.users); err != nil {
+		return err
+	}
+	if ur.logFile != nil {
+		ur.logFile.Close()
+	}
+	logFile, err := os.OpenFile(ur.logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening users log: %v", err)
+	}
+	ur.logFile = logFile
+	ur.pending = 0
+	return nil
+}
+
+func (ur *MemoryUserRepository) compactEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ur.mu.Lock()
+			if err := ur.compact(); err != nil {
+				ur.logger.Error("compacting users log", "error", err)
+			}
+			ur.mu.Unlock()
+		case <-ur.done:
+			return
+		}
+	}
+}
+
+// replayUsersLog applies the users logged at path to users and returns how
+// many were replayed.
+func replayUsersLog(path string, users model.UserMap, logger *slog.Logger) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("reading users log: %v", err)
+	}
+	replayed := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(nil, 10<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var user model.User
+		if err := json.Unmarshal(scanner.Bytes(), &user); err != nil {
+			// A crash while appending leaves a partial last line behind,
+			// everything before it is still valid.
+			logger.Error("skipping the rest of the users log", "line", line, "error", err)
+			break
+		}
+		users[user.ID] = user
+		replayed++
+	}
+	return replayed, scanner.Err()
+}
+
+func readUsersFile(path string) (model.UserMap, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(model.UserMap), nil
+		}
+		return nil, fmt.Errorf("reading users from file: %v", err)
+	}
+	users := model.UserMap{}
+	if len(content) == 0 {
+		return users, nil
+	}
+	if err := json.Unmarshal(content, &users); err != nil {
+		return nil, fmt.Errorf("decoding content to usermap: %v", err)
+	}
+	return users, nil
+}
+
+// writeFileAtomic replaces path with the json encoding of v, so readers
+// never see a half written file.
+func writeFileAtomic(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %v", filepath.Base(path), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("writing %s: %v", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func cloneUser(user model.User) model.User {
+	user.Answers = append([]model.Answer(nil), user.Answers...)
+	return user
+}
+
+func (ur *MemoryUserRepository) lock() func() {
+	ur.mu.Lock()
+	return ur.mu.Unlock
+}
+
+func (ur *MemoryUserRepository) dumpUsers() (model.UserMap, error) {
+	users := make(model.UserMap, len(ur.users))
+	for id, user := range ur.users {
+		users[id] = cloneUser(user)
+	}
+	return users, nil
+}
+
+// restoreUsers replaces the users and compacts right away, so the log
+// written before the restore is never replayed over the restored users.
+func (ur *MemoryUserRepository) restoreUsers(users model.UserMap) error {
+	ur.users = make(model.UserMap, len(users))
+	for id, user := range users {
+		ur.users[id] = cloneUser(user)
+	}
+	ur.pending++
+	return ur.compact()
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUserRepository(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+
+	repo, err := NewMemoryUserRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+
+	created, err := repo.CreateUser(ctx, model.User{Name: "Ana"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", created.ID)
+	created.Answers = []model.Answer{{QuestionID: "1", Option: model.Option{ID: "A"}}}
+	require.NoError(t, repo.UpdateUser(ctx, created))
+	_, err = repo.CreateUser(ctx, model.User{Name: "Bob"})
+	require.NoError(t, err)
+
+	t.Run("Reads Are Isolated From Callers", func(t *testing.T) {
+		user, err := repo.GetUser(ctx, "1")
+		require.NoError(t, err)
+		user.Answers[0].Option.ID = "B"
+
+		stored, err := repo.GetUser(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "A", stored.Answers[0].Option.ID)
+	})
+
+	t.Run("Changes Are Replayed From The Log", func(t *testing.T) {
+		// Simulate a crash in the middle of appending a change.
+		logFile, err := os.OpenFile(filepath.Join(dataDir, "users.log"), os.O_APPEND|os.O_WRONLY, 0644)
+		require.NoError(t, err)
+		_, err = logFile.WriteString(`{"id":"2","name":"Bo`)
+		require.NoError(t, err)
+		require.NoError(t, logFile.Close())
+
+		reloaded, err := NewMemoryUserRepository(logger, dataDir, 0)
+		require.NoError(t, err)
+		users, err := reloaded.GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, model.UserMap{
+			"1": {ID: "1", Name: "Ana", Answers: []model.Answer{{QuestionID: "1", Option: model.Option{ID: "A"}}}, Version: 1},
+			"2": {ID: "2", Name: "Bob"},
+		}, users)
+	})
+
+	t.Run("Close Compacts The Log Into The Snapshot", func(t *testing.T) {
+		require.NoError(t, repo.Close())
+
+		content, err := os.ReadFile(filepath.Join(dataDir, "users.log"))
+		require.NoError(t, err)
+		assert.Empty(t, content)
+		users, err := NewUserRepository(logger, dataDir).GetAllUsers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+	})
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// Migration upgrades data stored with schema version Version-1 to Version.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(data *model.Backup) error
+}
+
+// migrations holds every schema change, oldest first. Adding a migration
+// means bumping model.SchemaVersion to its version.
+var migrations = []Migration{
+	{
+		Version:     2,
+		Description: "record question revisions and stamp answers with the revision answered",
+		Migrate:     stampAnswerRevisions,
+	},
+	{
+		Version:     3,
+		Description: "give every question an explicit position, in id order",
+		Migrate:     positionQuestions,
+	},
+}
+
+// schemaFile holds the schema version of a data directory. Directories
+// written before it existed are version 1.
+const schemaFile = "schema.json"
+
+type schema struct {
+	SchemaVersion int       `json:"schema_version"`
+	MigratedAt    time.Time `json:"migrated_at"`
+}
+
+// Migrate upgrades the data directory to model.SchemaVersion. The files are
+// copied to a backups directory before anything is changed. It must run
+// before the repositories are opened.
+func Migrate(logger *slog.Logger, dataDir string) error {
+	version, err := readSchemaVersion(dataDir)
+	if err != nil {
+		return err
+	}
+	if version == model.SchemaVersion {
+		return nil
+	}
+	if version > model.SchemaVersion {
+		return fmt.Errorf("data has schema version %d, this server supports up to %d", version, model.SchemaVersion)
+	}
+
+	now := time.Now().UTC()
+	backupDir := filepath.Join(dataDir, "backups", fmt.Sprintf("schema-v%d-%s", version, now.Format("20060102-150405")))
+	if err := copyDataFiles(dataDir, backupDir); err != nil {
+		return fmt.Errorf("backing up data before migrating: %v", err)
+	}
+	logger.Info("migrating data", "from", version, "to", model.SchemaVersion, "backup", backupDir)
+
+	data, err := readDataDir(logger, dataDir)
+	if err != nil {
+		return err
+	}
+	data.SchemaVersion = version
+	if err := migrate(logger, data); err != nil {
+		return err
+	}
+	if err := writeDataDir(dataDir, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dataDir, schemaFile), schema{SchemaVersion: data.SchemaVersion, MigratedAt: now})
+}
+
+// migrate applies the migrations the data is missing.
+func migrate(logger *slog.Logger, data *model.Backup) error {
+	for _, migration := range migrations {
+		if migration.Version <= data.SchemaVersion {
+			continue
+		}
+		if err := migration.Migrate(data); err != nil {
+			return fmt.Errorf("migrating to schema version %d: %v", migration.Version, err)
+		}
+		data.SchemaVersion = migration.Version
+		logger.Info("migrated data", "schema_version", migration.Version, "description", migration.Description)
+	}
+	return nil
+}
+
+func readSchemaVersion(dataDir string) (int, error) {
+	content, err := os.ReadFile(filepath.Join(dataDir, schemaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("reading schema version: %v", err)
+	}
+	var s schema
+	if err := json.Unmarshal(content, &s); err != nil {
+		return 0, fmt.Errorf("decoding schema version: %v", err)
+	}
+	return s.SchemaVersion, nil
+}
+
+// readDataDir reads every data file, folding the users log of the memory
+// storage into the users.
+func readDataDir(logger *slog.Logger, dataDir string) (*model.Backup, error) {
+	data := &model.Backup{}
+	var err error
+	if data.Users, err = readUsersFile(filepath.Join(dataDir, "users.json")); err != nil {
+		return nil, err
+	}
+	if _, err = replayUsersLog(filepath.Join(dataDir, "users.log"), data.Users, logger); err != nil {
+		return nil, err
+	}
+	if data.Questions, err = readQuestionsFile(filepath.Join(dataDir, "questions.json")); err != nil {
+		return nil, err
+	}
+	if data.QuestionRevisions, err = readRevisionsFile(filepath.Join(dataDir, "question_revisions.json")); err != nil {
+		return nil, err
+	}
+	if data.Events, err = readEventsFile(filepath.Join(dataDir, "events.jsonl")); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func writeDataDir(dataDir string, data *model.Backup) error {
+	if err := writeFileAtomic(filepath.Join(dataDir, "users.json"), data.Users); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dataDir, "users.log")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing users log: %v", err)
+	}
+	if err := writeQuestionsFile(filepath.Join(dataDir, "questions.json"), data.Questions); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dataDir, "question_revisions.json"), data.QuestionRevisions); err != nil {
+		return err
+	}
+	return writeEventsFile(filepath.Join(dataDir, "events.jsonl"), data.Events)
+}
+
+// copyDataFiles copies the files of dataDir, but not its directories, to dst.
+func copyDataFiles(dataDir, dst string) error {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(dataDir, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// stampAnswerRevisions attributes the answers given before questions had
+// revisions to the revision current at the time of the migration, which is
+// the closest to what was answered that is known.
+func stampAnswerRevisions(data *model.Backup) error {
+	if data.QuestionRevisions == nil {
+		data.QuestionRevisions = model.QuestionRevisions{}
+	}
+	data.QuestionRevisions.Record(data.Questions)
+
+	for id, user := range data.Users {
+		for i, answer := range user.Answers {
+			if answer.QuestionRevision == 0 {
+				user.Answers[i].QuestionRevision = data.Questions[answer.QuestionID].Revision
+			}
+		}
+		data.Users[id] = user
+	}
+	for i, event := range data.Events {
+		if event.Option != nil && event.QuestionRevision == 0 {
+			data.Events[i].QuestionRevision = data.Questions[event.QuestionID].Revision
+		}
+	}
+	return nil
+}
+
+// positionQuestions numbers the questions in the order they were asked
+// before they had positions, after any position already set by hand.
+func positionQuestions(data *model.Backup) error {
+	next := data.Questions.MaxPosition() + 1
+	for _, id := range data.Questions.Order() {
+		question := data.Questions[id]
+		if question.Position == 0 {
+			question.Position = next
+			data.Questions[id] = question
+			next++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsRegistry(t *testing.T) {
+	for i, migration := range migrations {
+		assert.Equal(t, i+2, migration.Version, "migrations must be sorted and without gaps")
+		assert.NotEmpty(t, migration.Description)
+	}
+	assert.Equal(t, len(migrations)+1, model.SchemaVersion, "model.SchemaVersion must be the version of the last migration")
+}
+
+// TestMigrate loads a data directory written by each schema version and
+// checks it reads the same as one written by the current version.
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.Discard()
+	finishedAt := time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		fixture        string
+		expectedBackup bool
+		users          model.UserMap
+		answeredEvents []int
+		order          []string
+	}{
+		{
+			fixture:        "v1",
+			expectedBackup: true,
+			users: model.UserMap{
+				"1": {ID: "1", Name: "Flor", Score: 0.5, FinishedQuiz: true, Answers: []model.Answer{
+					{QuestionID: "1", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Paris", IsCorrect: true}},
+					{QuestionID: "2", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Charles Dickens"}},
+				}},
+				"2": {ID: "2", Name: "Maria", Answers: []model.Answer{
+					{QuestionID: "2", QuestionRevision: 1, Option: model.Option{ID: "B", Label: "William Shakespeare", IsCorrect: true}},
+				}},
+			},
+			answeredEvents: []int{1},
+			order:          []string{"1", "2"},
+		},
+		{
+			fixture:        "v2",
+			expectedBackup: true,
+			users: model.UserMap{
+				"1": {ID: "1", Name: "Flor", Score: 0.5, FinishedQuiz: true, FinishedAt: &finishedAt, Answers: []model.Answer{
+					{QuestionID: "1", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Paris", IsCorrect: true}},
+					{QuestionID: "2", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Charles Dickens"}},
+				}},
+			},
+			order: []string{"1", "2"},
+		},
+		{
+			fixture: "v3",
+			users: model.UserMap{
+				"1": {ID: "1", Name: "Flor", Score: 0.5, FinishedQuiz: true, FinishedAt: &finishedAt, Answers: []model.Answer{
+					{QuestionID: "1", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Paris", IsCorrect: true}},
+					{QuestionID: "2", QuestionRevision: 1, Option: model.Option{ID: "A", Label: "Charles Dickens"}},
+				}},
+			},
+			order: []string{"2", "1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			dataDir := t.TempDir()
+			require.NoError(t, copyDataFiles(filepath.Join("testdata", "schema", tt.fixture), dataDir))
+
+			require.NoError(t, Migrate(logger, dataDir))
+
+			version, err := readSchemaVersion(dataDir)
+			require.NoError(t, err)
+			assert.Equal(t, model.SchemaVersion, version)
+			backups, _ := os.ReadDir(filepath.Join(dataDir, "backups"))
+			if tt.expectedBackup {
+				require.Len(t, backups, 1)
+				original, err := os.ReadFile(filepath.Join("testdata", "schema", tt.fixture, "users.json"))
+				require.NoError(t, err)
+				backedUp, err := os.ReadFile(filepath.Join(dataDir, "backups", backups[0].Name(), "users.json"))
+				require.NoError(t, err)
+				assert.Equal(t, original, backedUp)
+			} else {
+				assert.Empty(t, backups)
+			}
+
+			users, err := NewMemoryUserRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			defer users.Close()
+			allUsers, err := users.GetAllUsers(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.users, allUsers)
+
+			questions, err := NewMemoryQuestionRepository(logger, dataDir, 0)
+			require.NoError(t, err)
+			defer questions.Close()
+			question, err := questions.GetQuestionRevision(ctx, "2", 1)
+			require.NoError(t, err)
+			assert.Equal(t, "Who wrote Hamlet?", question.Label)
+			allQuestions, err := questions.GetAllQuestions(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.order, allQuestions.Order())
+			assert.Equal(t, len(allQuestions), allQuestions.MaxPosition(), "every question has a position")
+
+			events, err := readEventsFile(filepath.Join(dataDir, "events.jsonl"))
+			require.NoError(t, err)
+			var revisions []int
+			for _, event := range events {
+				if event.Option != nil {
+					revisions = append(revisions, event.QuestionRevision)
+				}
+			}
+			assert.Equal(t, tt.answeredEvents, revisions)
+
+			// Migrating again is a no-op.
+			require.NoError(t, Migrate(logger, dataDir))
+			again, _ := os.ReadDir(filepath.Join(dataDir, "backups"))
+			assert.Len(t, again, len(backups))
+		})
+	}
+
+	t.Run("Newer Schema Is Refused", func(t *testing.T) {
+		dataDir := t.TempDir()
+		require.NoError(t, writeFileAtomic(filepath.Join(dataDir, schemaFile), schema{SchemaVersion: model.SchemaVersion + 1}))
+
+		assert.ErrorContains(t, Migrate(logger, dataDir), "supports up to")
+	})
+}
+
+func TestRestoreMigratedOlderBackups(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.Discard()
+	dataDir := t.TempDir()
+	require.NoError(t, copyDataFiles(filepath.Join("testdata", "schema", "v2"), dataDir))
+	users, questions, events := NewUserRepository(logger, dataDir), NewQuestionRepository(logger, dataDir), NewEventRepository(logger, dataDir)
+	backups, err := NewBackupRepository(logger, users, questions, events)
+	require.NoError(t, err)
+
+	v1, err := readDataDir(logger, filepath.Join("testdata", "schema", "v1"))
+	require.NoError(t, err)
+	v1.SchemaVersion = 1
+	assert.ErrorContains(t, backups.Restore(ctx, *v1), "must be migrated")
+	require.NoError(t, backups.Migrate(ctx, v1))
+	require.NoError(t, backups.Restore(ctx, *v1))
+
+	restored, err := users.GetUser(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored.Answers[0].QuestionRevision)
+}
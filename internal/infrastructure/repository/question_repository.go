@@ -4,26 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
 )
 
 type QuestionRepository struct {
-	mu       *sync.RWMutex
-	logger   *log.Logger
-	dataPath string
+	mu            *sync.RWMutex
+	logger        *slog.Logger
+	dataPath      string
+	revisionsPath string
 }
 
-func NewQuestionRepository(logger *log.Logger) model.QuestionRepository {
+func NewQuestionRepository(logger *slog.Logger, dataDir string) model.QuestionRepository {
 	mu := &sync.RWMutex{}
-	dataPath := "./db/questions.json"
+	dataPath := filepath.Join(dataDir, "questions.json")
+	revisionsPath := filepath.Join(dataDir, "question_revisions.json")
 	return &QuestionRepository{
-		mu:       mu,
-		logger:   logger,
-		dataPath: dataPath,
+		mu:            mu,
+		logger:        logger,
+		dataPath:      dataPath,
+		revisionsPath: revisionsPath,
 	}
 }
 
@@ -31,45 +35,138 @@ func (qr *QuestionRepository) GetAllQuestions(ctx context.Context) (model.Questi
 	qr.mu.RLock()
 	defer qr.mu.RUnlock()
 
-	questions, err := qr.readQuestionsFromFile()
+	questions, _, err := qr.readQuestionsFromFile()
 	if err != nil {
-		qr.logger.Printf("error: getting questions: %v", err)
+		qr.logger.ErrorContext(ctx, "getting questions", "error", err)
 		return nil, err
 	}
 
 	return questions, nil
 }
 
+func (qr *QuestionRepository) ListQuestions(ctx context.Context, query model.QuestionQuery) (*model.QuestionPage, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	questions, _, err := qr.readQuestionsFromFile()
+	if err != nil {
+		qr.logger.ErrorContext(ctx, "listing questions", "error", err)
+		return nil, err
+	}
+
+	return model.ListQuestions(questions, query)
+}
+
 func (qr *QuestionRepository) GetQuestion(ctx context.Context, id string) (*model.Question, error) {
 	qr.mu.RLock()
 	defer qr.mu.RUnlock()
 
-	questions, err := qr.readQuestionsFromFile()
+	questions, _, err := qr.readQuestionsFromFile()
 	if err != nil {
-		qr.logger.Printf("error: getting question: %v", err)
+		qr.logger.ErrorContext(ctx, "getting question", "error", err)
 		return nil, err
 	}
 
 	question, exists := questions[id]
 	if !exists {
 		err = fmt.Errorf("question with id %s not found", id)
-		qr.logger.Printf("error: getting question: %v", err)
+		qr.logger.ErrorContext(ctx, "getting question", "error", err)
 		return nil, err
 	}
 
 	return &question, nil
 }
 
-func (qr *QuestionRepository) readQuestionsFromFile() (model.QuestionMap, error) {
-	fileContent, err := os.ReadFile(qr.dataPath)
+func (qr *QuestionRepository) GetQuestionRevision(ctx context.Context, id string, revision int) (*model.Question, error) {
+	qr.mu.RLock()
+	defer qr.mu.RUnlock()
+
+	_, revisions, err := qr.readQuestionsFromFile()
 	if err != nil {
-		return nil, fmt.Errorf("reading file %s: %v", qr.dataPath, err)
+		qr.logger.ErrorContext(ctx, "getting question revision", "error", err)
+		return nil, err
 	}
 
-	questions := model.QuestionMap{}
-	if err := json.Unmarshal(fileContent, &questions); err != nil {
-		return nil, fmt.Errorf("decoding json: %v", err)
+	question, exists := revisions.Get(id, revision)
+	if !exists {
+		err = fmt.Errorf("question with id %s has no revision %d", id, revision)
+		qr.logger.ErrorContext(ctx, "getting question revision", "error", err)
+		return nil, err
 	}
 
-	return questions, nil
+	return &question, nil
+}
+
+func (qr *QuestionRepository) SaveQuestions(ctx context.Context, questions model.QuestionMap) error {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+
+	revisions, err := readRevisionsFile(qr.revisionsPath)
+	if err != nil {
+		qr.logger.ErrorContext(ctx, "saving questions", "error", err)
+		return err
+	}
+	saved := make(model.QuestionMap, len(questions))
+	for id, question := range questions {
+		saved[id] = question
+	}
+	if _, err := recordRevisions(qr.revisionsPath, revisions, saved); err != nil {
+		qr.logger.ErrorContext(ctx, "saving questions", "error", err)
+		return err
+	}
+	if err := qr.writeQuestionsToFile(saved); err != nil {
+		qr.logger.ErrorContext(ctx, "saving questions", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// readQuestionsFromFile reads the questions stamped with their revisions.
+// Questions edited in the file since they were saved are given the
+// revision they will be recorded with on the next save, without writing.
+func (qr *QuestionRepository) readQuestionsFromFile() (model.QuestionMap, model.QuestionRevisions, error) {
+	questions, err := readQuestionsFile(qr.dataPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions, err := readRevisionsFile(qr.revisionsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	revisions.Record(questions)
+	return questions, revisions, nil
+}
+
+func (qr *QuestionRepository) writeQuestionsToFile(questions model.QuestionMap) error {
+	content := make(model.QuestionMap, len(questions))
+	for id, question := range questions {
+		question.Revision = 0
+		content[id] = question
+	}
+	encoded, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding questions: %v", err)
+	}
+
+	if err := os.WriteFile(qr.dataPath, encoded, 0644); err != nil {
+		return fmt.Errorf("writing questions to file: %v", err)
+	}
+	return nil
+}
+
+func (qr *QuestionRepository) lock() func() {
+	qr.mu.Lock()
+	return qr.mu.Unlock
+}
+
+func (qr *QuestionRepository) dumpQuestions() (model.QuestionMap, model.QuestionRevisions, error) {
+	return qr.readQuestionsFromFile()
+}
+
+func (qr *QuestionRepository) restoreQuestions(questions model.QuestionMap, revisions model.QuestionRevisions) error {
+	if err := writeFileAtomic(qr.revisionsPath, revisions); err != nil {
+		return err
+	}
+	return writeQuestionsFile(qr.dataPath, questions)
 }
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuestionRepositoryRevisions(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	revisionsPath := filepath.Join(dataDir, "question_revisions.json")
+	options := []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}}
+	require.NoError(t, writeQuestionsFile(filepath.Join(dataDir, "questions.json"), model.QuestionMap{
+		"1": {Label: "First?", Options: options},
+	}))
+	repo := NewQuestionRepository(logging.Discard(), dataDir)
+
+	t.Run("Reads Write Nothing", func(t *testing.T) {
+		questions, err := repo.GetAllQuestions(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, questions["1"].Revision)
+		question, err := repo.GetQuestionRevision(ctx, "1", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "First?", question.Label)
+
+		_, err = os.Stat(revisionsPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Saves Record Revisions", func(t *testing.T) {
+		require.NoError(t, repo.SaveQuestions(ctx, model.QuestionMap{"1": {Label: "First, edited?", Options: options}}))
+
+		revisions, err := readRevisionsFile(revisionsPath)
+		require.NoError(t, err)
+		assert.Len(t, revisions["1"], 1)
+		question, err := repo.GetQuestion(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, question.Revision)
+	})
+}
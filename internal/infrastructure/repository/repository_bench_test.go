@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+)
+
+const benchmarkUsers = 500
+
+func newBenchmarkData(b *testing.B) string {
+	b.Helper()
+	dataDir := b.TempDir()
+	questions := model.QuestionMap{}
+	for i := 1; i <= 10; i++ {
+		questions[fmt.Sprint(i)] = model.Question{
+			Label: fmt.Sprintf("Question %d?", i),
+			Options: []model.Option{
+				{ID: "A", Label: "Right", IsCorrect: true},
+				{ID: "B", Label: "Wrong"},
+			},
+		}
+	}
+	users := model.UserMap{}
+	for i := 1; i <= benchmarkUsers; i++ {
+		user := model.User{ID: fmt.Sprint(i), Name: fmt.Sprintf("User %d", i)}
+		for id, question := range questions {
+			user.Answers = append(user.Answers, model.Answer{QuestionID: id, Option: question.Options[0]})
+		}
+		users[user.ID] = user
+	}
+	if err := writeFileAtomic(dataDir+"/questions.json", questions); err != nil {
+		b.Fatal(err)
+	}
+	if err := writeFileAtomic(dataDir+"/users.json", users); err != nil {
+		b.Fatal(err)
+	}
+	return dataDir
+}
+
+type benchmarkRepositories struct {
+	name      string
+	users     model.UserRepository
+	questions model.QuestionRepository
+}
+
+func newBenchmarkRepositories(b *testing.B) []benchmarkRepositories {
+	logger := logging.Discard()
+
+	jsonDir := newBenchmarkData(b)
+	memoryDir := newBenchmarkData(b)
+	memoryUsers, err := NewMemoryUserRepository(logger, memoryDir, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { memoryUsers.Close() })
+	memoryQuestions, err := NewMemoryQuestionRepository(logger, memoryDir, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return []benchmarkRepositories{
+		{name: "JSON", users: NewUserRepository(logger, jsonDir), questions: NewQuestionRepository(logger, jsonDir)},
+		{name: "Memory", users: memoryUsers, questions: memoryQuestions},
+	}
+}
+
+func BenchmarkGetUser(b *testing.B) {
+	ctx := context.Background()
+	for _, repos := range newBenchmarkRepositories(b) {
+		b.Run(repos.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repos.users.GetUser(ctx, fmt.Sprint(i%benchmarkUsers+1)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetAllUsers(b *testing.B) {
+	ctx := context.Background()
+	for _, repos := range newBenchmarkRepositories(b) {
+		b.Run(repos.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repos.users.GetAllUsers(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUpdateUser(b *testing.B) {
+	ctx := context.Background()
+	for _, repos := range newBenchmarkRepositories(b) {
+		b.Run(repos.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				user := &model.User{ID: fmt.Sprint(i%benchmarkUsers + 1), Name: "Updated"}
+				if err := repos.users.UpdateUser(ctx, user); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetAllQuestions(b *testing.B) {
+	ctx := context.Background()
+	for _, repos := range newBenchmarkRepositories(b) {
+		b.Run(repos.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repos.questions.GetAllQuestions(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/MFCaballero/simple-quiz/internal/domain/model"
@@ -13,13 +14,13 @@ import (
 
 type UserRepository struct {
 	mu       *sync.RWMutex
-	logger   *log.Logger
+	logger   *slog.Logger
 	dataPath string
 }
 
-func NewUserRepository(logger *log.Logger) model.UserRepository {
+func NewUserRepository(logger *slog.Logger, dataDir string) model.UserRepository {
 	mu := &sync.RWMutex{}
-	dataPath := "./db/users.json"
+	dataPath := filepath.Join(dataDir, "users.json")
 	return &UserRepository{
 		mu:       mu,
 		logger:   logger,
@@ -33,7 +34,7 @@ func (ur *UserRepository) CreateUser(ctx context.Context, user model.User) (*mod
 
 	users, err := ur.readUsersFromFile()
 	if err != nil {
-		ur.logger.Printf("error: creating user: %v", err)
+		ur.logger.ErrorContext(ctx, "creating user", "error", err)
 		return nil, err
 	}
 
@@ -42,7 +43,7 @@ func (ur *UserRepository) CreateUser(ctx context.Context, user model.User) (*mod
 	users[userID] = user
 
 	if err := ur.writeUsersToFile(users); err != nil {
-		ur.logger.Printf("error: creating user: %v", err)
+		ur.logger.ErrorContext(ctx, "creating user", "error", err)
 		return nil, err
 	}
 	return &user, err
@@ -54,15 +55,21 @@ func (ur *UserRepository) UpdateUser(ctx context.Context, user *model.User) erro
 
 	users, err := ur.readUsersFromFile()
 	if err != nil {
-		ur.logger.Printf("error: updating user: %v", err)
+		ur.logger.ErrorContext(ctx, "updating user", "error", err)
 		return err
 	}
 
-	users[user.ID] = *user
+	if stored, exists := users[user.ID]; exists && stored.Version != user.Version {
+		return &model.ConflictError{UserID: user.ID, Expected: user.Version, Actual: stored.Version}
+	}
+	updated := *user
+	updated.Version++
+	users[user.ID] = updated
 	if err := ur.writeUsersToFile(users); err != nil {
-		ur.logger.Printf("error: updating user: %v", err)
+		ur.logger.ErrorContext(ctx, "updating user", "error", err)
 		return err
 	}
+	user.Version = updated.Version
 
 	return nil
 }
@@ -73,14 +80,14 @@ func (ur *UserRepository) GetUser(ctx context.Context, id string) (*model.User,
 
 	users, err := ur.readUsersFromFile()
 	if err != nil {
-		ur.logger.Printf("error: getting user: %v", err)
+		ur.logger.ErrorContext(ctx, "getting user", "error", err)
 		return nil, err
 	}
 
 	user, exists := users[id]
 	if !exists {
 		err = fmt.Errorf("user with id %s not found", id)
-		ur.logger.Printf("error: getting user: %v", err)
+		ur.logger.ErrorContext(ctx, "getting user", "error", err)
 		return nil, err
 	}
 
@@ -93,33 +100,28 @@ func (ur *UserRepository) GetAllUsers(ctx context.Context) (model.UserMap, error
 
 	users, err := ur.readUsersFromFile()
 	if err != nil {
-		ur.logger.Printf("error: getting all users: %v", err)
+		ur.logger.ErrorContext(ctx, "getting all users", "error", err)
 		return nil, err
 	}
 
 	return users, nil
 }
 
-func (ur *UserRepository) readUsersFromFile() (model.UserMap, error) {
-	content, err := os.ReadFile(ur.dataPath)
+func (ur *UserRepository) ListUsers(ctx context.Context, query model.UserQuery) (*model.UserPage, error) {
+	ur.mu.RLock()
+	defer ur.mu.RUnlock()
 
+	users, err := ur.readUsersFromFile()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return make(model.UserMap), nil
-		}
-		return nil, fmt.Errorf("reading users from file: % v", err)
-	}
-
-	if len(content) == 0 {
-		return make(model.UserMap), nil
+		ur.logger.ErrorContext(ctx, "listing users", "error", err)
+		return nil, err
 	}
 
-	users := model.UserMap{}
-	if err := json.Unmarshal(content, &users); err != nil {
-		return nil, fmt.Errorf("decoding content to usermap: % v", err)
-	}
+	return model.ListUsers(users, query)
+}
 
-	return users, nil
+func (ur *UserRepository) readUsersFromFile() (model.UserMap, error) {
+	return readUsersFile(ur.dataPath)
 }
 
 func (ur *UserRepository) writeUsersToFile(users model.UserMap) error {
@@ -133,3 +135,16 @@ func (ur *UserRepository) writeUsersToFile(users model.UserMap) error {
 	}
 	return nil
 }
+
+func (ur *UserRepository) lock() func() {
+	ur.mu.Lock()
+	return ur.mu.Unlock
+}
+
+func (ur *UserRepository) dumpUsers() (model.UserMap, error) {
+	return ur.readUsersFromFile()
+}
+
+func (ur *UserRepository) restoreUsers(users model.UserMap) error {
+	return writeFileAtomic(ur.dataPath, users)
+}
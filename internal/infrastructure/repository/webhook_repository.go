@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+)
+
+// deliveriesKept bounds how many delivered or failed deliveries are kept
+// for the delivery log. Pending deliveries are always kept.
+const deliveriesKept = 1000
+
+// WebhookRepository stores the webhooks and their deliveries in a JSON file,
+// so deliveries still pending when the server stops are sent once it is
+// back.
+type WebhookRepository struct {
+	mu       *sync.Mutex
+	logger   *slog.Logger
+	dataPath string
+}
+
+type webhooksFile struct {
+	WebhookSequence  int              `json:"webhook_sequence"`
+	DeliverySequence int              `json:"delivery_sequence"`
+	Webhooks         []model.Webhook  `json:"webhooks"`
+	Deliveries       []model.Delivery `json:"deliveries"`
+}
+
+func NewWebhookRepository(logger *slog.Logger, dataDir string) model.WebhookRepository {
+	return &WebhookRepository{
+		mu:       &sync.Mutex{},
+		logger:   logger,
+		dataPath: filepath.Join(dataDir, "webhooks.json"),
+	}
+}
+
+func (wr *WebhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (*model.Webhook, error) {
+	var created model.Webhook
+	err := wr.update(func(file *webhooksFile) error {
+		file.WebhookSequence++
+		webhook.ID = strconv.Itoa(file.WebhookSequence)
+		file.Webhooks = append(file.Webhooks, webhook)
+		created = webhook
+		return nil
+	})
+	if err != nil {
+		wr.logger.ErrorContext(ctx, "creating webhook", "error", err)
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (wr *WebhookRepository) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	file, err := wr.readFile()
+	if err != nil {
+		wr.logger.ErrorContext(ctx, "getting webhooks", "error", err)
+		return nil, err
+	}
+	return file.Webhooks, nil
+}
+
+func (wr *WebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	err := wr.update(func(file *webhooksFile) error {
+		for i, webhook := range file.Webhooks {
+			if webhook.ID == id {
+				file.Webhooks = append(file.Webhooks[:i], file.Webhooks[i+1:]...)
+				return nil
+			}
+		}
+		return model.ErrWebhookNotFound
+	})
+	if err != nil && err != model.ErrWebhookNotFound {
+		wr.logger.ErrorContext(ctx, "deleting webhook", "error", err)
+	}
+	return err
+}
+
+func (wr *WebhookRepository) AddDeliveries(ctx context.Context, deliveries []model.Delivery) ([]model.Delivery, error) {
+	added := make([]model.Delivery, len(deliveries))
+	err := wr.update(func(file *webhooksFile) error {
+		for i, delivery := range deliveries {
+			file.DeliverySequence++
+			delivery.ID = strconv.Itoa(file.DeliverySequence)
+			file.Deliveries = append(file.Deliveries, delivery)
+			added[i] = delivery
+		}
+		return nil
+	})
+	if err != nil {
+		wr.logger.ErrorContext(ctx, "adding deliveries", "error", err)
+		return nil, err
+	}
+	return added, nil
+}
+
+func (wr *WebhookRepository) UpdateDelivery(ctx context.Context, delivery model.Delivery) error {
+	err := wr.update(func(file *webhooksFile) error {
+		for i := range file.Deliveries {
+			if file.Deliveries[i].ID == delivery.ID {
+				file.Deliveries[i] = delivery
+				file.Deliveries = pruneDeliveries(file.Deliveries)
+				return nil
+			}
+		}
+		return fmt.Errorf("delivery with id %s not found", delivery.ID)
+	})
+	if err != nil {
+		wr.logger.ErrorContext(ctx, "updating delivery", "error", err)
+	}
+	return err
+}
+
+func (wr *WebhookRepository) GetDeliveries(ctx context.Context) ([]model.Delivery, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	file, err := wr.readFile()
+	if err != nil {
+		wr.logger.ErrorContext(ctx, "getting deliveries", "error", err)
+		return nil, err
+	}
+	deliveries := make([]model.Delivery, len(file.Deliveries))
+	for i, delivery := range file.Deliveries {
+		deliveries[len(deliveries)-1-i] = delivery
+	}
+	return deliveries, nil
+}
+
+// update applies change to the stored webhooks and saves them, unless change
+// fails.
+func (wr *WebhookRepository) update(change func(file *webhooksFile) error) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	file, err := wr.readFile()
+	if err != nil {
+		return err
+	}
+	if err := change(file); err != nil {
+		return err
+	}
+	return writeFileAtomic(wr.dataPath, file)
+}
+
+func (wr *WebhookRepository) readFile() (*webhooksFile, error) {
+	file := &webhooksFile{Webhooks: []model.Webhook{}, Deliveries: []model.Delivery{}}
+	content, err := os.ReadFile(wr.dataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		return nil, fmt.Errorf("reading webhooks from file: %v", err)
+	}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("decoding webhooks: %v", err)
+	}
+	// The file is indented, payloads are given back as they were added.
+	for i, delivery := range file.Deliveries {
+		payload := &bytes.Buffer{}
+		if err := json.Compact(payload, delivery.Payload); err != nil {
+			return nil, fmt.Errorf("decoding payload of delivery %s: %v", delivery.ID, err)
+		}
+		file.Deliveries[i].Payload = payload.Bytes()
+	}
+	return file, nil
+}
+
+// pruneDeliveries drops the oldest finished deliveries past deliveriesKept.
+func pruneDeliveries(deliveries []model.Delivery) []model.Delivery {
+	finished := 0
+	for _, delivery := range deliveries {
+		if delivery.Status != model.DeliveryPending {
+			finished++
+		}
+	}
+	if finished <= deliveriesKept {
+		return deliveries
+	}
+	kept := deliveries[:0]
+	for _, delivery := range deliveries {
+		if delivery.Status != model.DeliveryPending && finished > deliveriesKept {
+			finished--
+			continue
+		}
+		kept = append(kept, delivery)
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	repo := NewWebhookRepository(logging.Discard(), dataDir)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	webhooks, err := repo.GetWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	hr, err := repo.CreateWebhook(ctx, model.Webhook{URL: "https://hr.example.com/quiz", Events: []string{"quiz.finished"}, Secret: "s3cret", CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, "1", hr.ID)
+	chat, err := repo.CreateWebhook(ctx, model.Webhook{URL: "https://chat.example.com/hook", Events: []string{"quiz.finished"}, Secret: "other", CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, "2", chat.ID)
+
+	deliveries, err := repo.AddDeliveries(ctx, []model.Delivery{
+		{WebhookID: hr.ID, Event: "quiz.finished", Payload: json.RawMessage(`{"a":1}`), Status: model.DeliveryPending, CreatedAt: now, NextAttemptAt: &now},
+		{WebhookID: chat.ID, Event: "quiz.finished", Payload: json.RawMessage(`{"a":1}`), Status: model.DeliveryPending, CreatedAt: now, NextAttemptAt: &now},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1", deliveries[0].ID)
+	assert.Equal(t, "2", deliveries[1].ID)
+
+	delivered := deliveries[0]
+	delivered.Status = model.DeliveryDelivered
+	delivered.Attempts = 1
+	delivered.ResponseStatus = 204
+	delivered.NextAttemptAt = nil
+	require.NoError(t, repo.UpdateDelivery(ctx, delivered))
+	assert.Error(t, repo.UpdateDelivery(ctx, model.Delivery{ID: "9"}))
+
+	require.NoError(t, repo.DeleteWebhook(ctx, chat.ID))
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, chat.ID), model.ErrWebhookNotFound)
+
+	// Everything is read back from the file, ids keep going up.
+	repo = NewWebhookRepository(logging.Discard(), dataDir)
+	webhooks, err = repo.GetWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Webhook{*hr}, webhooks)
+	stored, err := repo.GetDeliveries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []model.Delivery{deliveries[1], delivered}, stored)
+	created, err := repo.CreateWebhook(ctx, model.Webhook{URL: "https://new.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "3", created.ID)
+}
+
+func TestPruneDeliveries(t *testing.T) {
+	deliveries := []model.Delivery{{ID: "pending", Status: model.DeliveryPending}}
+	for i := 0; i < deliveriesKept+2; i++ {
+		deliveries = append(deliveries, model.Delivery{ID: string(rune('a' + i%26)), Status: model.DeliveryDelivered})
+	}
+
+	pruned := pruneDeliveries(deliveries)
+	assert.Len(t, pruned, deliveriesKept+1)
+	assert.Equal(t, "pending", pruned[0].ID)
+	assert.Equal(t, "c", pruned[1].ID, "the oldest finished deliveries are dropped")
+}
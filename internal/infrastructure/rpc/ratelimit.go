@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/rpc/quizpb"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// clientErrors are the codes of the calls rejected because of the client,
+// which count as failures towards a login lockout.
+var clientErrors = map[codes.Code]bool{
+	codes.InvalidArgument:    true,
+	codes.FailedPrecondition: true,
+	codes.NotFound:           true,
+	codes.Aborted:            true,
+	codes.ResourceExhausted:  true,
+}
+
+// limitCalls applies the limits of the HTTP API to the same calls over gRPC:
+// logins and answers by client address, answers by quizzer and the lockout
+// of addresses after too many rejected logins.
+func limitCalls(logger *slog.Logger, limits ratelimit.Limits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ip := clientIP(ctx)
+		switch info.FullMethod {
+		case quizpb.UserService_Login_FullMethodName:
+			if wait := limits.Login.Locked(ip); wait > 0 {
+				return nil, tooManyCalls(ctx, wait)
+			}
+			response, err := limitByIP(ctx, limits, ip, request, handler)
+			if clientErrors[status.Code(err)] {
+				if limits.Login.Fail(ip) {
+					logger.WarnContext(ctx, "logins locked out", "ip", ip, "code", status.Code(err).String())
+				}
+			} else if err == nil {
+				limits.Login.Succeed(ip)
+			}
+			return response, err
+
+		case quizpb.UserService_AnswerQuestion_FullMethodName:
+			return limitByIP(ctx, limits, ip, request, func(ctx context.Context, request any) (any, error) {
+				if answer, ok := request.(*quizpb.AnswerQuestionRequest); ok {
+					if allowed, wait := limits.User.Allow(answer.GetUserId()); !allowed {
+						return nil, tooManyCalls(ctx, wait)
+					}
+				}
+				return handler(ctx, request)
+			})
+		}
+		return handler(ctx, request)
+	}
+}
+
+func limitByIP(ctx context.Context, limits ratelimit.Limits, ip string, request any, handler grpc.UnaryHandler) (any, error) {
+	if allowed, wait := limits.IP.Allow(ip); !allowed {
+		return nil, tooManyCalls(ctx, wait)
+	}
+	return handler(ctx, request)
+}
+
+// tooManyCalls rejects a call, telling in the retry-after header how many
+// seconds to wait before trying again.
+func tooManyCalls(ctx context.Context, wait time.Duration) error {
+	seconds := ratelimit.RetryAfter(wait)
+	grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+	return status.Error(codes.ResourceExhausted, fmt.Sprintf("Too many requests, try again in %d seconds", seconds))
+}
+
+// clientIP is the address the call comes from, without its port.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,189 @@
+// Package rpc serves the questions and users of the quiz over gRPC, sharing
+// the usecase layer with the HTTP API.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/rpc/quizpb"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewServer registers the quiz services on a gRPC server logging every call
+// and applying the limits.
+func NewServer(logger *slog.Logger, services usecase.Services, limits ratelimit.Limits) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(logCalls(logger), limitCalls(logger, limits)))
+	quizpb.RegisterQuestionServiceServer(server, &questionServer{questions: services.QuestionService})
+	quizpb.RegisterUserServiceServer(server, &userServer{users: services.UserService})
+	return server
+}
+
+// Serve serves the quiz on the given port until the server is stopped.
+func Serve(logger *slog.Logger, server *grpc.Server, port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	logger.Info("starting grpc server", "port", port)
+	return server.Serve(listener)
+}
+
+type questionServer struct {
+	quizpb.UnimplementedQuestionServiceServer
+	questions *usecase.QuestionService
+}
+
+func (qs *questionServer) ListQuestions(ctx context.Context, request *quizpb.ListQuestionsRequest) (*quizpb.ListQuestionsResponse, error) {
+	questions, err := qs.questions.OrderedQuestions(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &quizpb.ListQuestionsResponse{Questions: make([]*quizpb.Question, len(questions))}
+	for i, question := range questions {
+		response.Questions[i] = toQuestion(question.ID, question)
+	}
+	return response, nil
+}
+
+func (qs *questionServer) GetQuestion(ctx context.Context, request *quizpb.GetQuestionRequest) (*quizpb.Question, error) {
+	question, err := qs.questions.Question(ctx, request.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toQuestion(request.GetId(), *question), nil
+}
+
+type userServer struct {
+	quizpb.UnimplementedUserServiceServer
+	users *usecase.UserService
+}
+
+func (us *userServer) Login(ctx context.Context, request *quizpb.LoginRequest) (*quizpb.LoginResponse, error) {
+	login, err := us.users.StartQuiz(ctx, usecase.LoginRequest{Name: request.GetName()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &quizpb.LoginResponse{UserId: login.UserID}, nil
+}
+
+func (us *userServer) AnswerQuestion(ctx context.Context, request *quizpb.AnswerQuestionRequest) (*quizpb.AnswerQuestionResponse, error) {
+	err := us.users.Answer(ctx, request.GetUserId(), usecase.AnswerRequest{
+		QuestionID: request.GetQuestionId(),
+		OptionID:   request.GetOptionId(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &quizpb.AnswerQuestionResponse{}, nil
+}
+
+func (us *userServer) ListAnswers(ctx context.Context, request *quizpb.ListAnswersRequest) (*quizpb.ListAnswersResponse, error) {
+	answers, err := us.users.AnsweredQuestions(ctx, request.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &quizpb.ListAnswersResponse{Answers: make([]*quizpb.Answer, len(answers))}
+	for i, answer := range answers {
+		response.Answers[i] = &quizpb.Answer{
+			QuestionId: answer.QuestionID,
+			Question:   answer.Question,
+			OptionId:   answer.OptionID,
+			Option:     answer.Option,
+		}
+	}
+	return response, nil
+}
+
+func (us *userServer) FinishQuiz(ctx context.Context, request *quizpb.FinishQuizRequest) (*quizpb.FinishQuizResponse, error) {
+	if err := us.users.FinishQuiz(ctx, request.GetUserId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &quizpb.FinishQuizResponse{}, nil
+}
+
+func (us *userServer) GetScore(ctx context.Context, request *quizpb.GetScoreRequest) (*quizpb.Score, error) {
+	score, err := us.users.Score(ctx, request.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	response := &quizpb.Score{
+		Score:               score.Score,
+		TotalQuestions:      int32(score.TotalQuestions),
+		CorrectAnswers:      int32(score.CorrectAnswers),
+		BetterThan:          score.BetterThan,
+		RelativePerformance: score.RelativePerformance,
+		AnswersDetail:       make([]*quizpb.AnswerDetail, len(score.AnswersDetail)),
+	}
+	for i, detail := range score.AnswersDetail {
+		response.AnswersDetail[i] = &quizpb.AnswerDetail{
+			Question:  detail.Question,
+			Answer:    detail.Answer,
+			IsCorrect: detail.IsCorrect,
+		}
+	}
+	return response, nil
+}
+
+func toQuestion(id string, question usecase.QuestionDTO) *quizpb.Question {
+	response := &quizpb.Question{
+		Id:       id,
+		Label:    question.Label,
+		Position: int32(question.Position),
+		Options:  make([]*quizpb.Option, len(question.Options)),
+	}
+	for i, option := range question.Options {
+		response.Options[i] = &quizpb.Option{Id: option.ID, Label: option.Label}
+	}
+	return response
+}
+
+// statusCodes gives the gRPC code of the HTTP statuses the services answer with.
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusForbidden:           codes.FailedPrecondition,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// toStatus reports an error of the services with the gRPC code matching the
+// HTTP status it is served with.
+func toStatus(err error) error {
+	httpStatus, message := usecase.ErrorStatus(err)
+	code, ok := statusCodes[httpStatus]
+	if !ok {
+		code = codes.Unknown
+	}
+	if message == "" {
+		message = http.StatusText(httpStatus)
+	}
+	return status.Error(code, message)
+}
+
+// logCalls logs every call once served, as the HTTP API logs its requests.
+func logCalls(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		response, err := handler(ctx, request)
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+		logger.Log(ctx, level, "call served",
+			"method", info.FullMethod,
+			"code", code.String(),
+			"duration", time.Since(start),
+		)
+		return response, err
+	}
+}
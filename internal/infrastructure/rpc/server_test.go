@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
+	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/repository"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/rpc/quizpb"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves the quiz from dataDir over an in-process listener and
+// connects to it.
+func newTestClient(t *testing.T, dataDir string, limits ratelimit.Limits) *grpc.ClientConn {
+	logger := logging.Discard()
+	questionRepo, err := repository.NewMemoryQuestionRepository(logger, dataDir, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { questionRepo.Close() })
+	services := usecase.LoadServices(repository.NewUserRepository(logger, dataDir), questionRepo, repository.NewEventRepository(logger, dataDir), nil, nil, webhook.Hosts{}, metrics.NewRegistry(), logger)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(logger, services, limits)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func writeQuestions(t *testing.T, dataDir string, count int) {
+	questions := model.QuestionMap{}
+	for i := 1; i <= count; i++ {
+		questions[fmt.Sprint(i)] = model.Question{
+			Label:    fmt.Sprintf("Question %d?", i),
+			Options:  []model.Option{{ID: "A", Label: "Yes", IsCorrect: true}, {ID: "B", Label: "No"}},
+			Position: count + 1 - i,
+		}
+	}
+	content, err := json.Marshal(questions)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "questions.json"), content, 0644))
+}
+
+func TestQuestionService(t *testing.T) {
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 12)
+	client := quizpb.NewQuestionServiceClient(newTestClient(t, dataDir, ratelimit.Limits{}))
+	ctx := context.Background()
+
+	t.Run("ListQuestions", func(t *testing.T) {
+		response, err := client.ListQuestions(ctx, &quizpb.ListQuestionsRequest{})
+		require.NoError(t, err)
+		require.Len(t, response.Questions, 12)
+		assert.Equal(t, "12", response.Questions[0].Id, "questions are ordered by position")
+		assert.Equal(t, int32(1), response.Questions[0].Position)
+		assert.Equal(t, "11", response.Questions[1].Id)
+		assert.Equal(t, "1", response.Questions[11].Id)
+	})
+
+	t.Run("GetQuestion", func(t *testing.T) {
+		question, err := client.GetQuestion(ctx, &quizpb.GetQuestionRequest{Id: "3"})
+		require.NoError(t, err)
+		assert.Equal(t, "Question 3?", question.Label)
+		require.Len(t, question.Options, 2)
+		assert.Equal(t, "A", question.Options[0].Id)
+		assert.Equal(t, "Yes", question.Options[0].Label)
+	})
+
+	t.Run("GetQuestion Not Found", func(t *testing.T) {
+		_, err := client.GetQuestion(ctx, &quizpb.GetQuestionRequest{Id: "99"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestUserService(t *testing.T) {
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 2)
+	client := quizpb.NewUserServiceClient(newTestClient(t, dataDir, ratelimit.Limits{}))
+	ctx := context.Background()
+
+	login, err := client.Login(ctx, &quizpb.LoginRequest{Name: "Ana"})
+	require.NoError(t, err)
+	userID := login.UserId
+	require.NotEmpty(t, userID)
+
+	_, err = client.AnswerQuestion(ctx, &quizpb.AnswerQuestionRequest{UserId: userID, QuestionId: "1", OptionId: "Z"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "an unknown option is rejected")
+
+	_, err = client.AnswerQuestion(ctx, &quizpb.AnswerQuestionRequest{UserId: userID, QuestionId: "1", OptionId: "A"})
+	require.NoError(t, err)
+
+	_, err = client.FinishQuiz(ctx, &quizpb.FinishQuizRequest{UserId: userID})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "the quiz can't be finished with missing answers")
+
+	_, err = client.AnswerQuestion(ctx, &quizpb.AnswerQuestionRequest{UserId: userID, QuestionId: "2", OptionId: "B"})
+	require.NoError(t, err)
+
+	answers, err := client.ListAnswers(ctx, &quizpb.ListAnswersRequest{UserId: userID})
+	require.NoError(t, err)
+	require.Len(t, answers.Answers, 2)
+	assert.ElementsMatch(t, []string{"1", "2"}, []string{answers.Answers[0].QuestionId, answers.Answers[1].QuestionId})
+
+	_, err = client.FinishQuiz(ctx, &quizpb.FinishQuizRequest{UserId: userID})
+	require.NoError(t, err)
+
+	_, err = client.AnswerQuestion(ctx, &quizpb.AnswerQuestionRequest{UserId: userID, QuestionId: "2", OptionId: "A"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "a finished quiz can't be answered")
+
+	score, err := client.GetScore(ctx, &quizpb.GetScoreRequest{UserId: userID})
+	require.NoError(t, err)
+	assert.Equal(t, float32(0.5), score.Score)
+	assert.Equal(t, int32(2), score.TotalQuestions)
+	assert.Equal(t, int32(1), score.CorrectAnswers)
+	assert.Len(t, score.AnswersDetail, 2)
+
+	_, err = client.ListAnswers(ctx, &quizpb.ListAnswersRequest{UserId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRateLimits(t *testing.T) {
+	dataDir := t.TempDir()
+	writeQuestions(t, dataDir, 2)
+	client := quizpb.NewUserServiceClient(newTestClient(t, dataDir, ratelimit.Limits{
+		IP:   ratelimit.NewLimiter(0.001, 4),
+		User: ratelimit.NewLimiter(0.001, 1),
+	}))
+	ctx := context.Background()
+
+	login, err := client.Login(ctx, &quizpb.LoginRequest{Name: "Ana"})
+	require.NoError(t, err)
+	answer := &quizpb.AnswerQuestionRequest{UserId: login.UserId, QuestionId: "1", OptionId: "A"}
+	_, err = client.AnswerQuestion(ctx, answer)
+	require.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.AnswerQuestion(ctx, answer, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the quizzer used up their answers")
+	assert.Equal(t, []string{"1000"}, header.Get("retry-after"))
+
+	_, err = client.Login(ctx, &quizpb.LoginRequest{Name: "Bruno"})
+	require.NoError(t, err)
+	_, err = client.Login(ctx, &quizpb.LoginRequest{Name: "Carla"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the address used up its calls")
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger, err := New(logs, "json", "info")
+	require.NoError(t, err)
+
+	mux := chi.NewRouter()
+	mux.Use(middleware.RequestID)
+	mux.Post("/users/{user}/answer", func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "dropped")
+		logger.With("attempt", 2).ErrorContext(r.Context(), "updating user")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/users/7/answer", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record), logs.String())
+	assert.Equal(t, "updating user", record["msg"])
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, "/users/{user}/answer", record["route"])
+	assert.Equal(t, "7", record["user_id"])
+	assert.EqualValues(t, 2, record["attempt"])
+
+	logs.Reset()
+	logger.Info("starting web server")
+	assert.Contains(t, logs.String(), `"msg":"starting web server"`)
+	assert.NotContains(t, logs.String(), "request_id")
+}
+
+func TestNew(t *testing.T) {
+	logs := &bytes.Buffer{}
+	logger, err := New(logs, "text", "WARN")
+	require.NoError(t, err)
+	logger.Info("dropped")
+	logger.Warn("kept")
+	assert.NotContains(t, logs.String(), "dropped")
+	assert.Contains(t, logs.String(), "level=WARN msg=kept")
+
+	_, err = New(logs, "xml", "info")
+	assert.ErrorContains(t, err, "unknown log format")
+	_, err = New(logs, "text", "loud")
+	assert.ErrorContains(t, err, "unknown log level")
+}
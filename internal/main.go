@@ -1,23 +1,132 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"sync"
 
+	"github.com/MFCaballero/simple-quiz/internal/config"
+	"github.com/MFCaballero/simple-quiz/internal/domain/model"
 	"github.com/MFCaballero/simple-quiz/internal/domain/usecase"
+	"github.com/MFCaballero/simple-quiz/internal/domain/webhook"
 	"github.com/MFCaballero/simple-quiz/internal/infrastructure/api"
 	"github.com/MFCaballero/simple-quiz/internal/infrastructure/repository"
+	"github.com/MFCaballero/simple-quiz/internal/infrastructure/rpc"
+	"github.com/MFCaballero/simple-quiz/internal/logging"
+	"github.com/MFCaballero/simple-quiz/internal/metrics"
+	"github.com/MFCaballero/simple-quiz/internal/ratelimit"
 )
 
 func main() {
-	logger := log.New(os.Stdout, "[Quiz Logger] ", log.Ldate|log.Ltime)
+	config := config.LoadConfig()
+	logger, err := logging.New(os.Stdout, config.LogFormat, config.LogLevel)
+	if err != nil {
+		log.Fatalf("configuring logs: %v", err)
+	}
 	wg := &sync.WaitGroup{}
-	userRepository := repository.NewUserRepository(logger)
-	questionRepository := repository.NewQuestionRepository(logger)
-	services := usecase.LoadServices(userRepository, questionRepository, logger)
-	app := api.NewApp(logger, wg, services)
+	if err := repository.Migrate(logger, config.DataDir); err != nil {
+		fatal(logger, "migrating data", err)
+	}
+	userRepository, questionRepository := loadRepositories(config, logger)
+	validateQuestions(questionRepository, logger)
+	eventRepository := repository.NewEventRepository(logger, config.DataDir)
+	backupRepository, err := repository.NewBackupRepository(logger, userRepository, questionRepository, eventRepository)
+	if err != nil {
+		fatal(logger, "loading repositories", err)
+	}
+	registry := metrics.NewRegistry()
+	instrumentation := repository.NewInstrumentation(registry)
+	services := usecase.LoadServices(
+		instrumentation.Users(userRepository),
+		instrumentation.Questions(questionRepository),
+		instrumentation.Events(eventRepository),
+		instrumentation.Backups(backupRepository),
+		instrumentation.Webhooks(repository.NewWebhookRepository(logger, config.DataDir)),
+		webhook.Hosts{Allowed: config.WebhookAllowedHosts},
+		registry,
+		logger,
+	)
+	services.SetAnswerPolicy(usecase.AnswerPolicy{
+		LockOnSubmit: config.LockAnswers,
+		MaxChanges:   config.MaxAnswerChanges,
+	})
+	limits := ratelimit.Limits{
+		IP:    ratelimit.NewLimiter(config.IPRateLimit, config.IPRateBurst),
+		User:  ratelimit.NewLimiter(config.UserRateLimit, config.UserRateBurst),
+		Login: ratelimit.NewLockout(config.LoginMaxFailures, config.LoginLockout),
+	}
+	if config.GRPCPort != 0 {
+		go func() {
+			if err := rpc.Serve(logger, rpc.NewServer(logger, services, limits), config.GRPCPort); err != nil {
+				fatal(logger, "serving grpc", err)
+			}
+		}()
+	}
+	if config.AdminToken == "" {
+		logger.Warn("admin routes are disabled, set QUIZ_ADMIN_TOKEN to enable them")
+	}
+	app := api.NewApp(logger, wg, services, registry, limits, config.AdminToken, config.Port)
 	go app.ListenForErrors()
-	go app.ListenForShutdown()
+	go app.ListenForShutdown(closers(userRepository, questionRepository, eventRepository)...)
 	app.Run()
 }
+
+func loadRepositories(config config.Config, logger *slog.Logger) (model.UserRepository, model.QuestionRepository) {
+	questionRepository, err := repository.NewMemoryQuestionRepository(logger, config.DataDir, config.QuestionsPollInterval)
+	if err != nil {
+		fatal(logger, "loading questions", err)
+	}
+
+	switch config.Storage {
+	case "json":
+		return repository.NewUserRepository(logger, config.DataDir), questionRepository
+	case "memory":
+		userRepository, err := repository.NewMemoryUserRepository(logger, config.DataDir, config.CompactInterval)
+		if err != nil {
+			fatal(logger, "loading users", err)
+		}
+		return userRepository, questionRepository
+	}
+	fatal(logger, "loading repositories", fmt.Errorf("unknown storage %q, use json or memory", config.Storage))
+	return nil, nil
+}
+
+// validateQuestions stops the server when the questions can't be served,
+// listing every problem so they can all be fixed at once.
+func validateQuestions(repo model.QuestionRepository, logger *slog.Logger) {
+	questions, err := repo.GetAllQuestions(context.Background())
+	if err != nil {
+		fatal(logger, "loading questions", err)
+	}
+	issues := model.ValidateQuestions(questions)
+	for _, issue := range issues.Warnings() {
+		logger.Warn("question can be improved", "question_id", issue.QuestionID, "rule", issue.Rule, "message", issue.Message)
+	}
+	if errs := issues.Errors(); len(errs) > 0 {
+		for _, issue := range errs {
+			logger.Error("invalid question", "question_id", issue.QuestionID, "rule", issue.Rule, "message", issue.Message)
+		}
+		fatal(logger, "loading questions", fmt.Errorf("questions are invalid: %d errors found", len(errs)))
+	}
+}
+
+// closers returns the repositories that hold files or goroutines to be
+// closed on shutdown.
+func closers(repositories ...any) []io.Closer {
+	var closers []io.Closer
+	for _, repository := range repositories {
+		if closer, ok := repository.(io.Closer); ok {
+			closers = append(closers, closer)
+		}
+	}
+	return closers
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
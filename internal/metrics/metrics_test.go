@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Requests served.", "route", "status")
+	durations := registry.NewHistogramVec("request_duration_seconds", "Time taken.", []float64{1, 0.1}, "route")
+	finished := registry.NewCounter("finished_total", "Finished.")
+	registry.NewGaugeFunc("active", "Active \\ users\nright now.", func() float64 { return 3 })
+
+	requests.With("/users/{user}", "200").Inc()
+	requests.With("/users/{user}", "200").Add(2)
+	requests.With("/a\"b", "404").Inc()
+	durations.With("/").Observe(0.05)
+	durations.With("/").Observe(0.1)
+	durations.With("/").Observe(3)
+	finished.Inc()
+
+	expected := `# HELP active Active \\ users\nright now.
+# TYPE active gauge
+active 3
+# HELP finished_total Finished.
+# TYPE finished_total counter
+finished_total 1
+# HELP request_duration_seconds Time taken.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{route="/",le="0.1"} 2
+request_duration_seconds_bucket{route="/",le="1"} 2
+request_duration_seconds_bucket{route="/",le="+Inf"} 3
+request_duration_seconds_sum{route="/"} 3.15
+request_duration_seconds_count{route="/"} 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a\"b",status="404"} 1
+requests_total{route="/users/{user}",status="200"} 3
+`
+	var out strings.Builder
+	registry.WriteText(&out)
+	assert.Equal(t, expected, out.String())
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewCounter("finished_total", "Finished.").Inc()
+
+	rr := httptest.NewRecorder()
+	registry.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "finished_total 1\n")
+}
+
+func TestRegistryPanics(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Requests served.", "route")
+
+	assert.Panics(t, func() { registry.NewCounter("requests_total", "Again.") })
+	assert.Panics(t, func() { requests.With("/", "200") })
+	assert.Panics(t, func() { requests.With("/").Add(-1) })
+}
@@ -0,0 +1,203 @@
+// Package ratelimit limits how often clients can call the quiz, by keys such
+// as their IP address or their user id, so the APIs can't be scripted to
+// probe answers or flood the quiz with quizzers.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the state of keys that are back to normal is
+// forgotten, so keys seen once don't stay in memory.
+const sweepInterval = time.Minute
+
+// Limiter is a token bucket per key: a key can make burst calls at once,
+// then rate calls per second. A nil Limiter allows every call.
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewLimiter returns a limiter allowing rate calls per second per key, up to
+// burst at once. It returns nil, allowing everything, when rate is not
+// positive.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   math.Max(float64(burst), 1),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token for the key. When there is none left it reports how
+// long until there is one.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(l.burst, b.tokens+now.Sub(b.at).Seconds()*l.rate)
+}
+
+// sweep forgets the buckets that are full again.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Lockout locks a key out for a while after too many failures in a row,
+// failures further apart than the lockout not counting together. A nil
+// Lockout never locks anything.
+type Lockout struct {
+	mu          sync.Mutex
+	maxFailures int
+	duration    time.Duration
+	keys        map[string]*failures
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+type failures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// NewLockout returns a lockout of duration after maxFailures failures. It
+// returns nil, never locking, when maxFailures or duration is not positive.
+func NewLockout(maxFailures int, duration time.Duration) *Lockout {
+	if maxFailures <= 0 || duration <= 0 {
+		return nil
+	}
+	return &Lockout{
+		maxFailures: maxFailures,
+		duration:    duration,
+		keys:        map[string]*failures{},
+		now:         time.Now,
+	}
+}
+
+// Locked reports how long the key is still locked out, zero when it is not.
+func (l *Lockout) Locked(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.keys[key]
+	if !ok {
+		return 0
+	}
+	return max(f.lockedUntil.Sub(l.now()), 0)
+}
+
+// Fail records a failure of the key, reporting whether it locked the key out.
+func (l *Lockout) Fail(key string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	f, ok := l.keys[key]
+	if !ok {
+		f = &failures{}
+		l.keys[key] = f
+	}
+	if now.Sub(f.last) > l.duration {
+		f.count = 0
+	}
+	f.count++
+	f.last = now
+	if f.count < l.maxFailures {
+		return false
+	}
+	f.count = 0
+	f.lockedUntil = now.Add(l.duration)
+	return true
+}
+
+// Succeed forgets the failures of the key, unless it is locked out.
+func (l *Lockout) Succeed(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.keys[key]; ok && !l.now().Before(f.lockedUntil) {
+		delete(l.keys, key)
+	}
+}
+
+// sweep forgets the keys whose failures no longer count.
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, f := range l.keys {
+		if now.Sub(f.last) > l.duration && !now.Before(f.lockedUntil) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// Limits are the limits applied to the clients of the quiz, shared by its
+// APIs. A nil field doesn't limit anything.
+type Limits struct {
+	// IP limits the calls of each client address.
+	IP *Limiter
+	// User limits the answers of each quizzer.
+	User *Limiter
+	// Login locks client addresses out after too many rejected logins.
+	Login *Lockout
+}
+
+// RetryAfter is the number of whole seconds to announce in a Retry-After
+// header for a wait, at least one.
+func RetryAfter(wait time.Duration) int {
+	return max(int(math.Ceil(wait.Seconds())), 1)
+}
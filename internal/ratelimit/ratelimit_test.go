@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a time that only moves when told to.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestLimiter(t *testing.T) {
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(2, 3)
+	limiter.now = c.Now
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("a")
+		assert.True(t, allowed, "the burst is allowed at once")
+	}
+	allowed, wait := limiter.Allow("a")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait, "a token comes back every half second")
+
+	allowed, _ = limiter.Allow("b")
+	assert.True(t, allowed, "keys have their own bucket")
+
+	c.Advance(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("a")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("a")
+	assert.False(t, allowed)
+
+	c.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("a")
+		assert.True(t, allowed, "tokens don't pile up over the burst")
+	}
+	allowed, _ = limiter.Allow("a")
+	assert.False(t, allowed)
+	assert.Len(t, limiter.buckets, 1, "full buckets are forgotten")
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	limiter := NewLimiter(0, 10)
+
+	assert.Nil(t, limiter)
+	allowed, _ := limiter.Allow("a")
+	assert.True(t, allowed)
+}
+
+func TestLockout(t *testing.T) {
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	lockout := NewLockout(3, time.Minute)
+	lockout.now = c.Now
+
+	assert.False(t, lockout.Fail("a"))
+	assert.False(t, lockout.Fail("a"))
+	lockout.Succeed("a")
+	assert.False(t, lockout.Fail("a"), "a success forgets the failures")
+	assert.False(t, lockout.Fail("a"))
+
+	c.Advance(2 * time.Minute)
+	assert.False(t, lockout.Fail("a"), "old failures don't count")
+	assert.False(t, lockout.Fail("a"))
+	assert.True(t, lockout.Fail("a"))
+	assert.Equal(t, time.Minute, lockout.Locked("a"))
+	assert.Zero(t, lockout.Locked("b"))
+
+	lockout.Succeed("a")
+	c.Advance(45 * time.Second)
+	assert.Equal(t, 15*time.Second, lockout.Locked("a"), "a success doesn't lift a lockout")
+
+	c.Advance(15 * time.Second)
+	assert.Zero(t, lockout.Locked("a"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 1, RetryAfter(0))
+	assert.Equal(t, 1, RetryAfter(300*time.Millisecond))
+	assert.Equal(t, 3, RetryAfter(2100*time.Millisecond))
+}